@@ -13,6 +13,15 @@ if [ ! -d "output" ]; then
     echo "Output dir does not exist, please run build script first."
 fi
 
+migrate_file="$service_directory/migrate/MigrateService"
+if [[ -x "$migrate_file" ]]; then
+    echo "Running $migrate_file"
+    if ! ./"$migrate_file" up >> "$log_directory"/MigrateService.log 2>&1; then
+        echo "Failed to migrate the database, please check $log_directory/MigrateService.log"
+        exit 1
+    fi
+fi
+
 for gateway_file in "$gateway_directory"/*; do
     if [[ -x "$gateway_file" && -f "$gateway_file" ]]; then
         echo "Running $gateway_file"
@@ -22,6 +31,9 @@ for gateway_file in "$gateway_directory"/*; do
 done
 
 for service in "$service_directory"/*; do
+    if [ "$(basename "$service")" == "migrate" ]; then
+        continue
+    fi
     for service_file in "$service"/*; do
         if [[ -x "$service_file" && -f "$service_file" ]]; then
             echo "Running $service_file"
@@ -1,9 +1,11 @@
 package config
 
 import (
+	"errors"
 	"github.com/caarlos0/env/v6"
 	"github.com/joho/godotenv"
 	log "github.com/sirupsen/logrus"
+	"io/fs"
 )
 
 var EnvCfg envConfig
@@ -47,20 +49,42 @@ type envConfig struct {
 	PostgreSQLReplicaAddress  string  `env:"POSTGRESQL_REPLICA_ADDR"`
 	PostgreSQLReplicaUsername string  `env:"POSTGRESQL_REPLICA_USER"`
 	PostgreSQLReplicaPassword string  `env:"POSTGRESQL_REPLICA_PASSWORD"`
+	PostgreSQLReplicaMaxLag   string  `env:"POSTGRESQL_REPLICA_MAX_LAG" envDefault:"5s"`
+	PostgreSQLReplicaCheck    string  `env:"POSTGRESQL_REPLICA_CHECK_INTERVAL" envDefault:"1s"`
+	PostgreSQLPrimaryPin      string  `env:"POSTGRESQL_PRIMARY_PIN_AFTER_WRITE" envDefault:"5s"`
 	OtelState                 string  `env:"TRACING_STATE" envDefault:"enable"`
 	OtelSampler               float64 `env:"TRACING_SAMPLER" envDefault:"0.01"`
 	AnonymityUser             string  `env:"ANONYMITY_USER" envDefault:"114514"`
 	ElasticsearchUrl          string  `env:"ES_ADDR"`
+	StorageGCCron             string  `env:"STORAGE_GC_CRON" envDefault:"0 30 3 * * *"`
+	StorageGCGracePeriod      string  `env:"STORAGE_GC_GRACE_PERIOD" envDefault:"24h"`
+	StorageGCDryRun           string  `env:"STORAGE_GC_DRY_RUN" envDefault:"disable"`
+	CachePolicies             string  `env:"CACHE_POLICIES" envDefault:""`
+	FavoriteReconcileCron     string  `env:"FAVORITE_RECONCILE_CRON" envDefault:"0 15 * * * *"`
+	OutboxPollInterval        string  `env:"OUTBOX_POLL_INTERVAL" envDefault:"1s"`
+	OutboxBatchSize           int     `env:"OUTBOX_BATCH_SIZE" envDefault:"100"`
+	OutboxMaxAttempts         int     `env:"OUTBOX_MAX_ATTEMPTS" envDefault:"20"`
+	OutboxRetention           string  `env:"OUTBOX_RETENTION" envDefault:"72h"`
+	ModerationWordsFile       string  `env:"MODERATION_WORDS_FILE" envDefault:""`
+	ModerationAllowDomains    string  `env:"MODERATION_ALLOW_DOMAINS" envDefault:""`
+	ModerationLLMState        string  `env:"MODERATION_LLM_STATE" envDefault:"enable"`
+	CommentEditWindow         string  `env:"COMMENT_EDIT_WINDOW" envDefault:"15m"`
 }
 
 func init() {
-	if err := godotenv.Load(); err != nil {
+	// 解析失败时保留已经解析的字段与默认值，由使用者在连接时报告具体的错误
+	if err := Load(); err != nil {
+		log.Errorf("Can not parse env from file system, please check the env: %v", err)
+	}
+}
+
+// Load 读取当前目录下的 .env 文件与环境变量并重新设置 EnvCfg，.env 文件不存在时只使用环境变量。
+// 服务启动时由 init 调用，测试可以在修改环境变量后再次调用
+func Load() error {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
 		log.Errorf("Can not read env from file system, please check the right this program owned.")
 	}
 
 	EnvCfg = envConfig{}
-
-	if err := env.Parse(&EnvCfg); err != nil {
-		panic("Can not parse env from file system, please check the env.")
-	}
+	return env.Parse(&EnvCfg)
 }
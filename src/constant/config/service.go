@@ -30,13 +30,23 @@ const RelationRpcServerPort = ":37008"
 const RecommendRpcServiceName = "GuGoTik-Recommend"
 const RecommendRpcServicePort = ":37009"
 
+const CollectionRpcServerName = "GuGoTik-CollectionService"
+const CollectionRpcServerPort = ":37010"
+
+const ModerationRpcServerName = "GuGoTik-ModerationService"
+const ModerationRpcServerPort = ":37011"
+
 const Metrics = ":37099"
 const VideoProcessorRpcServiceName = "GuGoTik-VideoProcessorService"
 
 const VideoPicker = "GuGoTik-VideoPicker"
 const Event = "GuGoTik-Recommend"
 const MsgConsumer = "GuGoTik-MgsConsumer"
+const StorageGC = "GuGoTik-StorageGC"
+const Migrate = "GuGoTik-Migrate"
+const OutboxRelay = "GuGoTik-OutboxRelay"
 
 const BloomRedisChannel = "GuGoTik-Bloom"
+const CacheInvalidationRedisChannel = "GuGoTik-CacheInvalidation"
 
 const MaxVideoSize = 200 * 1024 * 1024
@@ -60,6 +60,10 @@ const (
 	UnableToGetFriendListError       = "无法查询到好友列表"
 	RecommendServiceInnerErrorCode   = 50025
 	RecommendServiceInnerError       = "推荐系统内部错误"
+	CollectionServiceInnerErrorCode  = 50026
+	CollectionServiceInnerError      = "收藏夹服务内部错误"
+	ModerationServiceInnerErrorCode  = 50027
+	ModerationServiceInnerError      = "审核服务内部错误"
 )
 
 // Expected Error
@@ -92,4 +96,64 @@ const (
 	UserDoNotExisted              = "查询用户不存在！"
 	OversizeVideoCode             = 10014
 	OversizeVideo                 = "上传视频超过了200MB"
+	FavoriteReactionInvalidCode   = 10015
+	FavoriteReactionInvalid       = "不支持的回应类型"
+	CollectionNotFoundCode        = 10016
+	CollectionNotFound            = "收藏夹不存在"
+	CollectionNameInvalidCode     = 10017
+	CollectionNameInvalid         = "收藏夹名称不能为空且不能超过64个字符"
+	CollectionNameExistedCode     = 10018
+	CollectionNameExisted         = "已经存在同名的收藏夹"
+	CollectionLimitedCode         = 10019
+	CollectionLimited             = "收藏夹数量已达上限"
+	CollectionVideoExistedCode    = 10020
+	CollectionVideoExisted        = "视频已经在收藏夹中"
+	CollectionVideoNotFoundCode   = 10021
+	CollectionVideoNotFound       = "视频不在收藏夹中"
+	FavoriteLikersForbiddenCode   = 10022
+	FavoriteLikersForbidden       = "只有视频作者可以查看点赞的用户"
+	CommentParentNotFoundCode     = 10023
+	CommentParentNotFound         = "回复的评论不存在或已被删除"
+	CommentNotFoundCode           = 10024
+	CommentNotFound               = "评论不存在或已被删除"
+	CommentLikeDuplicateCode      = 10025
+	CommentLikeDuplicate          = "不能重复点赞评论"
+	CommentLikeCancelCode         = 10026
+	CommentLikeCancel             = "没有点赞该评论，不能取消点赞"
+	CommentSortInvalidCode        = 10027
+	CommentSortInvalid            = "不支持的评论排序方式"
+	CommentRejectedCode           = 10028
+	CommentRejected               = "评论包含违规内容，无法发布"
+	ModerationForbiddenCode       = 10029
+	ModerationForbidden           = "只有审核员可以执行该操作"
+	ModerationItemTypeInvalidCode = 10030
+	ModerationItemTypeInvalid     = "不支持的审核内容类型"
+	ModerationReviewNotFoundCode  = 10031
+	ModerationReviewNotFound      = "审核记录不存在"
+	ModerationReviewHandledCode   = 10032
+	ModerationReviewHandled       = "该内容已经处理过"
+	UserBannedCode                = 10033
+	UserBanned                    = "账号已被封禁，暂时无法发布内容"
+	CommentEditExpiredCode        = 10034
+	CommentEditExpired            = "评论发布时间过久，已无法编辑"
+	CommentPinInvalidCode         = 10035
+	CommentPinInvalid             = "只能置顶视频下可见的顶层评论"
+	CommentPinForbiddenCode       = 10036
+	CommentPinForbidden           = "只有视频作者可以置顶评论"
+	UserBlockedCode               = 10037
+	UserBlocked                   = "你与对方存在拉黑关系，无法进行该操作"
+	AlreadyBlockedCode            = 10038
+	AlreadyBlocked                = "已经拉黑该用户"
+	BlockNotFoundCode             = 10039
+	BlockNotFound                 = "没有拉黑该用户"
+	AccountPrivateCode            = 10040
+	AccountPrivate                = "该账号为私密账号，关注后才能查看"
+	FollowRequestPendingCode      = 10041
+	FollowRequestPending          = "已经发送过关注请求，请等待对方同意"
+	FollowRequestNotFoundCode     = 10042
+	FollowRequestNotFound         = "关注请求不存在"
+	CommentUnderReviewCode        = 10043
+	CommentUnderReview            = "评论正在审核或已被驳回，无法编辑"
+	ContentRejectedCode           = 10044
+	ContentRejected               = "内容包含违规内容，无法发布"
 )
@@ -2,20 +2,22 @@ package strings
 
 // Exchange name
 const (
-	VideoExchange   = "video_exchange"
-	EventExchange   = "event"
-	MessageExchange = "message_exchange"
-	AuditExchange   = "audit_exchange"
+	VideoExchange        = "video_exchange"
+	EventExchange        = "event"
+	MessageExchange      = "message_exchange"
+	AuditExchange        = "audit_exchange"
+	NotificationExchange = "notification_exchange"
 )
 
 // Queue name
 const (
-	VideoPicker   = "video_picker"
-	VideoSummary  = "video_summary"
-	MessageCommon = "message_common"
-	MessageGPT    = "message_gpt"
-	MessageES     = "message_es"
-	AuditPicker   = "audit_picker"
+	VideoPicker        = "video_picker"
+	VideoSummary       = "video_summary"
+	MessageCommon      = "message_common"
+	MessageGPT         = "message_gpt"
+	MessageES          = "message_es"
+	AuditPicker        = "audit_picker"
+	NotificationPicker = "notification_picker"
 )
 
 // Routing key
@@ -28,27 +30,37 @@ const (
 	MessageActionEvent    = "message.common"
 	MessageGptActionEvent = "message.gpt"
 	AuditPublishEvent     = "audit"
+
+	NotificationMentionEvent = "notification.mention"
 )
 
 // Action Type
 const (
-	FavoriteIdActionLog = 1 // 用户点赞相关操作
-	FollowIdActionLog   = 2 // 用户关注相关操作
+	FavoriteIdActionLog   = 1 // 用户点赞相关操作
+	FollowIdActionLog     = 2 // 用户关注相关操作
+	ModerationIdActionLog = 3 // 审核员处理内容与封禁用户
 )
 
 // Action Name
 const (
-	FavoriteNameActionLog    = "favorite.action" // 用户点赞操作名称
-	FavoriteUpActionSubLog   = "up"
-	FavoriteDownActionSubLog = "down"
+	FavoriteNameActionLog     = "favorite.action" // 用户点赞操作名称
+	FavoriteUpActionSubLog    = "up"
+	FavoriteDownActionSubLog  = "down"
+	FavoriteReactActionSubLog = "react"
 
 	FollowNameActionLog    = "follow.action" // 用户关注操作名称
 	FollowUpActionSubLog   = "up"
 	FollowDownActionSubLog = "down"
+
+	ModerationNameActionLog       = "moderation.action" // 审核操作名称
+	ModerationApproveActionSubLog = "approve"
+	ModerationRejectActionSubLog  = "reject"
+	ModerationBanActionSubLog     = "ban"
 )
 
 // Action Service Name
 const (
-	FavoriteServiceName = "FavoriteService"
-	FollowServiceName   = "FollowService"
+	FavoriteServiceName   = "FavoriteService"
+	FollowServiceName     = "FollowService"
+	ModerationServiceName = "ModerationService"
 )
@@ -15,7 +15,8 @@ import (
 	trace2 "go.opentelemetry.io/otel/trace"
 )
 
-var Tracer trace2.Tracer
+// Tracer 没有调用 SetTraceProvider 时使用全局的 TracerProvider，默认不记录任何 Span，便于在测试中使用
+var Tracer trace2.Tracer = otel.Tracer("GuGoTik")
 
 func SetTraceProvider(name string) (*trace.TracerProvider, error) {
 	client := otlptracehttp.NewClient(
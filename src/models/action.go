@@ -1,7 +1,6 @@
 package models
 
 import (
-	"GuGoTik/src/storage/database"
 	"gorm.io/gorm"
 )
 
@@ -21,9 +20,3 @@ type Action struct {
 	SpanId       string // 这个操作的 SpanId
 	gorm.Model          //数据库模型
 }
-
-func init() {
-	if err := database.Client.AutoMigrate(&Action{}); err != nil {
-		panic(err)
-	}
-}
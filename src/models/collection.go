@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Collection 用户创建的收藏夹，与点赞记录相互独立
+type Collection struct {
+	ID        uint32 `gorm:"not null;primaryKey;autoIncrement"`                                           // 收藏夹 ID
+	UserId    uint32 `json:"user_id" column:"user_id" gorm:"not null;uniqueIndex:collection_user_name"`   // 创建者 ID
+	Name      string `json:"name" column:"name" gorm:"not null;size:64;uniqueIndex:collection_user_name"` // 收藏夹名称，同一个用户下不能重复
+	Private   bool   `json:"private" column:"private" gorm:"not null;default:false"`                      // 私密收藏夹只有创建者可见
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// CollectionItem 收藏夹中的视频，按 Position 升序排列
+type CollectionItem struct {
+	ID           uint32    `gorm:"not null;primaryKey;autoIncrement"`
+	CollectionId uint32    `json:"collection_id" column:"collection_id" gorm:"not null;uniqueIndex:collection_item_video;index:collection_item_position,priority:1"`
+	VideoId      uint32    `json:"video_id" column:"video_id" gorm:"not null;uniqueIndex:collection_item_video"`
+	Position     int64     `json:"position" column:"position" gorm:"not null;index:collection_item_position,priority:2"` // 排序位置，相邻位置之间留有间隔，移动时只需要修改一条记录
+	CreatedAt    time.Time // 收藏时间
+}
@@ -1,18 +1,25 @@
 package models
 
 import (
-	"GuGoTik/src/storage/database"
 	"gorm.io/gorm"
+	"time"
 )
 
 type Comment struct {
-	ID                uint32 `gorm:"not null;primaryKey;autoIncrement"`                              // 评论 ID
-	VideoId           uint32 `json:"video_id" column:"video_id" gorm:"not null;index:comment_video"` // 视频 ID
-	UserId            uint32 `json:"user_id" column:"user_id" gorm:"not null"`                       // 用户 ID
-	Content           string `json:"content" column:"content"`                                       // 评论内容
-	Rate              uint32 `gorm:"index:comment_video"`                                            // 记录评论的评分
-	Reason            string // 存储评论的原因或理由
-	ModerationFlagged bool   // 评论是否被标记为需要审核
+	ID                uint32     `gorm:"not null;primaryKey;autoIncrement;index:comment_root,priority:2"`                  // 评论 ID，同一楼层的回复按 ID 顺序分页
+	VideoId           uint32     `json:"video_id" column:"video_id" gorm:"not null;index:comment_video"`                   // 视频 ID
+	UserId            uint32     `json:"user_id" column:"user_id" gorm:"not null"`                                         // 用户 ID
+	ParentId          uint32     `json:"parent_id" column:"parent_id" gorm:"not null;default:0;index:comment_parent"`      // 回复的评论 ID，顶层评论为 0
+	RootId            uint32     `json:"root_id" column:"root_id" gorm:"not null;default:0;index:comment_root,priority:1"` // 所属的顶层评论 ID，顶层评论为 0
+	Tombstoned        bool       `json:"tombstoned" column:"tombstoned" gorm:"not null;default:false"`                     // 已删除但仍有回复，只保留占位
+	LikeCount         uint32     `json:"like_count" column:"like_count" gorm:"not null;default:0"`                         // 点赞数量，与 comment_likes 在同一个事务中修改
+	Content           string     `json:"content" column:"content"`                                                         // 评论内容
+	EditedAt          *time.Time // 最后一次编辑的时间，未编辑过时为空
+	Rate              uint32     `gorm:"index:comment_video"` // 记录评论的评分
+	Reason            string     // 存储评论的原因或理由
+	ModerationFlagged bool       // 评论是否被标记为需要审核
+	// 审核结论，取值见 moderation.Decision，只有 Allow 的评论会被展示
+	ModerationDecision uint32 `json:"moderation_decision" column:"moderation_decision" gorm:"not null;default:0"`
 
 	// 这些字段用于表示评论是否包含特定类型的内容，如仇恨言论、威胁性言论、自残内容、性内容、未成年人性内容、暴力内容等
 	ModerationHate            bool
@@ -25,8 +32,25 @@ type Comment struct {
 	gorm.Model
 }
 
-func init() {
-	if err := database.Client.AutoMigrate(&Comment{}); err != nil {
-		panic(err)
-	}
+// CommentLike 用户对评论的点赞记录
+type CommentLike struct {
+	ID        uint32    `gorm:"not null;primaryKey;autoIncrement"`                                                    // 点赞 ID
+	CommentId uint32    `json:"comment_id" column:"comment_id" gorm:"not null;uniqueIndex:comment_like_comment_user"` // 评论 ID
+	UserId    uint32    `json:"user_id" column:"user_id" gorm:"not null;uniqueIndex:comment_like_comment_user"`       // 点赞的用户 ID
+	CreatedAt time.Time // 点赞时间
+}
+
+// CommentRevision 评论被编辑前的内容，每次编辑保存一条
+type CommentRevision struct {
+	ID        uint32    `gorm:"not null;primaryKey;autoIncrement;index:comment_revision_comment,priority:2"`              // 修订 ID
+	CommentId uint32    `json:"comment_id" column:"comment_id" gorm:"not null;index:comment_revision_comment,priority:1"` // 评论 ID
+	Content   string    `json:"content" column:"content"`                                                                 // 编辑前的内容
+	CreatedAt time.Time // 编辑时间
+}
+
+// CommentPin 视频作者置顶的评论，每个视频最多一条
+type CommentPin struct {
+	VideoId   uint32    `gorm:"not null;primaryKey;autoIncrement:false"`                                  // 视频 ID
+	CommentId uint32    `json:"comment_id" column:"comment_id" gorm:"not null;index:comment_pin_comment"` // 置顶的顶层评论 ID
+	CreatedAt time.Time // 置顶时间
 }
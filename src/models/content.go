@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// ContentObject 按内容寻址保存的上传文件，相同内容的多次上传共用同一个文件
+type ContentObject struct {
+	Checksum  string `gorm:"not null;primaryKey;size:64"` // 文件内容的 SHA-256
+	FileName  string `gorm:"not null"`                    // 存储中的文件名
+	Size      int64  // 文件大小
+	RefCount  int64  `gorm:"not null;default:0"` // 引用该文件的 RawVideo 数量
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
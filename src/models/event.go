@@ -3,10 +3,21 @@ package models
 type RecommendEvent struct {
 	Type     int      // 1. 已读 2. 喜欢 3. 插入新数据
 	Source   string   // 来源
-	Slice    string   // 附带信息
+	Slice    string   // 附带信息，类型 2 中为推荐系统的反馈类型，为空时按照来源决定
 	ActorId  uint32   // 执行操作的用户 ID
 	VideoId  []uint32 // 代表视频 Id，可以批量操作，但是仅对于某一个唯一的用户
 	Tag      []string // 插入时使用
 	Category []string // 插入时使用
 	Title    string
 }
+
+// NotificationEvent 通过 notification_exchange 投递的通知事件
+type NotificationEvent struct {
+	EventId  string // 事件 ID，消费者据此去重
+	Type     string // 通知类型，如 NotificationMention
+	ActorId  uint32 // 触发通知的用户
+	UserId   uint32 // 接收通知的用户
+	ItemType uint32 // 关联内容的类型
+	ItemId   uint32 // 关联内容的 ID
+	VideoId  uint32 // 评论所在的视频，其他内容为 0
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// Favorite 用户对视频的点赞记录，Redis 中的点赞集合与计数都可以由该表重建
+type Favorite struct {
+	ID        uint32    `gorm:"not null;primaryKey;autoIncrement;index:favorite_video_id,priority:2"`                                          // 点赞 ID，同一个视频的点赞按 ID 倒序分页
+	UserId    uint32    `json:"user_id" column:"user_id" gorm:"not null;uniqueIndex:favorite_user_video"`                                      // 点赞的用户 ID
+	VideoId   uint32    `json:"video_id" column:"video_id" gorm:"not null;uniqueIndex:favorite_user_video;index:favorite_video_id,priority:1"` // 视频 ID
+	AuthorId  uint32    `json:"author_id" column:"author_id" gorm:"not null;index:favorite_author"`                                            // 视频作者 ID，用于重建作者的获赞总数
+	Reaction  uint32    `json:"reaction" column:"reaction" gorm:"not null;default:1"`                                                          // 回应类型，取值见 favorite.ReactionType，普通点赞为 1
+	CreatedAt time.Time // 点赞时间，即 user_like 集合中的分数
+}
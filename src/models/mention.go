@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// 出现 @ 的内容类型
+const (
+	MentionInComment uint32 = 1
+	MentionInMessage uint32 = 2
+)
+
+// Mention 评论或消息中的一次 @，Start 与 Length 以 Unicode 字符计
+type Mention struct {
+	ID        uint32 `gorm:"not null;primaryKey;autoIncrement;index:mention_user,priority:2"`
+	ItemType  uint32 `json:"item_type" column:"item_type" gorm:"not null;index:mention_item,priority:1"` // 内容类型
+	ItemId    uint32 `json:"item_id" column:"item_id" gorm:"not null;index:mention_item,priority:2"`     // 评论或消息的 ID
+	AuthorId  uint32 `json:"author_id" column:"author_id" gorm:"not null"`                               // 内容的作者
+	UserId    uint32 `json:"user_id" column:"user_id" gorm:"not null;index:mention_user,priority:1"`     // 被 @ 的用户
+	UserName  string `json:"user_name" column:"user_name" gorm:"not null"`                               // @ 时使用的用户名
+	Start     uint32 `json:"start" column:"start" gorm:"not null"`                                       // @ 在内容中的位置
+	Length    uint32 `json:"length" column:"length" gorm:"not null"`                                     // 包含 @ 在内的长度
+	CreatedAt time.Time
+}
+
+// 通知的类型
+const (
+	NotificationMention = "mention"
+)
+
+// Notification 发给用户的通知，由 MsgConsumer 消费通知事件后写入
+type Notification struct {
+	ID        uint32 `gorm:"not null;primaryKey;autoIncrement;index:notification_user,priority:2"`
+	UserId    uint32 `json:"user_id" column:"user_id" gorm:"not null;index:notification_user,priority:1"` // 接收通知的用户
+	ActorId   uint32 `json:"actor_id" column:"actor_id" gorm:"not null"`                                  // 触发通知的用户
+	Type      string `json:"type" column:"type" gorm:"not null"`                                          // 通知类型
+	ItemType  uint32 `json:"item_type" column:"item_type" gorm:"not null"`                                // 关联内容的类型
+	ItemId    uint32 `json:"item_id" column:"item_id" gorm:"not null"`                                    // 关联内容的 ID
+	VideoId   uint32 `json:"video_id" column:"video_id" gorm:"not null;default:0"`                        // 评论所在的视频，其他内容为 0
+	EventId   string `json:"event_id" column:"event_id" gorm:"not null;uniqueIndex:notification_event"`   // 事件 ID，重复投递时只写入一次
+	Read      bool   `json:"read" column:"read" gorm:"not null;default:false"`                            // 是否已读
+	CreatedAt time.Time
+}
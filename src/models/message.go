@@ -1,18 +1,19 @@
 package models
 
 import (
-	"GuGoTik/src/storage/database"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Message struct {
-	ID             uint32 `gorm:"not null;primarykey;autoIncrement"`
-	ToUserId       uint32 `gorm:"not null"`                        // 接收者用户 ID
-	FromUserId     uint32 `gorm:"not null"`                        // 发送者用户 ID
-	ConversationId string `gorm:"not null" index:"conversationid"` // 标识消息所属的对话
-	Content        string `gorm:"not null"`                        // 存储消息的文本内容
+	ID             uint32    `gorm:"not null;primarykey;autoIncrement"`
+	ToUserId       uint32    `gorm:"not null"`                        // 接收者用户 ID
+	FromUserId     uint32    `gorm:"not null"`                        // 发送者用户 ID
+	ConversationId string    `gorm:"not null" index:"conversationid"` // 标识消息所属的对话
+	Content        string    `gorm:"not null"`                        // 存储消息的文本内容
+	Mentions       []Mention `gorm:"-"`                               // 消息中的 @，由 MessageService 解析后随消息一起投递
+	ReviewReason   string    `gorm:"-"`                               // 本地审核要求人工复核的原因，不为空时随消息一起加入复核队列
 
 	// Create_time  time.Time `gorm:"not null"`
 	// Updatetime deleteTime
@@ -27,9 +28,3 @@ type EsMessage struct {
 	Content        string    `json:"content"`
 	CreateTime     time.Time `json:"createTime"`
 }
-
-func init() {
-	if err := database.Client.AutoMigrate(&Message{}); err != nil {
-		panic(err)
-	}
-}
@@ -0,0 +1,43 @@
+package models
+
+import "time"
+
+// 待审核内容的类型，与 moderation.ItemType 的取值一致
+const (
+	ModerationItemComment uint32 = 1
+	ModerationItemVideo   uint32 = 2
+	ModerationItemMessage uint32 = 3
+)
+
+// 审核记录的状态，与 moderation.ReviewStatus 的取值一致
+const (
+	ModerationReviewPending  uint32 = 0
+	ModerationReviewApproved uint32 = 1
+	ModerationReviewRejected uint32 = 2
+)
+
+// ModerationReview 等待人工复核的内容，每个内容只有一条记录，重新被标记时回到待审核状态
+type ModerationReview struct {
+	ID           uint32     `gorm:"not null;primaryKey;autoIncrement;index:moderation_review_pending,priority:3"`                                               // 审核记录 ID
+	ItemType     uint32     `json:"item_type" column:"item_type" gorm:"not null;uniqueIndex:moderation_review_item;index:moderation_review_pending,priority:2"` // 内容类型
+	ItemId       uint32     `json:"item_id" column:"item_id" gorm:"not null;uniqueIndex:moderation_review_item"`                                                // 内容 ID
+	AuthorId     uint32     `json:"author_id" column:"author_id" gorm:"not null"`                                                                               // 内容的作者
+	Content      string     `json:"content" column:"content"`                                                                                                   // 提交审核时的内容快照
+	Reason       string     `json:"reason" column:"reason"`                                                                                                     // 被标记的原因
+	Status       uint32     `json:"status" column:"status" gorm:"not null;default:0;index:moderation_review_pending,priority:1"`                                // 审核状态
+	ReviewerId   uint32     `json:"reviewer_id" column:"reviewer_id" gorm:"not null;default:0"`                                                                 // 处理的审核员
+	ReviewReason string     `json:"review_reason" column:"review_reason"`                                                                                       // 审核员填写的处理原因
+	ReviewedAt   *time.Time // 处理时间
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+// UserBan 用户封禁记录，封禁期间不能发布评论、视频与消息
+type UserBan struct {
+	ID          uint32    `gorm:"not null;primaryKey;autoIncrement"`
+	UserId      uint32    `json:"user_id" column:"user_id" gorm:"not null;index:user_ban_user"` // 被封禁的用户
+	ModeratorId uint32    `json:"moderator_id" column:"moderator_id" gorm:"not null"`           // 执行封禁的审核员
+	Reason      string    `json:"reason" column:"reason"`                                       // 封禁原因
+	Until       time.Time `json:"until" column:"until" gorm:"not null;index:user_ban_user"`     // 封禁截止时间
+	CreatedAt   time.Time
+}
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+const (
+	OutboxPending = "pending" // 等待投递或等待重试
+	OutboxSent    = "sent"    // 已经被 RabbitMQ 确认
+	OutboxDead    = "dead"    // 超过最大重试次数，需要人工处理
+)
+
+// OutboxMessage 与业务变更在同一个事务中写入的待投递消息，由 OutboxRelay 投递到 RabbitMQ
+type OutboxMessage struct {
+	ID            uint64     `gorm:"primaryKey"`
+	Exchange      string     `gorm:"not null"` // 投递的 Exchange
+	RoutingKey    string     `gorm:"not null"` // 投递的 RoutingKey
+	Body          []byte     `gorm:"not null"` // 消息内容
+	Headers       string     // JSON 编码的 AMQP Headers，用于传递 Trace 上下文
+	Status        string     `gorm:"not null;default:pending"` // pending/sent/dead
+	Attempts      int        `gorm:"not null;default:0"`       // 已经尝试投递的次数
+	NextAttemptAt time.Time  `gorm:"not null"`                 // 下一次可以投递的时间
+	LastError     string     // 最近一次投递失败的原因
+	SentAt        *time.Time // 被确认的时间
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
@@ -1,21 +1,15 @@
 package models
 
 import (
-	"GuGoTik/src/storage/database"
 	"gorm.io/gorm"
 )
 
 type RawVideo struct {
-	ActorId   uint32
-	VideoId   uint32 `gorm:"not null;primaryKey"`
-	Title     string
-	FileName  string
-	CoverName string
+	ActorId     uint32
+	VideoId     uint32 `gorm:"not null;primaryKey"`
+	Title       string
+	FileName    string
+	CoverName   string
+	ContentHash string `gorm:"size:64"` // 原始视频内容的 SHA-256
 	gorm.Model
 }
-
-func init() {
-	if err := database.Client.AutoMigrate(&RawVideo{}); err != nil {
-		panic(err)
-	}
-}
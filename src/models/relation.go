@@ -1,8 +1,8 @@
 package models
 
 import (
-	"GuGoTik/src/storage/database"
 	"gorm.io/gorm"
+	"time"
 )
 
 type Relation struct {
@@ -12,8 +12,26 @@ type Relation struct {
 	gorm.Model
 }
 
-func init() {
-	if err := database.Client.AutoMigrate(&Relation{}); err != nil {
-		panic(err)
-	}
+// UserBlock 拉黑记录，拉黑后双方互相不可见，也不能关注、私信与评论对方
+type UserBlock struct {
+	ID        uint32 `gorm:"not null;primaryKey;autoIncrement"`
+	ActorId   uint32 `json:"actor_id" column:"actor_id" gorm:"not null;uniqueIndex:user_block_pair"`                     // 执行拉黑的用户
+	UserId    uint32 `json:"user_id" column:"user_id" gorm:"not null;uniqueIndex:user_block_pair;index:user_block_user"` // 被拉黑的用户
+	CreatedAt time.Time
+}
+
+// UserMute 屏蔽记录，只对执行屏蔽的用户隐藏对方的视频与评论，对方不会感知
+type UserMute struct {
+	ID        uint32 `gorm:"not null;primaryKey;autoIncrement"`
+	ActorId   uint32 `json:"actor_id" column:"actor_id" gorm:"not null;uniqueIndex:user_mute_pair"` // 执行屏蔽的用户
+	UserId    uint32 `json:"user_id" column:"user_id" gorm:"not null;uniqueIndex:user_mute_pair"`   // 被屏蔽的用户
+	CreatedAt time.Time
+}
+
+// FollowRequest 关注私密账号时等待对方同意的请求
+type FollowRequest struct {
+	ID        uint32 `gorm:"not null;primaryKey;autoIncrement;index:follow_request_user,priority:2"`
+	ActorId   uint32 `json:"actor_id" column:"actor_id" gorm:"not null;uniqueIndex:follow_request_pair"`                                    // 发出请求的用户
+	UserId    uint32 `json:"user_id" column:"user_id" gorm:"not null;uniqueIndex:follow_request_pair;index:follow_request_user,priority:1"` // 私密账号
+	CreatedAt time.Time
 }
@@ -1,20 +1,26 @@
 package models
 
 import (
-	"GuGoTik/src/storage/cached"
-	"GuGoTik/src/storage/database"
 	"gorm.io/gorm"
 	"regexp"
 )
 
+// 用户角色
+const (
+	UserRoleNormal    = 1 // 普通用户
+	UserRoleMagic     = 2 // 内置的 ChatGPT 用户
+	UserRoleModerator = 3 // 审核员，可以调用 ModerationService
+)
+
 type User struct {
 	ID              uint32 `gorm:"not null;primarykey;autoIncrement"`               //用户 Id
 	UserName        string `gorm:"not null;unique;size: 32;index" redis:"UserName"` // 用户名
 	Password        string `gorm:"not null" redis:"Password"`                       // 密码
-	Role            int    `gorm:"default:1" redis:"Role"`                          // 角色
+	Role            int    `gorm:"default:1" redis:"Role"`                          // 角色，取值见 UserRole*
 	Avatar          string `redis:"Avatar"`                                         // 头像
 	BackgroundImage string `redis:"BackGroundImage"`                                // 背景图片
 	Signature       string `redis:"Signature"`                                      // 个人简介
+	Private         bool   `gorm:"not null;default:false" redis:"Private"`          // 私密账号，关注需要对方同意
 	gorm.Model
 }
 
@@ -24,19 +30,3 @@ func (u *User) IsNameEmail() bool {
 	reg := regexp.MustCompile(pattern)
 	return reg.MatchString(u.UserName)
 }
-
-var _ cached.MultiCachedItem = (*User)(nil) // *User 是否实现 cached.MultiCachedItem 接口
-
-func (u *User) IsDirty() bool {
-	return u.UserName != ""
-}
-
-func (u *User) GetID() uint32 {
-	return u.ID
-}
-
-func init() {
-	if err := database.Client.AutoMigrate(&User{}); err != nil {
-		panic(err)
-	}
-}
@@ -1,7 +1,6 @@
 package models
 
 import (
-	"GuGoTik/src/storage/database"
 	"gorm.io/gorm"
 )
 
@@ -16,11 +15,6 @@ type Video struct {
 	Transcript    string // 存储视频的文本转录内容
 	Summary       string // 存储视频的摘要信息
 	Keywords      string // e.g., "keywords1 | keywords2 | keywords3"
+	ContentHash   string `gorm:"size:64;index"` // 原始视频内容的 SHA-256，用于复用相同内容视频的处理结果
 	gorm.Model
 }
-
-func init() {
-	if err := database.Client.AutoMigrate(&Video{}); err != nil {
-		panic(err)
-	}
-}
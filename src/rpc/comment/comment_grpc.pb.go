@@ -21,6 +21,9 @@ const _ = grpc.SupportPackageIsVersion7
 const (
 	CommentService_ActionComment_FullMethodName = "/rpc.comment.CommentService/ActionComment"
 	CommentService_ListComment_FullMethodName   = "/rpc.comment.CommentService/ListComment"
+	CommentService_ListReplies_FullMethodName   = "/rpc.comment.CommentService/ListReplies"
+	CommentService_LikeComment_FullMethodName   = "/rpc.comment.CommentService/LikeComment"
+	CommentService_PinComment_FullMethodName    = "/rpc.comment.CommentService/PinComment"
 	CommentService_CountComment_FullMethodName  = "/rpc.comment.CommentService/CountComment"
 )
 
@@ -30,6 +33,9 @@ const (
 type CommentServiceClient interface {
 	ActionComment(ctx context.Context, in *ActionCommentRequest, opts ...grpc.CallOption) (*ActionCommentResponse, error)
 	ListComment(ctx context.Context, in *ListCommentRequest, opts ...grpc.CallOption) (*ListCommentResponse, error)
+	ListReplies(ctx context.Context, in *ListRepliesRequest, opts ...grpc.CallOption) (*ListRepliesResponse, error)
+	LikeComment(ctx context.Context, in *LikeCommentRequest, opts ...grpc.CallOption) (*LikeCommentResponse, error)
+	PinComment(ctx context.Context, in *PinCommentRequest, opts ...grpc.CallOption) (*PinCommentResponse, error)
 	CountComment(ctx context.Context, in *CountCommentRequest, opts ...grpc.CallOption) (*CountCommentResponse, error)
 }
 
@@ -59,6 +65,33 @@ func (c *commentServiceClient) ListComment(ctx context.Context, in *ListCommentR
 	return out, nil
 }
 
+func (c *commentServiceClient) ListReplies(ctx context.Context, in *ListRepliesRequest, opts ...grpc.CallOption) (*ListRepliesResponse, error) {
+	out := new(ListRepliesResponse)
+	err := c.cc.Invoke(ctx, CommentService_ListReplies_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) LikeComment(ctx context.Context, in *LikeCommentRequest, opts ...grpc.CallOption) (*LikeCommentResponse, error) {
+	out := new(LikeCommentResponse)
+	err := c.cc.Invoke(ctx, CommentService_LikeComment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) PinComment(ctx context.Context, in *PinCommentRequest, opts ...grpc.CallOption) (*PinCommentResponse, error) {
+	out := new(PinCommentResponse)
+	err := c.cc.Invoke(ctx, CommentService_PinComment_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *commentServiceClient) CountComment(ctx context.Context, in *CountCommentRequest, opts ...grpc.CallOption) (*CountCommentResponse, error) {
 	out := new(CountCommentResponse)
 	err := c.cc.Invoke(ctx, CommentService_CountComment_FullMethodName, in, out, opts...)
@@ -74,6 +107,9 @@ func (c *commentServiceClient) CountComment(ctx context.Context, in *CountCommen
 type CommentServiceServer interface {
 	ActionComment(context.Context, *ActionCommentRequest) (*ActionCommentResponse, error)
 	ListComment(context.Context, *ListCommentRequest) (*ListCommentResponse, error)
+	ListReplies(context.Context, *ListRepliesRequest) (*ListRepliesResponse, error)
+	LikeComment(context.Context, *LikeCommentRequest) (*LikeCommentResponse, error)
+	PinComment(context.Context, *PinCommentRequest) (*PinCommentResponse, error)
 	CountComment(context.Context, *CountCommentRequest) (*CountCommentResponse, error)
 	mustEmbedUnimplementedCommentServiceServer()
 }
@@ -88,6 +124,15 @@ func (UnimplementedCommentServiceServer) ActionComment(context.Context, *ActionC
 func (UnimplementedCommentServiceServer) ListComment(context.Context, *ListCommentRequest) (*ListCommentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ListComment not implemented")
 }
+func (UnimplementedCommentServiceServer) ListReplies(context.Context, *ListRepliesRequest) (*ListRepliesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListReplies not implemented")
+}
+func (UnimplementedCommentServiceServer) LikeComment(context.Context, *LikeCommentRequest) (*LikeCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LikeComment not implemented")
+}
+func (UnimplementedCommentServiceServer) PinComment(context.Context, *PinCommentRequest) (*PinCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PinComment not implemented")
+}
 func (UnimplementedCommentServiceServer) CountComment(context.Context, *CountCommentRequest) (*CountCommentResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CountComment not implemented")
 }
@@ -140,6 +185,60 @@ func _CommentService_ListComment_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _CommentService_ListReplies_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRepliesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ListReplies(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommentService_ListReplies_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ListReplies(ctx, req.(*ListRepliesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_LikeComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LikeCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).LikeComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommentService_LikeComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).LikeComment(ctx, req.(*LikeCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_PinComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).PinComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CommentService_PinComment_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).PinComment(ctx, req.(*PinCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _CommentService_CountComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CountCommentRequest)
 	if err := dec(in); err != nil {
@@ -173,6 +272,18 @@ var CommentService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListComment",
 			Handler:    _CommentService_ListComment_Handler,
 		},
+		{
+			MethodName: "ListReplies",
+			Handler:    _CommentService_ListReplies_Handler,
+		},
+		{
+			MethodName: "LikeComment",
+			Handler:    _CommentService_LikeComment_Handler,
+		},
+		{
+			MethodName: "PinComment",
+			Handler:    _CommentService_PinComment_Handler,
+		},
 		{
 			MethodName: "CountComment",
 			Handler:    _CommentService_CountComment_Handler,
@@ -25,6 +25,11 @@ const (
 	FavoriteService_CountFavorite_FullMethodName           = "/rpc.favorite.FavoriteService/CountFavorite"
 	FavoriteService_CountUserFavorite_FullMethodName       = "/rpc.favorite.FavoriteService/CountUserFavorite"
 	FavoriteService_CountUserTotalFavorited_FullMethodName = "/rpc.favorite.FavoriteService/CountUserTotalFavorited"
+	FavoriteService_React_FullMethodName                   = "/rpc.favorite.FavoriteService/React"
+	FavoriteService_GetReaction_FullMethodName             = "/rpc.favorite.FavoriteService/GetReaction"
+	FavoriteService_CountReactions_FullMethodName          = "/rpc.favorite.FavoriteService/CountReactions"
+	FavoriteService_BatchVideoStats_FullMethodName         = "/rpc.favorite.FavoriteService/BatchVideoStats"
+	FavoriteService_ListVideoLikers_FullMethodName         = "/rpc.favorite.FavoriteService/ListVideoLikers"
 )
 
 // FavoriteServiceClient is the client API for FavoriteService service.
@@ -37,6 +42,11 @@ type FavoriteServiceClient interface {
 	CountFavorite(ctx context.Context, in *CountFavoriteRequest, opts ...grpc.CallOption) (*CountFavoriteResponse, error)
 	CountUserFavorite(ctx context.Context, in *CountUserFavoriteRequest, opts ...grpc.CallOption) (*CountUserFavoriteResponse, error)
 	CountUserTotalFavorited(ctx context.Context, in *CountUserTotalFavoritedRequest, opts ...grpc.CallOption) (*CountUserTotalFavoritedResponse, error)
+	React(ctx context.Context, in *ReactRequest, opts ...grpc.CallOption) (*ReactResponse, error)
+	GetReaction(ctx context.Context, in *GetReactionRequest, opts ...grpc.CallOption) (*GetReactionResponse, error)
+	CountReactions(ctx context.Context, in *CountReactionsRequest, opts ...grpc.CallOption) (*CountReactionsResponse, error)
+	BatchVideoStats(ctx context.Context, in *BatchVideoStatsRequest, opts ...grpc.CallOption) (*BatchVideoStatsResponse, error)
+	ListVideoLikers(ctx context.Context, in *ListVideoLikersRequest, opts ...grpc.CallOption) (*ListVideoLikersResponse, error)
 }
 
 type favoriteServiceClient struct {
@@ -101,6 +111,51 @@ func (c *favoriteServiceClient) CountUserTotalFavorited(ctx context.Context, in
 	return out, nil
 }
 
+func (c *favoriteServiceClient) React(ctx context.Context, in *ReactRequest, opts ...grpc.CallOption) (*ReactResponse, error) {
+	out := new(ReactResponse)
+	err := c.cc.Invoke(ctx, FavoriteService_React_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *favoriteServiceClient) GetReaction(ctx context.Context, in *GetReactionRequest, opts ...grpc.CallOption) (*GetReactionResponse, error) {
+	out := new(GetReactionResponse)
+	err := c.cc.Invoke(ctx, FavoriteService_GetReaction_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *favoriteServiceClient) CountReactions(ctx context.Context, in *CountReactionsRequest, opts ...grpc.CallOption) (*CountReactionsResponse, error) {
+	out := new(CountReactionsResponse)
+	err := c.cc.Invoke(ctx, FavoriteService_CountReactions_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *favoriteServiceClient) BatchVideoStats(ctx context.Context, in *BatchVideoStatsRequest, opts ...grpc.CallOption) (*BatchVideoStatsResponse, error) {
+	out := new(BatchVideoStatsResponse)
+	err := c.cc.Invoke(ctx, FavoriteService_BatchVideoStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *favoriteServiceClient) ListVideoLikers(ctx context.Context, in *ListVideoLikersRequest, opts ...grpc.CallOption) (*ListVideoLikersResponse, error) {
+	out := new(ListVideoLikersResponse)
+	err := c.cc.Invoke(ctx, FavoriteService_ListVideoLikers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FavoriteServiceServer is the server API for FavoriteService service.
 // All implementations must embed UnimplementedFavoriteServiceServer
 // for forward compatibility
@@ -111,6 +166,11 @@ type FavoriteServiceServer interface {
 	CountFavorite(context.Context, *CountFavoriteRequest) (*CountFavoriteResponse, error)
 	CountUserFavorite(context.Context, *CountUserFavoriteRequest) (*CountUserFavoriteResponse, error)
 	CountUserTotalFavorited(context.Context, *CountUserTotalFavoritedRequest) (*CountUserTotalFavoritedResponse, error)
+	React(context.Context, *ReactRequest) (*ReactResponse, error)
+	GetReaction(context.Context, *GetReactionRequest) (*GetReactionResponse, error)
+	CountReactions(context.Context, *CountReactionsRequest) (*CountReactionsResponse, error)
+	BatchVideoStats(context.Context, *BatchVideoStatsRequest) (*BatchVideoStatsResponse, error)
+	ListVideoLikers(context.Context, *ListVideoLikersRequest) (*ListVideoLikersResponse, error)
 	mustEmbedUnimplementedFavoriteServiceServer()
 }
 
@@ -136,6 +196,21 @@ func (UnimplementedFavoriteServiceServer) CountUserFavorite(context.Context, *Co
 func (UnimplementedFavoriteServiceServer) CountUserTotalFavorited(context.Context, *CountUserTotalFavoritedRequest) (*CountUserTotalFavoritedResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CountUserTotalFavorited not implemented")
 }
+func (UnimplementedFavoriteServiceServer) React(context.Context, *ReactRequest) (*ReactResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method React not implemented")
+}
+func (UnimplementedFavoriteServiceServer) GetReaction(context.Context, *GetReactionRequest) (*GetReactionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetReaction not implemented")
+}
+func (UnimplementedFavoriteServiceServer) CountReactions(context.Context, *CountReactionsRequest) (*CountReactionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountReactions not implemented")
+}
+func (UnimplementedFavoriteServiceServer) BatchVideoStats(context.Context, *BatchVideoStatsRequest) (*BatchVideoStatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchVideoStats not implemented")
+}
+func (UnimplementedFavoriteServiceServer) ListVideoLikers(context.Context, *ListVideoLikersRequest) (*ListVideoLikersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListVideoLikers not implemented")
+}
 func (UnimplementedFavoriteServiceServer) mustEmbedUnimplementedFavoriteServiceServer() {}
 
 // UnsafeFavoriteServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -257,6 +332,96 @@ func _FavoriteService_CountUserTotalFavorited_Handler(srv interface{}, ctx conte
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FavoriteService_React_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FavoriteServiceServer).React(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FavoriteService_React_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FavoriteServiceServer).React(ctx, req.(*ReactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FavoriteService_GetReaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetReactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FavoriteServiceServer).GetReaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FavoriteService_GetReaction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FavoriteServiceServer).GetReaction(ctx, req.(*GetReactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FavoriteService_CountReactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountReactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FavoriteServiceServer).CountReactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FavoriteService_CountReactions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FavoriteServiceServer).CountReactions(ctx, req.(*CountReactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FavoriteService_BatchVideoStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchVideoStatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FavoriteServiceServer).BatchVideoStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FavoriteService_BatchVideoStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FavoriteServiceServer).BatchVideoStats(ctx, req.(*BatchVideoStatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FavoriteService_ListVideoLikers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListVideoLikersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FavoriteServiceServer).ListVideoLikers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FavoriteService_ListVideoLikers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FavoriteServiceServer).ListVideoLikers(ctx, req.(*ListVideoLikersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // FavoriteService_ServiceDesc is the grpc.ServiceDesc for FavoriteService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -288,6 +453,26 @@ var FavoriteService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CountUserTotalFavorited",
 			Handler:    _FavoriteService_CountUserTotalFavorited_Handler,
 		},
+		{
+			MethodName: "React",
+			Handler:    _FavoriteService_React_Handler,
+		},
+		{
+			MethodName: "GetReaction",
+			Handler:    _FavoriteService_GetReaction_Handler,
+		},
+		{
+			MethodName: "CountReactions",
+			Handler:    _FavoriteService_CountReactions_Handler,
+		},
+		{
+			MethodName: "BatchVideoStats",
+			Handler:    _FavoriteService_BatchVideoStats_Handler,
+		},
+		{
+			MethodName: "ListVideoLikers",
+			Handler:    _FavoriteService_ListVideoLikers_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "favorite.proto",
@@ -19,14 +19,23 @@ import (
 const _ = grpc.SupportPackageIsVersion7
 
 const (
-	RelationService_Follow_FullMethodName            = "/rpc.Relation.RelationService/Follow"
-	RelationService_Unfollow_FullMethodName          = "/rpc.Relation.RelationService/Unfollow"
-	RelationService_GetFollowList_FullMethodName     = "/rpc.Relation.RelationService/GetFollowList"
-	RelationService_CountFollowList_FullMethodName   = "/rpc.Relation.RelationService/CountFollowList"
-	RelationService_GetFollowerList_FullMethodName   = "/rpc.Relation.RelationService/GetFollowerList"
-	RelationService_CountFollowerList_FullMethodName = "/rpc.Relation.RelationService/CountFollowerList"
-	RelationService_GetFriendList_FullMethodName     = "/rpc.Relation.RelationService/GetFriendList"
-	RelationService_IsFollow_FullMethodName          = "/rpc.Relation.RelationService/IsFollow"
+	RelationService_Follow_FullMethodName              = "/rpc.Relation.RelationService/Follow"
+	RelationService_Unfollow_FullMethodName            = "/rpc.Relation.RelationService/Unfollow"
+	RelationService_GetFollowList_FullMethodName       = "/rpc.Relation.RelationService/GetFollowList"
+	RelationService_CountFollowList_FullMethodName     = "/rpc.Relation.RelationService/CountFollowList"
+	RelationService_GetFollowerList_FullMethodName     = "/rpc.Relation.RelationService/GetFollowerList"
+	RelationService_CountFollowerList_FullMethodName   = "/rpc.Relation.RelationService/CountFollowerList"
+	RelationService_GetFriendList_FullMethodName       = "/rpc.Relation.RelationService/GetFriendList"
+	RelationService_IsFollow_FullMethodName            = "/rpc.Relation.RelationService/IsFollow"
+	RelationService_Block_FullMethodName               = "/rpc.Relation.RelationService/Block"
+	RelationService_Unblock_FullMethodName             = "/rpc.Relation.RelationService/Unblock"
+	RelationService_ListBlocked_FullMethodName         = "/rpc.Relation.RelationService/ListBlocked"
+	RelationService_Mute_FullMethodName                = "/rpc.Relation.RelationService/Mute"
+	RelationService_IsBlocked_FullMethodName           = "/rpc.Relation.RelationService/IsBlocked"
+	RelationService_GetHiddenUsers_FullMethodName      = "/rpc.Relation.RelationService/GetHiddenUsers"
+	RelationService_ListFollowRequests_FullMethodName  = "/rpc.Relation.RelationService/ListFollowRequests"
+	RelationService_AcceptFollowRequest_FullMethodName = "/rpc.Relation.RelationService/AcceptFollowRequest"
+	RelationService_RejectFollowRequest_FullMethodName = "/rpc.Relation.RelationService/RejectFollowRequest"
 )
 
 // RelationServiceClient is the client API for RelationService service.
@@ -41,6 +50,17 @@ type RelationServiceClient interface {
 	CountFollowerList(ctx context.Context, in *CountFollowerListRequest, opts ...grpc.CallOption) (*CountFollowerListResponse, error)
 	GetFriendList(ctx context.Context, in *FriendListRequest, opts ...grpc.CallOption) (*FriendListResponse, error)
 	IsFollow(ctx context.Context, in *IsFollowRequest, opts ...grpc.CallOption) (*IsFollowResponse, error)
+	Block(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*BlockResponse, error)
+	Unblock(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*BlockResponse, error)
+	ListBlocked(ctx context.Context, in *ListBlockedRequest, opts ...grpc.CallOption) (*ListBlockedResponse, error)
+	Mute(ctx context.Context, in *MuteRequest, opts ...grpc.CallOption) (*MuteResponse, error)
+	IsBlocked(ctx context.Context, in *IsBlockedRequest, opts ...grpc.CallOption) (*IsBlockedResponse, error)
+	// 查询需要对当前用户隐藏内容的用户，供评论与视频流过滤使用
+	GetHiddenUsers(ctx context.Context, in *HiddenUsersRequest, opts ...grpc.CallOption) (*HiddenUsersResponse, error)
+	ListFollowRequests(ctx context.Context, in *FollowRequestListRequest, opts ...grpc.CallOption) (*FollowRequestListResponse, error)
+	// actor_id 为私密账号，user_id 为发出请求的用户
+	AcceptFollowRequest(ctx context.Context, in *RelationActionRequest, opts ...grpc.CallOption) (*RelationActionResponse, error)
+	RejectFollowRequest(ctx context.Context, in *RelationActionRequest, opts ...grpc.CallOption) (*RelationActionResponse, error)
 }
 
 type relationServiceClient struct {
@@ -123,6 +143,87 @@ func (c *relationServiceClient) IsFollow(ctx context.Context, in *IsFollowReques
 	return out, nil
 }
 
+func (c *relationServiceClient) Block(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*BlockResponse, error) {
+	out := new(BlockResponse)
+	err := c.cc.Invoke(ctx, RelationService_Block_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) Unblock(ctx context.Context, in *BlockRequest, opts ...grpc.CallOption) (*BlockResponse, error) {
+	out := new(BlockResponse)
+	err := c.cc.Invoke(ctx, RelationService_Unblock_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) ListBlocked(ctx context.Context, in *ListBlockedRequest, opts ...grpc.CallOption) (*ListBlockedResponse, error) {
+	out := new(ListBlockedResponse)
+	err := c.cc.Invoke(ctx, RelationService_ListBlocked_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) Mute(ctx context.Context, in *MuteRequest, opts ...grpc.CallOption) (*MuteResponse, error) {
+	out := new(MuteResponse)
+	err := c.cc.Invoke(ctx, RelationService_Mute_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) IsBlocked(ctx context.Context, in *IsBlockedRequest, opts ...grpc.CallOption) (*IsBlockedResponse, error) {
+	out := new(IsBlockedResponse)
+	err := c.cc.Invoke(ctx, RelationService_IsBlocked_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) GetHiddenUsers(ctx context.Context, in *HiddenUsersRequest, opts ...grpc.CallOption) (*HiddenUsersResponse, error) {
+	out := new(HiddenUsersResponse)
+	err := c.cc.Invoke(ctx, RelationService_GetHiddenUsers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) ListFollowRequests(ctx context.Context, in *FollowRequestListRequest, opts ...grpc.CallOption) (*FollowRequestListResponse, error) {
+	out := new(FollowRequestListResponse)
+	err := c.cc.Invoke(ctx, RelationService_ListFollowRequests_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) AcceptFollowRequest(ctx context.Context, in *RelationActionRequest, opts ...grpc.CallOption) (*RelationActionResponse, error) {
+	out := new(RelationActionResponse)
+	err := c.cc.Invoke(ctx, RelationService_AcceptFollowRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *relationServiceClient) RejectFollowRequest(ctx context.Context, in *RelationActionRequest, opts ...grpc.CallOption) (*RelationActionResponse, error) {
+	out := new(RelationActionResponse)
+	err := c.cc.Invoke(ctx, RelationService_RejectFollowRequest_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // RelationServiceServer is the server API for RelationService service.
 // All implementations must embed UnimplementedRelationServiceServer
 // for forward compatibility
@@ -135,6 +236,17 @@ type RelationServiceServer interface {
 	CountFollowerList(context.Context, *CountFollowerListRequest) (*CountFollowerListResponse, error)
 	GetFriendList(context.Context, *FriendListRequest) (*FriendListResponse, error)
 	IsFollow(context.Context, *IsFollowRequest) (*IsFollowResponse, error)
+	Block(context.Context, *BlockRequest) (*BlockResponse, error)
+	Unblock(context.Context, *BlockRequest) (*BlockResponse, error)
+	ListBlocked(context.Context, *ListBlockedRequest) (*ListBlockedResponse, error)
+	Mute(context.Context, *MuteRequest) (*MuteResponse, error)
+	IsBlocked(context.Context, *IsBlockedRequest) (*IsBlockedResponse, error)
+	// 查询需要对当前用户隐藏内容的用户，供评论与视频流过滤使用
+	GetHiddenUsers(context.Context, *HiddenUsersRequest) (*HiddenUsersResponse, error)
+	ListFollowRequests(context.Context, *FollowRequestListRequest) (*FollowRequestListResponse, error)
+	// actor_id 为私密账号，user_id 为发出请求的用户
+	AcceptFollowRequest(context.Context, *RelationActionRequest) (*RelationActionResponse, error)
+	RejectFollowRequest(context.Context, *RelationActionRequest) (*RelationActionResponse, error)
 	mustEmbedUnimplementedRelationServiceServer()
 }
 
@@ -166,6 +278,33 @@ func (UnimplementedRelationServiceServer) GetFriendList(context.Context, *Friend
 func (UnimplementedRelationServiceServer) IsFollow(context.Context, *IsFollowRequest) (*IsFollowResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method IsFollow not implemented")
 }
+func (UnimplementedRelationServiceServer) Block(context.Context, *BlockRequest) (*BlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Block not implemented")
+}
+func (UnimplementedRelationServiceServer) Unblock(context.Context, *BlockRequest) (*BlockResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Unblock not implemented")
+}
+func (UnimplementedRelationServiceServer) ListBlocked(context.Context, *ListBlockedRequest) (*ListBlockedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBlocked not implemented")
+}
+func (UnimplementedRelationServiceServer) Mute(context.Context, *MuteRequest) (*MuteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Mute not implemented")
+}
+func (UnimplementedRelationServiceServer) IsBlocked(context.Context, *IsBlockedRequest) (*IsBlockedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsBlocked not implemented")
+}
+func (UnimplementedRelationServiceServer) GetHiddenUsers(context.Context, *HiddenUsersRequest) (*HiddenUsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetHiddenUsers not implemented")
+}
+func (UnimplementedRelationServiceServer) ListFollowRequests(context.Context, *FollowRequestListRequest) (*FollowRequestListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListFollowRequests not implemented")
+}
+func (UnimplementedRelationServiceServer) AcceptFollowRequest(context.Context, *RelationActionRequest) (*RelationActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcceptFollowRequest not implemented")
+}
+func (UnimplementedRelationServiceServer) RejectFollowRequest(context.Context, *RelationActionRequest) (*RelationActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectFollowRequest not implemented")
+}
 func (UnimplementedRelationServiceServer) mustEmbedUnimplementedRelationServiceServer() {}
 
 // UnsafeRelationServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -323,6 +462,168 @@ func _RelationService_IsFollow_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RelationService_Block_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).Block(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_Block_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).Block(ctx, req.(*BlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_Unblock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).Unblock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_Unblock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).Unblock(ctx, req.(*BlockRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_ListBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBlockedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).ListBlocked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_ListBlocked_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).ListBlocked(ctx, req.(*ListBlockedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_Mute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MuteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).Mute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_Mute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).Mute(ctx, req.(*MuteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_IsBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsBlockedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).IsBlocked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_IsBlocked_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).IsBlocked(ctx, req.(*IsBlockedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_GetHiddenUsers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HiddenUsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).GetHiddenUsers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_GetHiddenUsers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).GetHiddenUsers(ctx, req.(*HiddenUsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_ListFollowRequests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FollowRequestListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).ListFollowRequests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_ListFollowRequests_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).ListFollowRequests(ctx, req.(*FollowRequestListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_AcceptFollowRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RelationActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).AcceptFollowRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_AcceptFollowRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).AcceptFollowRequest(ctx, req.(*RelationActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _RelationService_RejectFollowRequest_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RelationActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(RelationServiceServer).RejectFollowRequest(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: RelationService_RejectFollowRequest_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(RelationServiceServer).RejectFollowRequest(ctx, req.(*RelationActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // RelationService_ServiceDesc is the grpc.ServiceDesc for RelationService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -362,6 +663,42 @@ var RelationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "IsFollow",
 			Handler:    _RelationService_IsFollow_Handler,
 		},
+		{
+			MethodName: "Block",
+			Handler:    _RelationService_Block_Handler,
+		},
+		{
+			MethodName: "Unblock",
+			Handler:    _RelationService_Unblock_Handler,
+		},
+		{
+			MethodName: "ListBlocked",
+			Handler:    _RelationService_ListBlocked_Handler,
+		},
+		{
+			MethodName: "Mute",
+			Handler:    _RelationService_Mute_Handler,
+		},
+		{
+			MethodName: "IsBlocked",
+			Handler:    _RelationService_IsBlocked_Handler,
+		},
+		{
+			MethodName: "GetHiddenUsers",
+			Handler:    _RelationService_GetHiddenUsers_Handler,
+		},
+		{
+			MethodName: "ListFollowRequests",
+			Handler:    _RelationService_ListFollowRequests_Handler,
+		},
+		{
+			MethodName: "AcceptFollowRequest",
+			Handler:    _RelationService_AcceptFollowRequest_Handler,
+		},
+		{
+			MethodName: "RejectFollowRequest",
+			Handler:    _RelationService_RejectFollowRequest_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "relation.proto",
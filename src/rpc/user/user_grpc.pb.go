@@ -20,7 +20,10 @@ const _ = grpc.SupportPackageIsVersion7
 
 const (
 	UserService_GetUserInfo_FullMethodName             = "/rpc.user.UserService/GetUserInfo"
+	UserService_GetUserInfos_FullMethodName            = "/rpc.user.UserService/GetUserInfos"
 	UserService_GetUserExistInformation_FullMethodName = "/rpc.user.UserService/GetUserExistInformation"
+	UserService_ResolveUserNames_FullMethodName        = "/rpc.user.UserService/ResolveUserNames"
+	UserService_SetPrivacy_FullMethodName              = "/rpc.user.UserService/SetPrivacy"
 )
 
 // UserServiceClient is the client API for UserService service.
@@ -28,7 +31,10 @@ const (
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type UserServiceClient interface {
 	GetUserInfo(ctx context.Context, in *UserRequest, opts ...grpc.CallOption) (*UserResponse, error)
+	GetUserInfos(ctx context.Context, in *UsersRequest, opts ...grpc.CallOption) (*UsersResponse, error)
 	GetUserExistInformation(ctx context.Context, in *UserExistRequest, opts ...grpc.CallOption) (*UserExistResponse, error)
+	ResolveUserNames(ctx context.Context, in *UserNamesRequest, opts ...grpc.CallOption) (*UserNamesResponse, error)
+	SetPrivacy(ctx context.Context, in *PrivacyRequest, opts ...grpc.CallOption) (*PrivacyResponse, error)
 }
 
 type userServiceClient struct {
@@ -48,6 +54,15 @@ func (c *userServiceClient) GetUserInfo(ctx context.Context, in *UserRequest, op
 	return out, nil
 }
 
+func (c *userServiceClient) GetUserInfos(ctx context.Context, in *UsersRequest, opts ...grpc.CallOption) (*UsersResponse, error) {
+	out := new(UsersResponse)
+	err := c.cc.Invoke(ctx, UserService_GetUserInfos_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *userServiceClient) GetUserExistInformation(ctx context.Context, in *UserExistRequest, opts ...grpc.CallOption) (*UserExistResponse, error) {
 	out := new(UserExistResponse)
 	err := c.cc.Invoke(ctx, UserService_GetUserExistInformation_FullMethodName, in, out, opts...)
@@ -57,12 +72,33 @@ func (c *userServiceClient) GetUserExistInformation(ctx context.Context, in *Use
 	return out, nil
 }
 
+func (c *userServiceClient) ResolveUserNames(ctx context.Context, in *UserNamesRequest, opts ...grpc.CallOption) (*UserNamesResponse, error) {
+	out := new(UserNamesResponse)
+	err := c.cc.Invoke(ctx, UserService_ResolveUserNames_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *userServiceClient) SetPrivacy(ctx context.Context, in *PrivacyRequest, opts ...grpc.CallOption) (*PrivacyResponse, error) {
+	out := new(PrivacyResponse)
+	err := c.cc.Invoke(ctx, UserService_SetPrivacy_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // UserServiceServer is the server API for UserService service.
 // All implementations must embed UnimplementedUserServiceServer
 // for forward compatibility
 type UserServiceServer interface {
 	GetUserInfo(context.Context, *UserRequest) (*UserResponse, error)
+	GetUserInfos(context.Context, *UsersRequest) (*UsersResponse, error)
 	GetUserExistInformation(context.Context, *UserExistRequest) (*UserExistResponse, error)
+	ResolveUserNames(context.Context, *UserNamesRequest) (*UserNamesResponse, error)
+	SetPrivacy(context.Context, *PrivacyRequest) (*PrivacyResponse, error)
 	mustEmbedUnimplementedUserServiceServer()
 }
 
@@ -73,9 +109,18 @@ type UnimplementedUserServiceServer struct {
 func (UnimplementedUserServiceServer) GetUserInfo(context.Context, *UserRequest) (*UserResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserInfo not implemented")
 }
+func (UnimplementedUserServiceServer) GetUserInfos(context.Context, *UsersRequest) (*UsersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserInfos not implemented")
+}
 func (UnimplementedUserServiceServer) GetUserExistInformation(context.Context, *UserExistRequest) (*UserExistResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetUserExistInformation not implemented")
 }
+func (UnimplementedUserServiceServer) ResolveUserNames(context.Context, *UserNamesRequest) (*UserNamesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResolveUserNames not implemented")
+}
+func (UnimplementedUserServiceServer) SetPrivacy(context.Context, *PrivacyRequest) (*PrivacyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetPrivacy not implemented")
+}
 func (UnimplementedUserServiceServer) mustEmbedUnimplementedUserServiceServer() {}
 
 // UnsafeUserServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -107,6 +152,24 @@ func _UserService_GetUserInfo_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_GetUserInfos_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UsersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).GetUserInfos(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_GetUserInfos_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).GetUserInfos(ctx, req.(*UsersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _UserService_GetUserExistInformation_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(UserExistRequest)
 	if err := dec(in); err != nil {
@@ -125,6 +188,42 @@ func _UserService_GetUserExistInformation_Handler(srv interface{}, ctx context.C
 	return interceptor(ctx, in, info, handler)
 }
 
+func _UserService_ResolveUserNames_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UserNamesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).ResolveUserNames(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_ResolveUserNames_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).ResolveUserNames(ctx, req.(*UserNamesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _UserService_SetPrivacy_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PrivacyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(UserServiceServer).SetPrivacy(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: UserService_SetPrivacy_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(UserServiceServer).SetPrivacy(ctx, req.(*PrivacyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // UserService_ServiceDesc is the grpc.ServiceDesc for UserService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -136,10 +235,22 @@ var UserService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetUserInfo",
 			Handler:    _UserService_GetUserInfo_Handler,
 		},
+		{
+			MethodName: "GetUserInfos",
+			Handler:    _UserService_GetUserInfos_Handler,
+		},
 		{
 			MethodName: "GetUserExistInformation",
 			Handler:    _UserService_GetUserExistInformation_Handler,
 		},
+		{
+			MethodName: "ResolveUserNames",
+			Handler:    _UserService_ResolveUserNames_Handler,
+		},
+		{
+			MethodName: "SetPrivacy",
+			Handler:    _UserService_SetPrivacy_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "user.proto",
@@ -10,9 +10,7 @@ import (
 	"GuGoTik/src/rpc/relation"
 	user2 "GuGoTik/src/rpc/user"
 	"GuGoTik/src/storage/cached"
-	"GuGoTik/src/storage/database"
-	"GuGoTik/src/storage/redis"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"context"
 	"crypto/md5"
@@ -41,14 +39,16 @@ var BloomFilter *bloom.BloomFilter
 
 type AuthServiceImpl struct {
 	auth.AuthServiceServer
+	deps *deps.Container
 }
 
-func (a AuthServiceImpl) New() {
-	relationConn := grpc2.Connect(config.RelationRpcServerName)
+func (a *AuthServiceImpl) New(container *deps.Container) {
+	a.deps = container
+	relationConn := container.Dial(config.RelationRpcServerName)
 	relationClient = relation.NewRelationServiceClient(relationConn)
-	userRpcConn := grpc2.Connect(config.UserRpcServerName)
+	userRpcConn := container.Dial(config.UserRpcServerName)
 	userClient = user2.NewUserServiceClient(userRpcConn)
-	recommendRpcConn := grpc2.Connect(config.RecommendRpcServiceName)
+	recommendRpcConn := container.Dial(config.RecommendRpcServiceName)
 	recommendClient = recommend.NewRecommendServiceClient(recommendRpcConn)
 }
 
@@ -108,7 +108,7 @@ func (a AuthServiceImpl) Register(ctx context.Context, request *auth.RegisterReq
 
 	resp = &auth.RegisterResponse{}
 	var user models.User
-	result := database.Client.WithContext(ctx).Limit(1).Where("user_name = ?", request.Username).Find(&user)
+	result := a.deps.DB.WithContext(ctx).Limit(1).Where("user_name = ?", request.Username).Find(&user)
 	if result.RowsAffected != 0 {
 		resp = &auth.RegisterResponse{
 			StatusCode: strings.AuthUserExistedCode,
@@ -195,7 +195,7 @@ func (a AuthServiceImpl) Register(ctx context.Context, request *auth.RegisterReq
 	user.BackgroundImage = "https://i.mij.rip/2023/08/26/0caa1681f9ae3de38f7d8abcc3b849fc.jpeg"
 	user.Password = hashedPassword
 
-	result = database.Client.WithContext(ctx).Create(&user)
+	result = a.deps.DB.WithContext(ctx).Create(&user)
 	if result.Error != nil {
 		logger.WithFields(logrus.Fields{
 			"err":      result.Error,
@@ -242,7 +242,7 @@ func (a AuthServiceImpl) Register(ctx context.Context, request *auth.RegisterReq
 	logger.WithFields(logrus.Fields{
 		"username": user.UserName,
 	}).Infof("Publishing user name to redis channel")
-	err = redis.Client.Publish(ctx, config.BloomRedisChannel, user.UserName).Err()
+	err = a.deps.Redis.Publish(ctx, config.BloomRedisChannel, user.UserName).Err()
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"err":      err,
@@ -294,7 +294,7 @@ func (a AuthServiceImpl) Login(ctx context.Context, request *auth.LoginRequest)
 	}
 
 	if !ok {
-		result := database.Client.Where("user_name = ?", request.Username).WithContext(ctx).Find(&user)
+		result := a.deps.DB.Where("user_name = ?", request.Username).WithContext(ctx).Find(&user)
 		if result.Error != nil {
 			logger.WithFields(logrus.Fields{
 				"err":      result.Error,
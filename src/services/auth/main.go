@@ -6,9 +6,8 @@ import (
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/models"
 	"GuGoTik/src/rpc/auth"
-	"GuGoTik/src/storage/database"
-	"GuGoTik/src/storage/redis"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
@@ -29,6 +28,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.AuthRpcServerName)
 
 	if err != nil {
@@ -68,7 +69,7 @@ func main() {
 
 	// Initialize BloomFilter from database
 	var users []models.User
-	userNamesResult := database.Client.WithContext(context.Background()).Select("user_name").Find(&users)
+	userNamesResult := container.DB.WithContext(context.Background()).Select("user_name").Find(&users)
 	if userNamesResult.Error != nil {
 		log.Panicf("Getting user names from databse happens error: %s", userNamesResult.Error)
 		panic(userNamesResult.Error)
@@ -79,7 +80,7 @@ func main() {
 
 	// Create a go routine to receive redis message and add it to BloomFilter
 	go func() {
-		pubSub := redis.Client.Subscribe(context.Background(), config.BloomRedisChannel)
+		pubSub := container.Redis.Subscribe(context.Background(), config.BloomRedisChannel)
 		defer func(pubSub *redis2.PubSub) {
 			err := pubSub.Close()
 			if err != nil {
@@ -112,10 +113,10 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.AuthRpcServerName, config.AuthRpcServerPort)
 
 	var srv AuthServiceImpl
+	srv.New(container)
 	auth.RegisterAuthServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
 
-	srv.New()
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
@@ -0,0 +1,682 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/collection"
+	"GuGoTik/src/rpc/feed"
+	"GuGoTik/src/utils/deps"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"errors"
+	strings2 "strings"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var feedClient feed.FeedServiceClient
+
+// 每个用户最多可以创建的收藏夹数量
+const maxCollectionsPerUser = 100
+
+const maxCollectionNameLength = 64
+
+// positionGap 相邻视频之间的位置间隔，移动视频时取两侧位置的中点，间隔用尽时重新编号整个收藏夹
+const positionGap int64 = 1 << 16
+
+const (
+	defaultListLimit = 30
+	maxListLimit     = 100
+)
+
+// errCollectionNotFound 收藏夹不存在，或者不属于当前用户
+var errCollectionNotFound = errors.New("collection not found")
+
+// errVideoNotInCollection 视频不在收藏夹中
+var errVideoNotInCollection = errors.New("video not in collection")
+
+type CollectionServiceImpl struct {
+	collection.CollectionServiceServer
+	deps *deps.Container
+}
+
+func (s *CollectionServiceImpl) New(container *deps.Container) {
+	s.deps = container
+	feedRpcConn := container.Dial(config.FeedRpcServerName)
+	feedClient = feed.NewFeedServiceClient(feedRpcConn)
+}
+
+// normalizeName 去掉名称两端的空白，名称为空或过长时返回 false
+func normalizeName(name string) (string, bool) {
+	name = strings2.TrimSpace(name)
+	count := utf8.RuneCountInString(name)
+	return name, count > 0 && count <= maxCollectionNameLength
+}
+
+// ownedCollection 查询属于 actorId 的收藏夹，lock 为 true 时锁住该收藏夹，用于串行化同一个收藏夹中视频位置的修改
+func ownedCollection(ctx context.Context, db *gorm.DB, actorId uint32, collectionId uint32, lock bool) (*models.Collection, error) {
+	query := db.WithContext(ctx)
+	if lock {
+		query = query.Clauses(clause.Locking{Strength: "UPDATE"})
+	}
+	var c models.Collection
+	err := query.Where("id = ? AND user_id = ?", collectionId, actorId).Take(&c).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errCollectionNotFound
+	}
+	return &c, err
+}
+
+// countVideos 统计每个收藏夹中的视频数量
+func (s CollectionServiceImpl) countVideos(ctx context.Context, collectionIds []uint32) (map[uint32]uint32, error) {
+	counts := make(map[uint32]uint32, len(collectionIds))
+	if len(collectionIds) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		CollectionId uint32
+		Count        uint32
+	}
+	if err := s.deps.DB.WithContext(ctx).Model(&models.CollectionItem{}).
+		Select("collection_id, count(*) AS count").
+		Where("collection_id IN ?", collectionIds).
+		Group("collection_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.CollectionId] = row.Count
+	}
+	return counts, nil
+}
+
+func toRpcCollection(c *models.Collection, videoCount uint32) *collection.Collection {
+	return &collection.Collection{
+		Id:         c.ID,
+		UserId:     c.UserId,
+		Name:       c.Name,
+		Private:    c.Private,
+		VideoCount: videoCount,
+		CreatedAt:  c.CreatedAt.Unix(),
+	}
+}
+
+func (s CollectionServiceImpl) CreateCollection(ctx context.Context, request *collection.CreateCollectionRequest) (resp *collection.CollectionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "CreateCollectionService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.CreateCollection").WithContext(ctx)
+
+	name, ok := normalizeName(request.Name)
+	if !ok {
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.CollectionNameInvalidCode,
+			StatusMsg:  strings.CollectionNameInvalid,
+		}
+		return
+	}
+
+	c := models.Collection{
+		UserId:  request.ActorId,
+		Name:    name,
+		Private: request.Private,
+	}
+	var limited, existed bool
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Collection{}).Where("user_id = ?", request.ActorId).Count(&count).Error; err != nil {
+			return err
+		}
+		if limited = count >= maxCollectionsPerUser; limited {
+			return nil
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&c)
+		existed = result.Error == nil && result.RowsAffected == 0
+		return result.Error
+	})
+
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"name":    name,
+		}).Errorf("Failed to create the collection")
+		logging.SetSpanError(span, err)
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.CollectionServiceInnerErrorCode,
+			StatusMsg:  strings.CollectionServiceInnerError,
+		}
+		return
+	}
+
+	switch {
+	case limited:
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.CollectionLimitedCode,
+			StatusMsg:  strings.CollectionLimited,
+		}
+	case existed:
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.CollectionNameExistedCode,
+			StatusMsg:  strings.CollectionNameExisted,
+		}
+	default:
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.ServiceOKCode,
+			StatusMsg:  strings.ServiceOK,
+			Collection: toRpcCollection(&c, 0),
+		}
+	}
+	return
+}
+
+func (s CollectionServiceImpl) UpdateCollection(ctx context.Context, request *collection.UpdateCollectionRequest) (resp *collection.CollectionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "UpdateCollectionService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.UpdateCollection").WithContext(ctx)
+
+	updates := map[string]any{}
+	if request.Name != nil {
+		name, ok := normalizeName(*request.Name)
+		if !ok {
+			resp = &collection.CollectionResponse{
+				StatusCode: strings.CollectionNameInvalidCode,
+				StatusMsg:  strings.CollectionNameInvalid,
+			}
+			return
+		}
+		updates["name"] = name
+	}
+	if request.Private != nil {
+		updates["private"] = *request.Private
+	}
+
+	var c *models.Collection
+	var existed bool
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		if c, err = ownedCollection(ctx, tx, request.ActorId, request.CollectionId, true); err != nil {
+			return err
+		}
+
+		if name, ok := updates["name"]; ok && name != c.Name {
+			var count int64
+			if err := tx.Model(&models.Collection{}).
+				Where("user_id = ? AND name = ?", request.ActorId, name).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if existed = count > 0; existed {
+				return nil
+			}
+		}
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := tx.Model(c).Updates(updates).Error; err != nil {
+			return err
+		}
+		if request.Name != nil {
+			c.Name = updates["name"].(string)
+		}
+		if request.Private != nil {
+			c.Private = *request.Private
+		}
+		return nil
+	})
+
+	if errors.Is(err, errCollectionNotFound) {
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.CollectionNotFoundCode,
+			StatusMsg:  strings.CollectionNotFound,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":          err,
+			"ActorId":      request.ActorId,
+			"CollectionId": request.CollectionId,
+		}).Errorf("Failed to update the collection")
+		logging.SetSpanError(span, err)
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.CollectionServiceInnerErrorCode,
+			StatusMsg:  strings.CollectionServiceInnerError,
+		}
+		return
+	}
+	if existed {
+		resp = &collection.CollectionResponse{
+			StatusCode: strings.CollectionNameExistedCode,
+			StatusMsg:  strings.CollectionNameExisted,
+		}
+		return
+	}
+
+	counts, err := s.countVideos(ctx, []uint32{c.ID})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":          err,
+			"CollectionId": c.ID,
+		}).Warnf("Failed to count videos in the collection")
+		err = nil
+	}
+	resp = &collection.CollectionResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Collection: toRpcCollection(c, counts[c.ID]),
+	}
+	return
+}
+
+func (s CollectionServiceImpl) DeleteCollection(ctx context.Context, request *collection.DeleteCollectionRequest) (resp *collection.CollectionActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "DeleteCollectionService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.DeleteCollection").WithContext(ctx)
+
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := ownedCollection(ctx, tx, request.ActorId, request.CollectionId, true)
+		if err != nil {
+			return err
+		}
+		if err := tx.Where("collection_id = ?", c.ID).Delete(&models.CollectionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(c).Error
+	})
+
+	return actionResponse(span, logger, err, "Failed to delete the collection", logrus.Fields{
+		"ActorId":      request.ActorId,
+		"CollectionId": request.CollectionId,
+	})
+}
+
+func (s CollectionServiceImpl) AddVideo(ctx context.Context, request *collection.CollectionVideoRequest) (resp *collection.CollectionActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "AddCollectionVideoService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.AddVideo").WithContext(ctx)
+
+	existed, err := feedClient.QueryVideoExisted(ctx, &feed.VideoExistRequest{
+		VideoId: request.VideoId,
+	})
+	if err != nil || existed.StatusCode != strings.ServiceOKCode {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"VideoId": request.VideoId,
+		}).Errorf("Query video existence happens error")
+		logging.SetSpanError(span, err)
+		resp = &collection.CollectionActionResponse{
+			StatusCode: strings.FeedServiceInnerErrorCode,
+			StatusMsg:  strings.FeedServiceInnerError,
+		}
+		return
+	}
+	if !existed.Existed {
+		resp = &collection.CollectionActionResponse{
+			StatusCode: strings.UnableToQueryVideoErrorCode,
+			StatusMsg:  strings.UnableToQueryVideoError,
+		}
+		return
+	}
+
+	// 新收藏的视频放在最前面
+	var duplicated bool
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := ownedCollection(ctx, tx, request.ActorId, request.CollectionId, true)
+		if err != nil {
+			return err
+		}
+
+		var first struct {
+			Position *int64
+		}
+		if err := tx.Model(&models.CollectionItem{}).
+			Select("min(position) AS position").
+			Where("collection_id = ?", c.ID).
+			Scan(&first).Error; err != nil {
+			return err
+		}
+		var position int64
+		if first.Position != nil {
+			position = *first.Position - positionGap
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CollectionItem{
+			CollectionId: c.ID,
+			VideoId:      request.VideoId,
+			Position:     position,
+		})
+		duplicated = result.Error == nil && result.RowsAffected == 0
+		return result.Error
+	})
+	if err == nil && duplicated {
+		resp = &collection.CollectionActionResponse{
+			StatusCode: strings.CollectionVideoExistedCode,
+			StatusMsg:  strings.CollectionVideoExisted,
+		}
+		return
+	}
+
+	return actionResponse(span, logger, err, "Failed to add the video to the collection", logrus.Fields{
+		"ActorId":      request.ActorId,
+		"CollectionId": request.CollectionId,
+		"VideoId":      request.VideoId,
+	})
+}
+
+func (s CollectionServiceImpl) RemoveVideo(ctx context.Context, request *collection.CollectionVideoRequest) (resp *collection.CollectionActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "RemoveCollectionVideoService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.RemoveVideo").WithContext(ctx)
+
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := ownedCollection(ctx, tx, request.ActorId, request.CollectionId, false)
+		if err != nil {
+			return err
+		}
+		result := tx.Where("collection_id = ? AND video_id = ?", c.ID, request.VideoId).Delete(&models.CollectionItem{})
+		if result.Error == nil && result.RowsAffected == 0 {
+			return errVideoNotInCollection
+		}
+		return result.Error
+	})
+
+	return actionResponse(span, logger, err, "Failed to remove the video from the collection", logrus.Fields{
+		"ActorId":      request.ActorId,
+		"CollectionId": request.CollectionId,
+		"VideoId":      request.VideoId,
+	})
+}
+
+func (s CollectionServiceImpl) MoveVideo(ctx context.Context, request *collection.MoveCollectionVideoRequest) (resp *collection.CollectionActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "MoveCollectionVideoService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.MoveVideo").WithContext(ctx)
+
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		c, err := ownedCollection(ctx, tx, request.ActorId, request.CollectionId, true)
+		if err != nil {
+			return err
+		}
+		return moveVideo(tx, c.ID, request.VideoId, request.AfterVideoId)
+	})
+
+	return actionResponse(span, logger, err, "Failed to move the video in the collection", logrus.Fields{
+		"ActorId":      request.ActorId,
+		"CollectionId": request.CollectionId,
+		"VideoId":      request.VideoId,
+		"AfterVideoId": request.AfterVideoId,
+	})
+}
+
+// moveVideo 将视频移动到 afterVideoId 之后，afterVideoId 为 0 时移动到最前面。
+// 调用方需要锁住收藏夹，保证同一时间只有一个请求修改位置
+func moveVideo(tx *gorm.DB, collectionId uint32, videoId uint32, afterVideoId uint32) error {
+	if videoId == afterVideoId {
+		return nil
+	}
+
+	var items []models.CollectionItem
+	if err := tx.Select("id", "video_id", "position").
+		Where("collection_id = ?", collectionId).
+		Order("position, id").
+		Find(&items).Error; err != nil {
+		return err
+	}
+
+	moving, after := -1, -1
+	for i, item := range items {
+		switch item.VideoId {
+		case videoId:
+			moving = i
+		case afterVideoId:
+			after = i
+		}
+	}
+	if moving < 0 || (afterVideoId != 0 && after < 0) {
+		return errVideoNotInCollection
+	}
+
+	// 去掉被移动的视频之后，目标位置的前后两个视频
+	rest := make([]models.CollectionItem, 0, len(items)-1)
+	rest = append(rest, items[:moving]...)
+	rest = append(rest, items[moving+1:]...)
+	index := 0
+	if afterVideoId != 0 {
+		for i, item := range rest {
+			if item.VideoId == afterVideoId {
+				index = i + 1
+				break
+			}
+		}
+	}
+
+	var position int64
+	switch {
+	case len(rest) == 0:
+		return nil
+	case index == 0:
+		position = rest[0].Position - positionGap
+	case index == len(rest):
+		position = rest[index-1].Position + positionGap
+	case rest[index].Position-rest[index-1].Position >= 2:
+		position = rest[index-1].Position + (rest[index].Position-rest[index-1].Position)/2
+	default:
+		// 间隔已经用尽，按照新的顺序重新编号整个收藏夹
+		ordered := make([]models.CollectionItem, 0, len(items))
+		ordered = append(ordered, rest[:index]...)
+		ordered = append(ordered, items[moving])
+		ordered = append(ordered, rest[index:]...)
+		for i, item := range ordered {
+			if err := tx.Model(&models.CollectionItem{}).
+				Where("id = ?", item.ID).
+				Update("position", int64(i)*positionGap).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return tx.Model(&models.CollectionItem{}).
+		Where("id = ?", items[moving].ID).
+		Update("position", position).Error
+}
+
+// actionResponse 将写操作的结果转换为响应，预期内的错误只返回对应的状态码
+func actionResponse(span trace.Span, logger *logrus.Entry, err error, msg string, fields logrus.Fields) (*collection.CollectionActionResponse, error) {
+	switch {
+	case err == nil:
+		return &collection.CollectionActionResponse{
+			StatusCode: strings.ServiceOKCode,
+			StatusMsg:  strings.ServiceOK,
+		}, nil
+	case errors.Is(err, errCollectionNotFound):
+		return &collection.CollectionActionResponse{
+			StatusCode: strings.CollectionNotFoundCode,
+			StatusMsg:  strings.CollectionNotFound,
+		}, nil
+	case errors.Is(err, errVideoNotInCollection):
+		return &collection.CollectionActionResponse{
+			StatusCode: strings.CollectionVideoNotFoundCode,
+			StatusMsg:  strings.CollectionVideoNotFound,
+		}, nil
+	}
+
+	fields["err"] = err
+	logger.WithFields(fields).Errorf(msg)
+	logging.SetSpanError(span, err)
+	return &collection.CollectionActionResponse{
+		StatusCode: strings.CollectionServiceInnerErrorCode,
+		StatusMsg:  strings.CollectionServiceInnerError,
+	}, err
+}
+
+func (s CollectionServiceImpl) ListCollections(ctx context.Context, request *collection.ListCollectionsRequest) (resp *collection.ListCollectionsResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListCollectionsService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.ListCollections").WithContext(ctx)
+
+	query := s.deps.DB.WithContext(ctx).Where("user_id = ?", request.UserId)
+	if request.ActorId != request.UserId {
+		query = query.Where("private = ?", false)
+	}
+	var collections []models.Collection
+	if err = query.Order("id").Find(&collections).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":    err,
+			"UserId": request.UserId,
+		}).Errorf("Failed to list collections")
+		logging.SetSpanError(span, err)
+		resp = &collection.ListCollectionsResponse{
+			StatusCode: strings.CollectionServiceInnerErrorCode,
+			StatusMsg:  strings.CollectionServiceInnerError,
+		}
+		return
+	}
+
+	ids := make([]uint32, 0, len(collections))
+	for _, c := range collections {
+		ids = append(ids, c.ID)
+	}
+	counts, err := s.countVideos(ctx, ids)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":    err,
+			"UserId": request.UserId,
+		}).Errorf("Failed to count videos in collections")
+		logging.SetSpanError(span, err)
+		resp = &collection.ListCollectionsResponse{
+			StatusCode: strings.CollectionServiceInnerErrorCode,
+			StatusMsg:  strings.CollectionServiceInnerError,
+		}
+		return
+	}
+
+	resp = &collection.ListCollectionsResponse{
+		StatusCode:     strings.ServiceOKCode,
+		StatusMsg:      strings.ServiceOK,
+		CollectionList: make([]*collection.Collection, 0, len(collections)),
+	}
+	for i := range collections {
+		resp.CollectionList = append(resp.CollectionList, toRpcCollection(&collections[i], counts[collections[i].ID]))
+	}
+	return
+}
+
+func (s CollectionServiceImpl) ListCollectionVideos(ctx context.Context, request *collection.ListCollectionVideosRequest) (resp *collection.ListCollectionVideosResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListCollectionVideosService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CollectionService.ListCollectionVideos").WithContext(ctx)
+
+	var c models.Collection
+	err = s.deps.DB.WithContext(ctx).Where("id = ?", request.CollectionId).Take(&c).Error
+	// 私密收藏夹对其他用户表现为不存在
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && c.Private && c.UserId != request.ActorId) {
+		resp = &collection.ListCollectionVideosResponse{
+			StatusCode: strings.CollectionNotFoundCode,
+			StatusMsg:  strings.CollectionNotFound,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":          err,
+			"CollectionId": request.CollectionId,
+		}).Errorf("Failed to query the collection")
+		logging.SetSpanError(span, err)
+		resp = &collection.ListCollectionVideosResponse{
+			StatusCode: strings.CollectionServiceInnerErrorCode,
+			StatusMsg:  strings.CollectionServiceInnerError,
+		}
+		return
+	}
+
+	limit := int(request.Limit)
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	if limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	query := s.deps.DB.WithContext(ctx).Where("collection_id = ?", c.ID)
+	if request.Cursor != nil {
+		query = query.Where("position > ?", *request.Cursor)
+	}
+	// 多取一条用于判断是否还有下一页
+	var items []models.CollectionItem
+	if err = query.Order("position, id").Limit(limit + 1).Find(&items).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":          err,
+			"CollectionId": request.CollectionId,
+		}).Errorf("Failed to list videos in the collection")
+		logging.SetSpanError(span, err)
+		resp = &collection.ListCollectionVideosResponse{
+			StatusCode: strings.CollectionServiceInnerErrorCode,
+			StatusMsg:  strings.CollectionServiceInnerError,
+		}
+		return
+	}
+
+	resp = &collection.ListCollectionVideosResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	if len(items) > limit {
+		items = items[:limit]
+		next := items[limit-1].Position
+		resp.NextCursor = &next
+	}
+	if len(items) == 0 {
+		return
+	}
+
+	videoIds := make([]uint32, 0, len(items))
+	for _, item := range items {
+		videoIds = append(videoIds, item.VideoId)
+	}
+	videos, err := feedClient.QueryVideos(ctx, &feed.QueryVideosRequest{
+		ActorId:  request.ActorId,
+		VideoIds: videoIds,
+	})
+	if err != nil || videos.StatusCode != strings.ServiceOKCode {
+		logger.WithFields(logrus.Fields{
+			"err":      err,
+			"VideoIds": videoIds,
+		}).Errorf("Failed to query videos from feed service")
+		logging.SetSpanError(span, err)
+		resp = &collection.ListCollectionVideosResponse{
+			StatusCode: strings.FeedServiceInnerErrorCode,
+			StatusMsg:  strings.FeedServiceInnerError,
+		}
+		return
+	}
+
+	// QueryVideos 不保证返回的顺序，按照收藏夹中的顺序重新排列
+	byId := make(map[uint32]*feed.Video, len(videos.VideoList))
+	for _, video := range videos.VideoList {
+		byId[video.Id] = video
+	}
+	resp.VideoList = make([]*feed.Video, 0, len(videoIds))
+	for _, id := range videoIds {
+		if video, ok := byId[id]; ok {
+			resp.VideoList = append(resp.VideoList, video)
+		}
+	}
+	return
+}
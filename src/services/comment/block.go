@@ -0,0 +1,97 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/comment"
+	"GuGoTik/src/rpc/relation"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+var relationClient relation.RelationServiceClient
+
+// hiddenUsers 查询需要对当前用户隐藏评论的用户，包括存在拉黑关系与被当前用户屏蔽的用户，未登录时为空
+func hiddenUsers(ctx context.Context, actorId uint32) ([]uint32, error) {
+	if actorId == 0 {
+		return nil, nil
+	}
+	resp, err := relationClient.GetHiddenUsers(ctx, &relation.HiddenUsersRequest{ActorId: actorId})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != strings.ServiceOKCode {
+		return nil, fmt.Errorf("query hidden users failed: %s", resp.StatusMsg)
+	}
+	return append(resp.BlockedIds, resp.MutedIds...), nil
+}
+
+// hideUsers 过滤掉 userIds 中用户发布的评论
+func hideUsers(userIds []uint32) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(userIds) == 0 {
+			return db
+		}
+		return db.Where("user_id NOT IN ?", userIds)
+	}
+}
+
+// checkBlocked 评论者与视频作者或被回复的评论作者存在拉黑关系时不能评论，返回对应的响应。
+// 点赞评论时 parentId 为被点赞的评论
+func (c CommentServiceImpl) checkBlocked(ctx context.Context, logger *logrus.Entry, span trace.Span, actorId uint32, videoId uint32, parentId uint32) *comment.ActionCommentResponse {
+	owner, err := c.videoOwner(ctx, videoId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      err,
+			"video_id": videoId,
+		}).Errorf("Failed to query the owner of the video")
+		logging.SetSpanError(span, err)
+		return &comment.ActionCommentResponse{
+			StatusCode: strings.UnableToCreateCommentErrorCode,
+			StatusMsg:  strings.UnableToCreateCommentError,
+		}
+	}
+	targets := []uint32{owner}
+
+	if parentId != 0 {
+		var parent models.Comment
+		// 回复的评论不存在时由写入评论时的检查返回错误
+		if err := c.deps.DB.WithContext(ctx).Select("id", "user_id").Where("id = ?", parentId).Take(&parent).Error; err == nil {
+			targets = append(targets, parent.UserId)
+		}
+	}
+
+	for _, target := range targets {
+		if target == actorId {
+			continue
+		}
+		resp, err := relationClient.IsBlocked(ctx, &relation.IsBlockedRequest{
+			ActorId: actorId,
+			UserId:  target,
+		})
+		if err != nil || resp.StatusCode != strings.ServiceOKCode {
+			logger.WithFields(logrus.Fields{
+				"err":     err,
+				"user_id": actorId,
+				"target":  target,
+			}).Errorf("Relation service error")
+			logging.SetSpanError(span, err)
+			return &comment.ActionCommentResponse{
+				StatusCode: strings.UnableToCreateCommentErrorCode,
+				StatusMsg:  strings.UnableToCreateCommentError,
+			}
+		}
+		if resp.Result {
+			return &comment.ActionCommentResponse{
+				StatusCode: strings.UserBlockedCode,
+				StatusMsg:  strings.UserBlocked,
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/comment"
+	"GuGoTik/src/rpc/user"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/mention"
+	"GuGoTik/src/utils/moderation"
+	"context"
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// errEditExpired 评论已经超过可编辑的时长
+var errEditExpired = errors.New("comment edit window expired")
+
+// errNotCommentAuthor 只有评论作者可以编辑评论
+var errNotCommentAuthor = errors.New("not the comment author")
+
+// errCommentUnderReview 被审核暂扣或驳回的评论不能编辑，避免通过编辑绕过人工审核
+var errCommentUnderReview = errors.New("comment is under review")
+
+// videoOwner 查询视频的作者，视频不存在时返回 gorm.ErrRecordNotFound
+func (c CommentServiceImpl) videoOwner(ctx context.Context, videoId uint32) (uint32, error) {
+	var video models.Video
+	if err := c.deps.DB.WithContext(ctx).Select("id", "user_id").Where("id = ?", videoId).Take(&video).Error; err != nil {
+		return 0, err
+	}
+	return video.UserId, nil
+}
+
+// editComment 修改评论内容，修改前的内容写入修订历史，新的内容重新经过审核
+func (c CommentServiceImpl) editComment(ctx context.Context, logger *logrus.Entry, span trace.Span, pUser *user.User, pVideoID uint32, commentID uint32, pCommentText string) (resp *comment.ActionCommentResponse, err error) {
+	// 1. 封禁期间不能编辑评论
+	if resp = c.checkBanned(ctx, logger, span, pUser.Id); resp != nil {
+		return
+	}
+
+	// 2. 新的内容重新经过本地审核
+	verdict, _ := localModerator.Moderate(ctx, pCommentText)
+	if verdict.Decision == moderation.Reject {
+		logger.WithFields(logrus.Fields{
+			"user_id":    pUser.Id,
+			"comment_id": commentID,
+			"reason":     verdict.Reason,
+		}).Infof("Edited comment rejected by moderation")
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.CommentRejectedCode,
+			StatusMsg:  strings.CommentRejected,
+		}
+		return
+	}
+
+	mentions, mentionErr := mention.Resolve(ctx, userClient, relationClient, models.MentionInComment, pUser.Id, pCommentText)
+	if mentionErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        mentionErr,
+			"user_id":    pUser.Id,
+			"comment_id": commentID,
+		}).Warnf("Failed to resolve the mentions of the comment")
+		mentions = nil
+	}
+
+	// 3. 锁住评论后写入修订历史并更新内容，@ 记录整体替换，已经通知过的用户由通知的事件 ID 去重
+	var rComment models.Comment
+	txErr := c.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("video_id = ? AND id = ? AND tombstoned = false", pVideoID, commentID).
+			Take(&rComment).Error; err != nil {
+			return err
+		}
+		if rComment.UserId != pUser.Id {
+			return errNotCommentAuthor
+		}
+		if time.Since(rComment.CreatedAt) > c.editWindow {
+			return errEditExpired
+		}
+		if rComment.ModerationDecision != uint32(moderation.Allow) {
+			return errCommentUnderReview
+		}
+
+		if err := tx.Create(&models.CommentRevision{
+			CommentId: rComment.ID,
+			Content:   rComment.Content,
+		}).Error; err != nil {
+			return err
+		}
+		now := time.Now()
+		rComment.Content = pCommentText
+		rComment.EditedAt = &now
+		applyModeration(&rComment, verdict)
+		if err := tx.Model(&rComment).
+			Select(append([]string{"content", "edited_at"}, moderationColumns...)).
+			Updates(&rComment).Error; err != nil {
+			return err
+		}
+
+		if err := mention.Remove(ctx, tx, models.MentionInComment, rComment.ID); err != nil {
+			return err
+		}
+		if err := mention.Save(ctx, tx, rComment.ID, mentions); err != nil {
+			return err
+		}
+		if verdict.Decision != moderation.Allow {
+			return moderation.SubmitReview(ctx, tx, models.ModerationItemComment, rComment.ID, pUser.Id, pCommentText, verdict.Reason)
+		}
+		return mention.Notify(ctx, tx, rComment.ID, pVideoID, mentions)
+	})
+
+	switch {
+	case errors.Is(txErr, gorm.ErrRecordNotFound):
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.CommentNotFoundCode,
+			StatusMsg:  strings.CommentNotFound,
+		}
+		return
+	case errors.Is(txErr, errNotCommentAuthor):
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.ActorIDNotMatchErrorCode,
+			StatusMsg:  strings.ActorIDNotMatchError,
+		}
+		return
+	case errors.Is(txErr, errEditExpired):
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.CommentEditExpiredCode,
+			StatusMsg:  strings.CommentEditExpired,
+		}
+		return
+	case errors.Is(txErr, errCommentUnderReview):
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.CommentUnderReviewCode,
+			StatusMsg:  strings.CommentUnderReview,
+		}
+		return
+	case txErr != nil:
+		logger.WithFields(logrus.Fields{
+			"err":        txErr,
+			"comment_id": commentID,
+			"video_id":   pVideoID,
+		}).Errorf("CommentService failed to edit the comment")
+		logging.SetSpanError(span, txErr)
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.UnableToCreateCommentErrorCode,
+			StatusMsg:  strings.UnableToCreateCommentError,
+		}
+		return
+	}
+
+	// 4. 通过本地审核的内容再交给 ChatGPT 复审
+	if verdict.Decision == moderation.Allow {
+		go c.rateComment(logger, span, pCommentText, verdict, rComment.ID, pVideoID, pUser.Id)
+	}
+
+	resp = &comment.ActionCommentResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Comment:    convertComment(&rComment, map[uint32]*user.User{pUser.Id: pUser}),
+	}
+	resp.Comment.Mentions = toRpcMentions(mentions)
+	return
+}
+
+// pinnedComment 查询视频置顶的评论，没有置顶时返回 0
+func (c CommentServiceImpl) pinnedComment(ctx context.Context, videoId uint32) (uint32, error) {
+	var pin models.CommentPin
+	err := c.deps.DB.WithContext(ctx).Where("video_id = ?", videoId).Take(&pin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	return pin.CommentId, err
+}
+
+// movePinnedFirst 把置顶的评论移动到列表的最前面，置顶的评论不在列表中时返回 false
+func movePinnedFirst(pCommentList []models.Comment, pinnedId uint32) bool {
+	for i := range pCommentList {
+		if pCommentList[i].ID != pinnedId {
+			continue
+		}
+		pinned := pCommentList[i]
+		copy(pCommentList[1:i+1], pCommentList[:i])
+		pCommentList[0] = pinned
+		return true
+	}
+	return false
+}
+
+// PinComment 视频作者置顶一条顶层评论，新的置顶会替换原有的置顶
+func (c CommentServiceImpl) PinComment(ctx context.Context, request *comment.PinCommentRequest) (resp *comment.PinCommentResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "PinCommentService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CommentService.PinComment").WithContext(ctx)
+
+	owner, err := c.videoOwner(ctx, request.VideoId)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		resp = &comment.PinCommentResponse{
+			StatusCode: strings.UnableToQueryVideoErrorCode,
+			StatusMsg:  strings.UnableToQueryVideoError,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      err,
+			"video_id": request.VideoId,
+		}).Errorf("Failed to query the owner of the video")
+		logging.SetSpanError(span, err)
+		resp = &comment.PinCommentResponse{
+			StatusCode: strings.UnableToQueryVideoErrorCode,
+			StatusMsg:  strings.UnableToQueryVideoError,
+		}
+		return
+	}
+	if owner != request.ActorId {
+		resp = &comment.PinCommentResponse{
+			StatusCode: strings.CommentPinForbiddenCode,
+			StatusMsg:  strings.CommentPinForbidden,
+		}
+		return
+	}
+
+	if request.Unpin {
+		err = c.deps.DB.WithContext(ctx).Where("video_id = ?", request.VideoId).Delete(&models.CommentPin{}).Error
+	} else {
+		err = c.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			// 锁住评论，避免置顶与删除评论并发时置顶已经删除的评论
+			var target models.Comment
+			if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+				Select("id").
+				Where("video_id = ? AND id = ? AND parent_id = 0 AND tombstoned = false", request.VideoId, request.CommentId).
+				Scopes(visibleComments).
+				Take(&target).Error; err != nil {
+				return err
+			}
+			return tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "video_id"}},
+				DoUpdates: clause.AssignmentColumns([]string{"comment_id", "created_at"}),
+			}).Create(&models.CommentPin{
+				VideoId:   request.VideoId,
+				CommentId: target.ID,
+			}).Error
+		})
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		resp = &comment.PinCommentResponse{
+			StatusCode: strings.CommentPinInvalidCode,
+			StatusMsg:  strings.CommentPinInvalid,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        err,
+			"video_id":   request.VideoId,
+			"comment_id": request.CommentId,
+			"unpin":      request.Unpin,
+		}).Errorf("Failed to pin the comment")
+		logging.SetSpanError(span, err)
+		resp = &comment.PinCommentResponse{
+			StatusCode: strings.UnableToQueryCommentErrorCode,
+			StatusMsg:  strings.UnableToQueryCommentError,
+		}
+		return
+	}
+
+	resp = &comment.PinCommentResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
@@ -7,22 +7,23 @@ import (
 	"GuGoTik/src/models"
 	"GuGoTik/src/rpc/comment"
 	"GuGoTik/src/rpc/feed"
+	"GuGoTik/src/rpc/relation"
 	"GuGoTik/src/rpc/user"
 	"GuGoTik/src/storage/cached"
-	"GuGoTik/src/storage/database"
-	"GuGoTik/src/storage/redis"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
-	"GuGoTik/src/utils/rabbitmq"
+	"GuGoTik/src/utils/mention"
+	"GuGoTik/src/utils/moderation"
+	"GuGoTik/src/utils/outbox"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/go-redis/redis_rate/v10"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strconv"
-	"sync"
 	"time"
 )
 
@@ -37,12 +38,6 @@ var rateCommentLimitKey = config.EnvCfg.RedisPrefix + "rate_comment_freq_limit"
 
 const rateCommentMaxQPM = 3 // Maximum RateComment query amount
 
-func exitOnError(err error) {
-	if err != nil {
-		panic(err)
-	}
-}
-
 // Return redis key to record the amount of ActionComment query of an actor, e.g., comment_freq_limit-1-1669524458
 func actionCommentLimitKey(userId uint32) string {
 	return fmt.Sprintf("%s-%d", actionCommentLimitKeyPrefix, userId)
@@ -50,83 +45,29 @@ func actionCommentLimitKey(userId uint32) string {
 
 type CommentServiceImpl struct {
 	comment.CommentServiceServer
+	deps       *deps.Container
+	editWindow time.Duration // 评论发布后允许作者编辑的时长
 }
 
-var conn *amqp.Connection
-
-var channel *amqp.Channel
-
-func (c CommentServiceImpl) New() {
-	userRpcConn := grpc2.Connect(config.UserRpcServerName)
-	userClient = user.NewUserServiceClient(userRpcConn)
-
-	feedRpcConn := grpc2.Connect(config.FeedRpcServerName)
-	feedClient = feed.NewFeedServiceClient(feedRpcConn)
-
-	var err error
-
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
-	exitOnError(err)
-
-	channel, err = conn.Channel()
-	exitOnError(err)
-
-	err = channel.ExchangeDeclare(
-		strings.EventExchange,
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	exitOnError(err)
-}
-
-func CloseMQConn() {
-	if err := conn.Close(); err != nil {
-		panic(err)
-	}
-
-	if err := channel.Close(); err != nil {
-		panic(err)
-	}
-}
-
-func produceComment(ctx context.Context, event models.RecommendEvent) {
-	ctx, span := tracing.Tracer.Start(ctx, "CommentPublisher")
-	defer span.End()
-	logging.SetSpanWithHostname(span)
-	logger := logging.LogService("CommentService.CommentPublisher").WithContext(ctx)
-	data, err := json.Marshal(event)
+func (c *CommentServiceImpl) New(container *deps.Container) {
+	c.deps = container
+	editWindow, err := time.ParseDuration(config.EnvCfg.CommentEditWindow)
 	if err != nil {
-		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("Error when marshal the event model")
-		logging.SetSpanError(span, err)
-		return
+		logging.Logger.WithFields(logrus.Fields{
+			"err":    err,
+			"window": config.EnvCfg.CommentEditWindow,
+		}).Panicf("Invalid COMMENT_EDIT_WINDOW")
 	}
+	c.editWindow = editWindow
 
-	headers := rabbitmq.InjectAMQPHeaders(ctx)
+	userRpcConn := container.Dial(config.UserRpcServerName)
+	userClient = user.NewUserServiceClient(userRpcConn)
 
-	err = channel.PublishWithContext(ctx,
-		strings.EventExchange,
-		strings.VideoCommentEvent,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        data,
-			Headers:     headers,
-		})
+	feedRpcConn := container.Dial(config.FeedRpcServerName)
+	feedClient = feed.NewFeedServiceClient(feedRpcConn)
 
-	if err != nil {
-		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("Error when publishing the event model")
-		logging.SetSpanError(span, err)
-		return
-	}
+	relationRpcConn := container.Dial(config.RelationRpcServerName)
+	relationClient = relation.NewRelationServiceClient(relationRpcConn)
 }
 
 // ActionComment 自身服务调用：评论/删除评论
@@ -141,6 +82,7 @@ func (c CommentServiceImpl) ActionComment(ctx context.Context, request *comment.
 		"action_type":  request.ActionType,
 		"comment_text": request.GetCommentText(),
 		"comment_id":   request.GetCommentId(),
+		"parent_id":    request.ParentId,
 	}).Debugf("Process start")
 
 	var pCommentText string
@@ -151,6 +93,9 @@ func (c CommentServiceImpl) ActionComment(ctx context.Context, request *comment.
 		pCommentText = request.GetCommentText()
 	case comment.ActionCommentType_ACTION_COMMENT_TYPE_DELETE:
 		pCommentID = request.GetCommentId()
+	case comment.ActionCommentType_ACTION_COMMENT_TYPE_EDIT:
+		pCommentText = request.GetCommentText()
+		pCommentID = request.EditCommentId
 	case comment.ActionCommentType_ACTION_COMMENT_TYPE_UNSPECIFIED:
 		fallthrough
 	default:
@@ -163,7 +108,7 @@ func (c CommentServiceImpl) ActionComment(ctx context.Context, request *comment.
 	}
 
 	// 1. 限流
-	limiter := redis_rate.NewLimiter(redis.Client)
+	limiter := redis_rate.NewLimiter(c.deps.Redis)
 	limiterKey := actionCommentLimitKey(request.ActorId)
 	limiterRes, err := limiter.Allow(ctx, limiterKey, redis_rate.PerSecond(actionCommentMaxQPS))
 	if err != nil {
@@ -248,12 +193,14 @@ func (c CommentServiceImpl) ActionComment(ctx context.Context, request *comment.
 	}
 
 	pUser := userResponse.User
-	// 4. 评论或删除评论
+	// 4. 评论、删除或编辑评论
 	switch request.ActionType {
 	case comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD:
-		resp, err = addComment(ctx, logger, span, pUser, request.VideoId, pCommentText)
+		resp, err = c.addComment(ctx, logger, span, pUser, request.VideoId, request.ParentId, pCommentText)
 	case comment.ActionCommentType_ACTION_COMMENT_TYPE_DELETE:
-		resp, err = deleteComment(ctx, logger, span, pUser, request.VideoId, pCommentID)
+		resp, err = c.deleteComment(ctx, logger, span, pUser, request.VideoId, pCommentID)
+	case comment.ActionCommentType_ACTION_COMMENT_TYPE_EDIT:
+		resp, err = c.editComment(ctx, logger, span, pUser, request.VideoId, pCommentID, pCommentText)
 	}
 
 	if err != nil {
@@ -279,8 +226,17 @@ func (c CommentServiceImpl) ListComment(ctx context.Context, request *comment.Li
 	logger.WithFields(logrus.Fields{
 		"user_id":  request.ActorId,
 		"video_id": request.VideoId,
+		"sort":     request.Sort,
 	}).Debugf("Process start")
 
+	if _, ok := comment.CommentSort_name[int32(request.Sort)]; !ok {
+		resp = &comment.ListCommentResponse{
+			StatusCode: strings.CommentSortInvalidCode,
+			StatusMsg:  strings.CommentSortInvalid,
+		}
+		return
+	}
+
 	// 1. 检查视频是否存在
 	videoExistResp, err := feedClient.QueryVideoExisted(ctx, &feed.VideoExistRequest{
 		VideoId: request.VideoId,
@@ -308,19 +264,32 @@ func (c CommentServiceImpl) ListComment(ctx context.Context, request *comment.Li
 		}
 		return
 	}
-	// 2. 从 DB 获取评论
+	// 2. 从 DB 获取顶层评论，不返回与当前用户存在拉黑关系或被当前用户屏蔽的用户的评论
+	hidden, hiddenErr := hiddenUsers(ctx, request.ActorId)
+	if hiddenErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     hiddenErr,
+			"user_id": request.ActorId,
+		}).Errorf("CommentService list comment failed to query hidden users")
+		logging.SetSpanError(span, hiddenErr)
+
+		resp = &comment.ListCommentResponse{
+			StatusCode: strings.UnableToQueryCommentErrorCode,
+			StatusMsg:  strings.UnableToQueryCommentError,
+		}
+		return
+	}
 	var pCommentList []models.Comment
-	result := database.Client.WithContext(ctx).
-		Where("video_id = ?", request.VideoId).
-		Where("rate <= 3").
-		//Where("moderation_flagged = false").
+	result := c.deps.DB.WithContext(ctx).
+		Where("video_id = ? AND parent_id = 0", request.VideoId).
+		Scopes(visibleComments, hideUsers(hidden)).
 		Order("created_at desc").
 		Find(&pCommentList)
 	if result.Error != nil {
 		logger.WithFields(logrus.Fields{
 			"err": result.Error,
 		}).Errorf("CommentService list comment failed to response when listing comments")
-		logging.SetSpanError(span, err)
+		logging.SetSpanError(span, result.Error)
 
 		resp = &comment.ListCommentResponse{
 			StatusCode: strings.UnableToQueryCommentErrorCode,
@@ -329,41 +298,66 @@ func (c CommentServiceImpl) ListComment(ctx context.Context, request *comment.Li
 		return
 	}
 
-	// 3. 把特定评论排在列表前面的位置
+	// 3. 按热度排序时使用热度集合中的顺序，再把特定评论排在列表前面的位置
+	if request.Sort == comment.CommentSort_COMMENT_SORT_HOT {
+		if hotErr := c.sortByHot(ctx, request.VideoId, pCommentList); hotErr != nil {
+			// 热度集合不可用时退化为按时间排序
+			logger.WithFields(logrus.Fields{
+				"err":      hotErr,
+				"video_id": request.VideoId,
+			}).Warnf("Failed to sort comments by hot score")
+		}
+	}
 	reindexCommentList(&pCommentList)
 
-	// 4. 获取每条评论的用户信息
-	rCommentList := make([]*comment.Comment, 0, result.RowsAffected)
-	userMap := make(map[uint32]*user.User)
+	// 视频作者置顶的评论总是排在最前面
+	pinnedId, pinErr := c.pinnedComment(ctx, request.VideoId)
+	if pinErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      pinErr,
+			"video_id": request.VideoId,
+		}).Warnf("Failed to query the pinned comment")
+	}
+	if pinnedId != 0 && !movePinnedFirst(pCommentList, pinnedId) {
+		pinnedId = 0
+	}
+
+	// 4. 获取每条顶层评论的回复数量与最早的几条回复
+	rootIds := make([]uint32, 0, len(pCommentList))
 	for _, pComment := range pCommentList {
-		userMap[pComment.UserId] = &user.User{}
-	}
-	getUserInfoError := false
-
-	wg := sync.WaitGroup{}
-	wg.Add(len(userMap))
-	for userId := range userMap {
-		go func(userId uint32) {
-			defer wg.Done()
-			userResponse, getUserErr := userClient.GetUserInfo(ctx, &user.UserRequest{
-				UserId:  userId,
-				ActorId: request.ActorId,
-			})
-			if err != nil || userResponse.StatusCode != strings.ServiceOKCode {
-				logger.WithFields(logrus.Fields{
-					"err":     getUserErr,
-					"user_id": userId,
-				}).Errorf("Unable to get user info")
-				logging.SetSpanError(span, getUserErr)
-				getUserInfoError = true
-				err = getUserErr
-			}
-			userMap[userId] = userResponse.User
-		}(userId)
+		rootIds = append(rootIds, pComment.ID)
+	}
+	replyCounts, replyPreviews, replyErr := c.previewReplies(ctx, rootIds, hidden)
+	if replyErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      replyErr,
+			"video_id": request.VideoId,
+		}).Errorf("CommentService list comment failed to response when listing replies")
+		logging.SetSpanError(span, replyErr)
+
+		resp = &comment.ListCommentResponse{
+			StatusCode: strings.UnableToQueryCommentErrorCode,
+			StatusMsg:  strings.UnableToQueryCommentError,
+		}
+		return
 	}
-	wg.Wait()
 
-	if getUserInfoError {
+	// 5. 获取评论与回复的用户信息
+	userIds := make([]uint32, 0, len(pCommentList))
+	for _, pComment := range pCommentList {
+		userIds = append(userIds, pComment.UserId)
+		for _, reply := range replyPreviews[pComment.ID] {
+			userIds = append(userIds, reply.UserId)
+		}
+	}
+	userMap, userErr := queryUsers(ctx, request.ActorId, userIds)
+	if userErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      userErr,
+			"user_ids": userIds,
+		}).Errorf("Unable to get user info")
+		logging.SetSpanError(span, userErr)
+
 		resp = &comment.ListCommentResponse{
 			StatusCode: strings.UnableToQueryUserErrorCode,
 			StatusMsg:  strings.UnableToQueryUserError,
@@ -372,15 +366,34 @@ func (c CommentServiceImpl) ListComment(ctx context.Context, request *comment.Li
 	}
 
 	// 返回响应
+	rCommentList := make([]*comment.Comment, 0, len(pCommentList))
 	for _, pComment := range pCommentList {
-		curUser := userMap[pComment.UserId]
+		rComment := convertComment(&pComment, userMap)
+		rComment.ReplyCount = replyCounts[pComment.ID]
+		rComment.Pinned = pComment.ID == pinnedId
+		for i := range replyPreviews[pComment.ID] {
+			rComment.Replies = append(rComment.Replies, convertComment(&replyPreviews[pComment.ID][i], userMap))
+		}
+		rCommentList = append(rCommentList, rComment)
+	}
 
-		rCommentList = append(rCommentList, &comment.Comment{
-			Id:         pComment.ID,
-			User:       curUser,
-			Content:    pComment.Content,
-			CreateDate: pComment.CreatedAt.Format("01-02"),
-		})
+	// 6. 标记当前用户点赞过的评论与回复，并填充其中的 @
+	likeTargets := make([]*comment.Comment, 0, len(rCommentList))
+	for _, rComment := range rCommentList {
+		likeTargets = append(likeTargets, rComment)
+		likeTargets = append(likeTargets, rComment.Replies...)
+	}
+	if likeErr := c.markLiked(ctx, request.ActorId, likeTargets); likeErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      likeErr,
+			"video_id": request.VideoId,
+		}).Warnf("Failed to query the liked comments")
+	}
+	if mentionErr := c.attachMentions(ctx, likeTargets); mentionErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      mentionErr,
+			"video_id": request.VideoId,
+		}).Warnf("Failed to query the mentions of the comments")
 	}
 
 	resp = &comment.ListCommentResponse{
@@ -409,10 +422,10 @@ func (c CommentServiceImpl) CountComment(ctx context.Context, request *comment.C
 	countStringKey := fmt.Sprintf("CommentCount-%d", request.VideoId)
 	countString, err := cached.GetWithFunc(ctx, countStringKey,
 		func(ctx context.Context, key string) (string, error) {
-			rCount, err := count(ctx, request.VideoId) // 从 DB 获取评论数量
+			rCount, err := c.count(ctx, request.VideoId) // 从 DB 获取评论数量
 
 			return strconv.FormatInt(rCount, 10), err
-		})
+		}, cached.WithStaleWhileRevalidate(time.Hour, 5*time.Minute))
 
 	if err != nil {
 		cached.TagDelete(ctx, "CommentCount")
@@ -441,21 +454,101 @@ func (c CommentServiceImpl) CountComment(ctx context.Context, request *comment.C
 	return
 }
 
-func addComment(ctx context.Context, logger *logrus.Entry, span trace.Span, pUser *user.User, pVideoID uint32, pCommentText string) (resp *comment.ActionCommentResponse, err error) {
+func (c CommentServiceImpl) addComment(ctx context.Context, logger *logrus.Entry, span trace.Span, pUser *user.User, pVideoID uint32, pParentID uint32, pCommentText string) (resp *comment.ActionCommentResponse, err error) {
+	// 0. 封禁期间不能发布评论
+	if resp = c.checkBanned(ctx, logger, span, pUser.Id); resp != nil {
+		return
+	}
+	// 与视频作者或被回复的评论作者存在拉黑关系时不能评论
+	if resp = c.checkBlocked(ctx, logger, span, pUser.Id, pVideoID, pParentID); resp != nil {
+		return
+	}
+
+	// 1. 本地审核，违规的评论直接拒绝，需要复核的评论写入但暂不展示
+	verdict, _ := localModerator.Moderate(ctx, pCommentText)
+	if verdict.Decision == moderation.Reject {
+		logger.WithFields(logrus.Fields{
+			"user_id":  pUser.Id,
+			"video_id": pVideoID,
+			"reason":   verdict.Reason,
+		}).Infof("Comment rejected by moderation")
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.CommentRejectedCode,
+			StatusMsg:  strings.CommentRejected,
+		}
+		return
+	}
+
 	rComment := models.Comment{
-		VideoId: pVideoID,
-		UserId:  pUser.Id,
-		Content: pCommentText,
+		VideoId:  pVideoID,
+		UserId:   pUser.Id,
+		ParentId: pParentID,
+		Content:  pCommentText,
 	}
-	// 1. 写入DB
-	result := database.Client.WithContext(ctx).Create(&rComment)
-	if result.Error != nil {
+	applyModeration(&rComment, verdict)
+
+	// 2. 解析评论中的 @，查询失败时按普通文本发布
+	mentions, mentionErr := mention.Resolve(ctx, userClient, relationClient, models.MentionInComment, pUser.Id, pCommentText)
+	if mentionErr != nil {
 		logger.WithFields(logrus.Fields{
-			"err":        result.Error,
+			"err":      mentionErr,
+			"user_id":  pUser.Id,
+			"video_id": pVideoID,
+		}).Warnf("Failed to resolve the mentions of the comment")
+		mentions = nil
+	}
+
+	// 3. 写入DB，@ 记录、通知与推荐反馈在同一个事务中写入 Outbox
+	txErr := c.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if pParentID != 0 {
+			// 锁住被回复的评论，避免与删除评论并发时回复到已经删除的评论上
+			var parent models.Comment
+			if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+				Select("id", "root_id").
+				Where("video_id = ? AND id = ? AND tombstoned = false", pVideoID, pParentID).
+				Take(&parent).Error; err != nil {
+				return err
+			}
+			rComment.RootId = parent.RootId
+			if rComment.RootId == 0 {
+				rComment.RootId = parent.ID
+			}
+		}
+		if err := tx.Create(&rComment).Error; err != nil {
+			return err
+		}
+		if err := mention.Save(ctx, tx, rComment.ID, mentions); err != nil {
+			return err
+		}
+		if verdict.Decision != moderation.Allow {
+			// 需要复核的评论进入人工审核队列，暂不通知被 @ 的用户
+			return moderation.SubmitReview(ctx, tx, models.ModerationItemComment, rComment.ID, pUser.Id, pCommentText, verdict.Reason)
+		}
+		if err := mention.Notify(ctx, tx, rComment.ID, pVideoID, mentions); err != nil {
+			return err
+		}
+		return outbox.Enqueue(ctx, tx, strings.EventExchange, strings.VideoCommentEvent, models.RecommendEvent{
+			ActorId: pUser.Id,
+			VideoId: []uint32{pVideoID},
+			Type:    2,
+			Source:  config.CommentRpcServerName,
+		})
+	})
+	if errors.Is(txErr, gorm.ErrRecordNotFound) {
+		resp = &comment.ActionCommentResponse{
+			StatusCode: strings.CommentParentNotFoundCode,
+			StatusMsg:  strings.CommentParentNotFound,
+		}
+		return
+	}
+	if txErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        txErr,
 			"comment_id": rComment.ID,
 			"video_id":   pVideoID,
+			"parent_id":  pParentID,
 		}).Errorf("CommentService add comment action failed to response when adding comment")
-		logging.SetSpanError(span, result.Error)
+		logging.SetSpanError(span, txErr)
 
 		resp = &comment.ActionCommentResponse{
 			StatusCode: strings.UnableToCreateCommentErrorCode,
@@ -464,41 +557,37 @@ func addComment(ctx context.Context, logger *logrus.Entry, span trace.Span, pUse
 		return
 	}
 
-	// 2. 对评论进行评分
-	go rateComment(logger, span, pCommentText, rComment.ID)
+	// 4. 通过本地审核的评论再交给 ChatGPT 复审，等待复核的评论由人工处理
+	if verdict.Decision == moderation.Allow {
+		go c.rateComment(logger, span, pCommentText, verdict, rComment.ID, pVideoID, pUser.Id)
+	}
 
-	// 3. 写入MQ给推荐系统
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		produceComment(ctx, models.RecommendEvent{
-			ActorId: pUser.Id,
-			VideoId: []uint32{pVideoID},
-			Type:    2,
-			Source:  config.CommentRpcServerName,
-		})
-	}()
-	wg.Wait()
+	// 5. 新的顶层评论加入热度集合，回复则更新所属顶层评论的热度
+	hotTarget := rComment.RootId
+	if hotTarget == 0 {
+		hotTarget = rComment.ID
+	}
+	if hotErr := c.refreshHotScore(ctx, pVideoID, hotTarget); hotErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        hotErr,
+			"comment_id": hotTarget,
+		}).Warnf("Failed to refresh the hot score")
+	}
 
 	resp = &comment.ActionCommentResponse{
 		StatusCode: strings.ServiceOKCode,
 		StatusMsg:  strings.ServiceOK,
-		Comment: &comment.Comment{
-			Id:         rComment.ID,
-			User:       pUser,
-			Content:    rComment.Content,
-			CreateDate: rComment.CreatedAt.Format("01-02"),
-		},
+		Comment:    convertComment(&rComment, map[uint32]*user.User{pUser.Id: pUser}),
 	}
+	resp.Comment.Mentions = toRpcMentions(mentions)
 	return
 }
 
-func deleteComment(ctx context.Context, logger *logrus.Entry, span trace.Span, pUser *user.User, pVideoID uint32, commentID uint32) (resp *comment.ActionCommentResponse, err error) {
+func (c CommentServiceImpl) deleteComment(ctx context.Context, logger *logrus.Entry, span trace.Span, pUser *user.User, pVideoID uint32, commentID uint32) (resp *comment.ActionCommentResponse, err error) {
 	rComment := models.Comment{}
 	// 1. 查询评论信息
-	result := database.Client.WithContext(ctx).
-		Where("video_id = ? AND id = ?", pVideoID, commentID).
+	result := c.deps.DB.WithContext(ctx).
+		Where("video_id = ? AND id = ? AND tombstoned = false", pVideoID, commentID).
 		First(&rComment)
 	if result.Error != nil {
 		logger.WithFields(logrus.Fields{
@@ -514,22 +603,50 @@ func deleteComment(ctx context.Context, logger *logrus.Entry, span trace.Span, p
 		}
 		return
 	}
-	// 2. 检查评论创建者id和删除者id是否一致
+	// 2. 只有评论的作者与视频的作者可以删除评论
 	if rComment.UserId != pUser.Id {
-		logger.Errorf("Comment creator and deletor not match")
-		resp = &comment.ActionCommentResponse{
-			StatusCode: strings.ActorIDNotMatchErrorCode,
-			StatusMsg:  strings.ActorIDNotMatchError,
+		owner, ownerErr := c.videoOwner(ctx, pVideoID)
+		if ownerErr != nil {
+			logger.WithFields(logrus.Fields{
+				"err":      ownerErr,
+				"video_id": pVideoID,
+			}).Errorf("Failed to query the owner of the video")
+			logging.SetSpanError(span, ownerErr)
+			resp = &comment.ActionCommentResponse{
+				StatusCode: strings.UnableToQueryVideoErrorCode,
+				StatusMsg:  strings.UnableToQueryVideoError,
+			}
+			return
+		}
+		if owner != pUser.Id {
+			logger.Errorf("Comment creator and deletor not match")
+			resp = &comment.ActionCommentResponse{
+				StatusCode: strings.ActorIDNotMatchErrorCode,
+				StatusMsg:  strings.ActorIDNotMatchError,
+			}
+			return
 		}
-		return
 	}
-	// 3. 删除评论
-	result = database.Client.WithContext(ctx).Delete(&models.Comment{}, commentID)
-	if result.Error != nil {
+	// 3. 删除评论，仍有回复的评论只保留占位
+	txErr := c.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := removeComment(tx, commentID); err != nil {
+			return err
+		}
+		// 删除的评论不再保留置顶、修订历史与 @ 记录
+		if err := tx.Where("comment_id = ?", commentID).Delete(&models.CommentPin{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("comment_id = ?", commentID).Delete(&models.CommentRevision{}).Error; err != nil {
+			return err
+		}
+		return mention.Remove(ctx, tx, models.MentionInComment, commentID)
+	})
+	if txErr != nil {
 		logger.WithFields(logrus.Fields{
-			"err": result.Error,
+			"err":        txErr,
+			"comment_id": commentID,
 		}).Errorf("Failed to delete comment")
-		logging.SetSpanError(span, result.Error)
+		logging.SetSpanError(span, txErr)
 
 		resp = &comment.ActionCommentResponse{
 			StatusCode: strings.UnableToDeleteCommentErrorCode,
@@ -537,6 +654,19 @@ func deleteComment(ctx context.Context, logger *logrus.Entry, span trace.Span, p
 		}
 		return
 	}
+
+	// 4. 删除的顶层评论移出热度集合，回复则更新所属顶层评论的热度
+	hotTarget := rComment.RootId
+	if hotTarget == 0 {
+		hotTarget = rComment.ID
+	}
+	if hotErr := c.refreshHotScore(ctx, pVideoID, hotTarget); hotErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        hotErr,
+			"comment_id": hotTarget,
+		}).Warnf("Failed to refresh the hot score")
+	}
+
 	resp = &comment.ActionCommentResponse{
 		StatusCode: strings.ServiceOKCode,
 		StatusMsg:  strings.ServiceOK,
@@ -545,40 +675,30 @@ func deleteComment(ctx context.Context, logger *logrus.Entry, span trace.Span, p
 	return
 }
 
-func rateComment(logger *logrus.Entry, span trace.Span, commentContent string, commentID uint32) {
-	//moderationCommentRes := ModerationCommentByGPT(commentContent, logger, span)
-	//
-	//rComment := models.Comment{
-	//	ID:                        commentID,
-	//	ModerationFlagged:         moderationCommentRes.Flagged,
-	//	ModerationHate:            moderationCommentRes.Categories.Hate,
-	//	ModerationHateThreatening: moderationCommentRes.Categories.HateThreatening,
-	//	ModerationSelfHarm:        moderationCommentRes.Categories.SelfHarm,
-	//	ModerationSexual:          moderationCommentRes.Categories.Sexual,
-	//	ModerationSexualMinors:    moderationCommentRes.Categories.SexualMinors,
-	//	ModerationViolence:        moderationCommentRes.Categories.Violence,
-	//	ModerationViolenceGraphic: moderationCommentRes.Categories.ViolenceGraphic,
-	//}
-
-	rate := uint32(0)
-	reason := ""
-
-	limiter := redis_rate.NewLimiter(redis.Client)
+// rateComment 评论发布后异步使用 ChatGPT 复审，复审只会让审核结论更严格
+func (c CommentServiceImpl) rateComment(logger *logrus.Entry, span trace.Span, commentContent string, local moderation.Result, commentID uint32, videoID uint32, userID uint32) {
+	if llmModerator == nil {
+		return
+	}
+
+	var res moderation.Result
+	var err error
+	limiter := redis_rate.NewLimiter(c.deps.Redis)
 	limiterKey := rateCommentLimitKey
 	for {
-		limiterRes, err := limiter.Allow(context.Background(), limiterKey, redis_rate.PerMinute(rateCommentMaxQPM))
-		if err != nil {
+		limiterRes, limitErr := limiter.Allow(context.Background(), limiterKey, redis_rate.PerMinute(rateCommentMaxQPM))
+		if limitErr != nil {
 			logger.WithFields(logrus.Fields{
-				"err":             err,
+				"err":             limitErr,
 				"comment_id":      commentID,
 				"comment_content": commentContent,
 			}).Errorf("RateComment limiter error")
-			logging.SetSpanError(span, err)
-			break
+			logging.SetSpanError(span, limitErr)
+			return
 		}
 
 		if limiterRes.Allowed != 0 {
-			rate, reason, _ = RateCommentByGPT(commentContent, logger, span)
+			res, err = llmModerator.Moderate(context.Background(), commentContent)
 			break
 		}
 		logger.WithFields(logrus.Fields{
@@ -586,38 +706,59 @@ func rateComment(logger *logrus.Entry, span trace.Span, commentContent string, c
 		}).Debugf("Wait for ChatGPT API rate limit.")
 		time.Sleep(20 * time.Second)
 	}
+	if err != nil {
+		// ChatGPT 不可用时保留本地审核的结论
+		logger.WithFields(logrus.Fields{
+			"err":        err,
+			"comment_id": commentID,
+		}).Warnf("ChatGPT moderation failed, keep the local decision")
+		return
+	}
 
-	rComment := models.Comment{
-		ID:     commentID,
-		Rate:   rate,
-		Reason: reason,
+	merged := moderation.Merge(local, res)
+	if merged.Reason == "" {
+		merged.Reason = res.Reason
 	}
+	rComment := models.Comment{ID: commentID}
+	applyModeration(&rComment, merged)
 
-	result := database.Client.Updates(&rComment)
-	if result.Error != nil {
+	// 复审未通过的评论与审核结论一起进入人工审核队列
+	err = c.deps.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&rComment).Select(moderationColumns).Updates(&rComment).Error; err != nil {
+			return err
+		}
+		if merged.Decision == moderation.Allow {
+			return nil
+		}
+		return moderation.SubmitReview(context.Background(), tx, models.ModerationItemComment, commentID, userID, commentContent, merged.Reason)
+	})
+	if err != nil {
 		logger.WithFields(logrus.Fields{
-			"err":        result.Error,
+			"err":        err,
 			"comment_id": commentID,
 		}).Errorf("CommentService failed to add comment rate to database")
-		logging.SetSpanError(span, result.Error)
+		logging.SetSpanError(span, err)
+	} else if merged.Decision != moderation.Allow {
+		// 复审未通过的评论不再计入评论数量
+		cached.TagDelete(context.Background(), fmt.Sprintf("CommentCount-%d", videoID))
 	}
 	logger.WithFields(logrus.Fields{
 		"comment_id": commentID,
-		"rate":       rate,
-		"reason":     reason,
-		//"flagged":    rComment.ModerationFlagged,
+		"rate":       merged.Rate,
+		"reason":     merged.Reason,
+		"decision":   merged.Decision.String(),
+		"flagged":    merged.Flagged,
 	}).Debugf("Add comment rate successfully.")
 }
 
-func count(ctx context.Context, videoId uint32) (count int64, err error) {
+func (c CommentServiceImpl) count(ctx context.Context, videoId uint32) (count int64, err error) {
 	ctx, span := tracing.Tracer.Start(ctx, "CountComment")
 	defer span.End()
 	logger := logging.LogService("CommentService.CountComment").WithContext(ctx)
 
-	result := database.Client.Model(&models.Comment{}).WithContext(ctx).
-		Where("video_id = ?", videoId).
-		Where("rate <= 3").
-		//Where("moderation_flagged = false").
+	result := c.deps.DB.Model(&models.Comment{}).WithContext(ctx).
+		Where("video_id = ? AND tombstoned = false", videoId).
+		Scopes(visibleComments).
 		Count(&count)
 
 	if result.Error != nil {
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/models"
+	"GuGoTik/src/storage/database"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// 热度 = log10(点赞数 + 2*回复数) + 发布时间 / hotDecaySeconds，
+	// 发布时间晚 hotDecaySeconds 秒相当于互动数量多 10 倍，越早发布的评论需要越多互动才能排在前面
+	hotDecaySeconds = 45000
+	hotReplyWeight  = 2
+	hotEpoch        = 1672531200 // 2023-01-01 00:00:00 UTC，减小分数的绝对值
+
+	hotCommentsTTL = time.Hour
+)
+
+// 重建的热度集合中总是带有一个占位成员，没有评论的视频也能命中缓存，读取时跳过该成员
+const hotPlaceholder = "0"
+
+func hotCommentsKey(videoId uint32) string {
+	return fmt.Sprintf("%scomment_hot_%d", config.EnvCfg.RedisPrefix, videoId)
+}
+
+// hotScore 计算顶层评论的热度
+func hotScore(likes uint32, replies uint32, createdAt time.Time) float64 {
+	engagement := float64(likes) + hotReplyWeight*float64(replies)
+	return math.Log10(math.Max(engagement, 1)) + float64(createdAt.Unix()-hotEpoch)/hotDecaySeconds
+}
+
+// hotUpdateScript 只在热度集合已经缓存时修改，避免只包含部分评论的集合被当作完整的缓存
+var hotUpdateScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	return 0
+end
+if ARGV[2] == '' then
+	return redis.call('ZREM', KEYS[1], ARGV[1])
+end
+return redis.call('ZADD', KEYS[1], ARGV[2], ARGV[1])
+`)
+
+// refreshHotScore 点赞、回复或删除后重新计算顶层评论的热度，评论已经删除时从集合中移除
+func (c CommentServiceImpl) refreshHotScore(ctx context.Context, videoId uint32, commentId uint32) error {
+	db := c.deps.DB.WithContext(database.WithPrimary(ctx))
+
+	score := ""
+	var root models.Comment
+	err := db.Select("id", "like_count", "created_at").
+		Where("id = ? AND parent_id = 0", commentId).
+		Take(&root).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+	case err != nil:
+		return err
+	default:
+		replies, err := countReplies(db, []uint32{commentId}, nil)
+		if err != nil {
+			return err
+		}
+		score = strconv.FormatFloat(hotScore(root.LikeCount, replies[commentId], root.CreatedAt), 'f', -1, 64)
+	}
+
+	return hotUpdateScript.Run(ctx, c.deps.Redis, []string{hotCommentsKey(videoId)}, commentId, score).Err()
+}
+
+// ensureHotComments 热度集合不存在时从数据库重建，重建总是读主库
+func (c CommentServiceImpl) ensureHotComments(ctx context.Context, videoId uint32) error {
+	key := hotCommentsKey(videoId)
+	existed, err := c.deps.Redis.Exists(ctx, key).Result()
+	if err != nil || existed > 0 {
+		return err
+	}
+
+	db := c.deps.DB.WithContext(database.WithPrimary(ctx))
+	var comments []models.Comment
+	if err := db.Select("id", "like_count", "created_at").
+		Where("video_id = ? AND parent_id = 0", videoId).
+		Find(&comments).Error; err != nil {
+		return err
+	}
+	rootIds := make([]uint32, 0, len(comments))
+	for _, root := range comments {
+		rootIds = append(rootIds, root.ID)
+	}
+	replies, err := countReplies(db, rootIds, nil)
+	if err != nil {
+		return err
+	}
+
+	members := make([]redis.Z, 0, len(comments)+1)
+	members = append(members, redis.Z{Score: math.Inf(-1), Member: hotPlaceholder})
+	for _, root := range comments {
+		members = append(members, redis.Z{
+			Score:  hotScore(root.LikeCount, replies[root.ID], root.CreatedAt),
+			Member: root.ID,
+		})
+	}
+	_, err = c.deps.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, key)
+		pipe.ZAdd(ctx, key, members...)
+		pipe.Expire(ctx, key, hotCommentsTTL)
+		return nil
+	})
+	return err
+}
+
+// sortByHot 按热度集合中的顺序排列评论，集合中还没有的评论按原来的顺序排在最后
+func (c CommentServiceImpl) sortByHot(ctx context.Context, videoId uint32, commentList []models.Comment) error {
+	if err := c.ensureHotComments(ctx, videoId); err != nil {
+		return err
+	}
+	ids, err := c.deps.Redis.ZRevRange(ctx, hotCommentsKey(videoId), 0, -1).Result()
+	if err != nil {
+		return err
+	}
+
+	rank := make(map[uint32]int, len(ids))
+	for i, id := range ids {
+		if id == hotPlaceholder {
+			continue
+		}
+		commentId, err := strconv.ParseUint(id, 10, 32)
+		if err != nil {
+			continue
+		}
+		rank[uint32(commentId)] = i
+	}
+	sort.SliceStable(commentList, func(i, j int) bool {
+		ri, okI := rank[commentList[i].ID]
+		rj, okJ := rank[commentList[j].ID]
+		if okI != okJ {
+			return okI
+		}
+		return okI && ri < rj
+	})
+	return nil
+}
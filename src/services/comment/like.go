@@ -0,0 +1,198 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/comment"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var (
+	errCommentLikeDuplicate = errors.New("comment already liked")
+	errCommentLikeCancel    = errors.New("comment not liked")
+)
+
+// LikeComment 点赞/取消点赞评论，点赞记录与评论的点赞数量在同一个事务中修改
+func (c CommentServiceImpl) LikeComment(ctx context.Context, request *comment.LikeCommentRequest) (resp *comment.LikeCommentResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "LikeCommentService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CommentService.LikeComment").WithContext(ctx)
+	logger.WithFields(logrus.Fields{
+		"user_id":     request.ActorId,
+		"comment_id":  request.CommentId,
+		"action_type": request.ActionType,
+	}).Debugf("Process start")
+
+	if request.ActionType != 1 && request.ActionType != 2 {
+		resp = &comment.LikeCommentResponse{
+			StatusCode: strings.ActionCommentTypeInvalidCode,
+			StatusMsg:  strings.ActionCommentTypeInvalid,
+		}
+		return
+	}
+
+	// 只能点赞对所有人可见的评论，与视频作者或评论作者存在拉黑关系时不能点赞；
+	// 取消点赞不做限制，避免评论被暂扣或拉黑之后已有的点赞无法撤销
+	visible := func(db *gorm.DB) *gorm.DB {
+		if request.ActionType == 1 {
+			return visibleComments(db)
+		}
+		return db
+	}
+	if request.ActionType == 1 {
+		var rComment models.Comment
+		err = c.deps.DB.WithContext(ctx).Scopes(visible).
+			Select("id", "video_id").
+			Where("id = ? AND tombstoned = false", request.CommentId).
+			Take(&rComment).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			resp = &comment.LikeCommentResponse{
+				StatusCode: strings.CommentNotFoundCode,
+				StatusMsg:  strings.CommentNotFound,
+			}
+			return
+		}
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"err":        err,
+				"comment_id": request.CommentId,
+			}).Errorf("Failed to query the comment")
+			logging.SetSpanError(span, err)
+			resp = &comment.LikeCommentResponse{
+				StatusCode: strings.UnableToQueryCommentErrorCode,
+				StatusMsg:  strings.UnableToQueryCommentError,
+			}
+			return
+		}
+		if blocked := c.checkBlocked(ctx, logger, span, request.ActorId, rComment.VideoId, rComment.ID); blocked != nil {
+			resp = &comment.LikeCommentResponse{
+				StatusCode: blocked.StatusCode,
+				StatusMsg:  blocked.StatusMsg,
+			}
+			return
+		}
+	}
+
+	var target models.Comment
+	txErr := c.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 锁住评论，点赞数量与删除评论互斥
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Scopes(visible).
+			Select("id", "video_id", "root_id", "like_count").
+			Where("id = ? AND tombstoned = false", request.CommentId).
+			Take(&target).Error; err != nil {
+			return err
+		}
+
+		if request.ActionType == 1 {
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.CommentLike{
+				CommentId: request.CommentId,
+				UserId:    request.ActorId,
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errCommentLikeDuplicate
+			}
+			target.LikeCount++
+		} else {
+			result := tx.Where("comment_id = ? AND user_id = ?", request.CommentId, request.ActorId).Delete(&models.CommentLike{})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return errCommentLikeCancel
+			}
+			if target.LikeCount > 0 {
+				target.LikeCount--
+			}
+		}
+		return tx.Model(&models.Comment{}).Where("id = ?", target.ID).Update("like_count", target.LikeCount).Error
+	})
+
+	switch {
+	case errors.Is(txErr, gorm.ErrRecordNotFound):
+		resp = &comment.LikeCommentResponse{
+			StatusCode: strings.CommentNotFoundCode,
+			StatusMsg:  strings.CommentNotFound,
+		}
+		return
+	case errors.Is(txErr, errCommentLikeDuplicate):
+		resp = &comment.LikeCommentResponse{
+			StatusCode: strings.CommentLikeDuplicateCode,
+			StatusMsg:  strings.CommentLikeDuplicate,
+		}
+		return
+	case errors.Is(txErr, errCommentLikeCancel):
+		resp = &comment.LikeCommentResponse{
+			StatusCode: strings.CommentLikeCancelCode,
+			StatusMsg:  strings.CommentLikeCancel,
+		}
+		return
+	case txErr != nil:
+		logger.WithFields(logrus.Fields{
+			"err":        txErr,
+			"user_id":    request.ActorId,
+			"comment_id": request.CommentId,
+		}).Errorf("Failed to like the comment")
+		logging.SetSpanError(span, txErr)
+		resp = &comment.LikeCommentResponse{
+			StatusCode: strings.UnableToQueryCommentErrorCode,
+			StatusMsg:  strings.UnableToQueryCommentError,
+		}
+		return
+	}
+
+	// 只有顶层评论参与热度排序
+	if target.RootId == 0 {
+		if err := c.refreshHotScore(ctx, target.VideoId, target.ID); err != nil {
+			logger.WithFields(logrus.Fields{
+				"err":        err,
+				"comment_id": target.ID,
+			}).Warnf("Failed to refresh the hot score")
+		}
+	}
+
+	resp = &comment.LikeCommentResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		LikeCount:  target.LikeCount,
+	}
+	logger.WithFields(logrus.Fields{
+		"response": resp,
+	}).Debugf("Process done.")
+	return
+}
+
+// markLiked 标记当前用户点赞过的评论
+func (c CommentServiceImpl) markLiked(ctx context.Context, actorId uint32, comments []*comment.Comment) error {
+	if len(comments) == 0 {
+		return nil
+	}
+	commentIds := make([]uint32, 0, len(comments))
+	for _, c := range comments {
+		commentIds = append(commentIds, c.Id)
+	}
+	var liked []uint32
+	if err := c.deps.DB.WithContext(ctx).Model(&models.CommentLike{}).
+		Where("user_id = ? AND comment_id IN ?", actorId, commentIds).
+		Pluck("comment_id", &liked).Error; err != nil {
+		return err
+	}
+	likedSet := make(map[uint32]bool, len(liked))
+	for _, id := range liked {
+		likedSet[id] = true
+	}
+	for _, c := range comments {
+		c.IsLiked = likedSet[c.Id]
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/comment"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
@@ -24,6 +25,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.CommentRpcServerName)
 
 	if err != nil {
@@ -69,13 +72,12 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.CommentRpcServerName, config.CommentRpcServerPort)
 
 	var srv CommentServiceImpl
+	srv.New(container)
 	comment.RegisterCommentServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
-	defer CloseMQConn()
 	if err := consul.RegisterConsul(config.CommentRpcServerName, config.CommentRpcServerPort); err != nil {
 		log.Panicf("Rpc %s register consul happens error for: %v", config.CommentRpcServerName, err)
 	}
-	srv.New()
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/comment"
+	"GuGoTik/src/utils/mention"
+	"context"
+)
+
+func toRpcMentions(mentions []models.Mention) []*comment.Mention {
+	rMentions := make([]*comment.Mention, 0, len(mentions))
+	for _, m := range mentions {
+		rMentions = append(rMentions, &comment.Mention{
+			UserId:   m.UserId,
+			UserName: m.UserName,
+			Offset:   m.Start,
+			Length:   m.Length,
+		})
+	}
+	return rMentions
+}
+
+// attachMentions 填充评论中的 @，已删除的评论不返回
+func (c CommentServiceImpl) attachMentions(ctx context.Context, comments []*comment.Comment) error {
+	commentIds := make([]uint32, 0, len(comments))
+	for _, c := range comments {
+		if !c.Deleted {
+			commentIds = append(commentIds, c.Id)
+		}
+	}
+	mentions, err := mention.Load(ctx, c.deps.DB, models.MentionInComment, commentIds)
+	if err != nil {
+		return err
+	}
+	for _, c := range comments {
+		if ms, ok := mentions[c.Id]; ok && !c.Deleted {
+			c.Mentions = toRpcMentions(ms)
+		}
+	}
+	return nil
+}
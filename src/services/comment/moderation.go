@@ -2,12 +2,18 @@ package main
 
 import (
 	"GuGoTik/src/constant/config"
+	strings2 "GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/comment"
 	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/moderation"
 	"context"
 	"errors"
 	"github.com/sashabaranov/go-openai"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 	"net/http"
 	url2 "net/url"
 	"strconv"
@@ -16,7 +22,19 @@ import (
 
 var openaiClient *openai.Client
 
+// localModerator 发布评论前同步执行的本地审核链
+var localModerator moderation.Moderator
+
+// llmModerator 评论发布后异步执行的 ChatGPT 审核，未配置时为空
+var llmModerator moderation.Moderator
+
 func init() {
+	chain, err := moderation.NewLocalChain()
+	if err != nil {
+		panic(err)
+	}
+	localModerator = chain
+
 	cfg := openai.DefaultConfig(config.EnvCfg.ChatGPTAPIKEYS)
 
 	url, err := url2.Parse(config.EnvCfg.ChatGptProxy)
@@ -30,6 +48,115 @@ func init() {
 	}
 
 	openaiClient = openai.NewClientWithConfig(cfg)
+	if config.EnvCfg.ModerationLLMState == "enable" && config.EnvCfg.ChatGPTAPIKEYS != "" {
+		llmModerator = gptModerator{}
+	}
+}
+
+// visibleComments 只保留审核通过的评论
+func visibleComments(db *gorm.DB) *gorm.DB {
+	return db.Where("moderation_decision = ?", moderation.Allow)
+}
+
+// checkBanned 检查用户是否处于封禁期间，不能发布或编辑评论时返回对应的响应
+func (c CommentServiceImpl) checkBanned(ctx context.Context, logger *logrus.Entry, span trace.Span, userId uint32) *comment.ActionCommentResponse {
+	until, banned, err := moderation.BannedUntil(ctx, c.deps.DB, userId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"user_id": userId,
+		}).Errorf("Failed to query the ban of the user")
+		logging.SetSpanError(span, err)
+		return &comment.ActionCommentResponse{
+			StatusCode: strings2.UnableToCreateCommentErrorCode,
+			StatusMsg:  strings2.UnableToCreateCommentError,
+		}
+	}
+	if banned {
+		logger.WithFields(logrus.Fields{
+			"user_id": userId,
+			"until":   until,
+		}).Infof("Banned user tried to comment")
+		return &comment.ActionCommentResponse{
+			StatusCode: strings2.UserBannedCode,
+			StatusMsg:  strings2.UserBanned,
+		}
+	}
+	return nil
+}
+
+// applyModeration 把审核结果写入评论的审核字段
+func applyModeration(c *models.Comment, res moderation.Result) {
+	c.Rate = res.Rate
+	c.Reason = res.Reason
+	c.ModerationDecision = uint32(res.Decision)
+	c.ModerationFlagged = res.Flagged
+	c.ModerationHate = res.Categories.Hate
+	c.ModerationHateThreatening = res.Categories.HateThreatening
+	c.ModerationSelfHarm = res.Categories.SelfHarm
+	c.ModerationSexual = res.Categories.Sexual
+	c.ModerationSexualMinors = res.Categories.SexualMinors
+	c.ModerationViolence = res.Categories.Violence
+	c.ModerationViolenceGraphic = res.Categories.ViolenceGraphic
+}
+
+// moderationColumns applyModeration 修改的列，更新时显式指定以便写入零值
+var moderationColumns = []string{
+	"rate", "reason", "moderation_decision", "moderation_flagged",
+	"moderation_hate", "moderation_hate_threatening", "moderation_self_harm", "moderation_sexual",
+	"moderation_sexual_minors", "moderation_violence", "moderation_violence_graphic",
+}
+
+// gptModerator 使用 ChatGPT 评分与 OpenAI Moderation 分类的审核阶段
+type gptModerator struct{}
+
+func (gptModerator) Name() string {
+	return "gpt"
+}
+
+func (gptModerator) Moderate(ctx context.Context, content string) (res moderation.Result, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "GPTModerator")
+	defer span.End()
+	logger := logging.LogService("CommentService.GPTModerator").WithContext(ctx)
+
+	rate, reason, err := RateCommentByGPT(content, logger, span)
+	if err != nil {
+		return
+	}
+	if rate == 0 {
+		return res, errors.New("ChatGPT response does not match expected format")
+	}
+	res.Rate = rate
+	switch {
+	case rate >= 5:
+		res.Decision = moderation.Reject
+	case rate == 4:
+		res.Decision = moderation.Hold
+	}
+	if res.Decision != moderation.Allow {
+		res.Reason = reason
+	}
+
+	categories, err := ModerationCommentByGPT(content, logger, span)
+	if err != nil {
+		// 已经拿到评分，分类失败时不影响结论
+		return res, nil
+	}
+	res.Flagged = categories.Flagged
+	res.Categories = moderation.Categories{
+		Hate:            categories.Categories.Hate,
+		HateThreatening: categories.Categories.HateThreatening,
+		SelfHarm:        categories.Categories.SelfHarm,
+		Sexual:          categories.Categories.Sexual,
+		SexualMinors:    categories.Categories.SexualMinors,
+		Violence:        categories.Categories.Violence,
+		ViolenceGraphic: categories.Categories.ViolenceGraphic,
+	}
+	if categories.Flagged && res.Decision == moderation.Allow {
+		res.Decision = moderation.Hold
+		res.Reason = "OpenAI Moderation 标记为违规内容"
+	}
+	return res, nil
 }
 
 func RateCommentByGPT(commentContent string, logger *logrus.Entry, span trace.Span) (rate uint32, reason string, err error) {
@@ -99,7 +226,7 @@ func RateCommentByGPT(commentContent string, logger *logrus.Entry, span trace.Sp
 	return
 }
 
-func ModerationCommentByGPT(commentContent string, logger *logrus.Entry, span trace.Span) (moderationRes openai.Result) {
+func ModerationCommentByGPT(commentContent string, logger *logrus.Entry, span trace.Span) (moderationRes openai.Result, err error) {
 	logger.WithFields(logrus.Fields{
 		"comment_content": commentContent,
 	}).Debugf("Start ModerationCommentByGPT")
@@ -121,6 +248,11 @@ func ModerationCommentByGPT(commentContent string, logger *logrus.Entry, span tr
 		return
 	}
 
+	if len(resp.Results) == 0 {
+		err = errors.New("OpenAI moderation response is empty")
+		logging.SetSpanError(span, err)
+		return
+	}
 	moderationRes = resp.Results[0]
 	return
 }
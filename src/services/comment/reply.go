@@ -0,0 +1,310 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/comment"
+	"GuGoTik/src/rpc/user"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	previewReplyCount   = 3 // ListComment 中每条顶层评论附带的回复数量
+	defaultRepliesLimit = 20
+	maxRepliesLimit     = 50
+)
+
+// ListReplies 按回复时间顺序分页获取顶层评论下的回复，游标为上一页最后一条回复的 id
+func (c CommentServiceImpl) ListReplies(ctx context.Context, request *comment.ListRepliesRequest) (resp *comment.ListRepliesResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListRepliesService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("CommentService.ListReplies").WithContext(ctx)
+	logger.WithFields(logrus.Fields{
+		"user_id":    request.ActorId,
+		"comment_id": request.CommentId,
+		"cursor":     request.Cursor,
+	}).Debugf("Process start")
+
+	// 1. 检查顶层评论是否存在，已经删除但仍有回复的评论同样可以查看回复
+	var root models.Comment
+	err = c.deps.DB.WithContext(ctx).
+		Select("id").
+		Where("id = ? AND parent_id = 0", request.CommentId).
+		Take(&root).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		resp = &comment.ListRepliesResponse{
+			StatusCode: strings.CommentParentNotFoundCode,
+			StatusMsg:  strings.CommentParentNotFound,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        err,
+			"comment_id": request.CommentId,
+		}).Errorf("Failed to query the comment")
+		logging.SetSpanError(span, err)
+		resp = &comment.ListRepliesResponse{
+			StatusCode: strings.UnableToQueryCommentErrorCode,
+			StatusMsg:  strings.UnableToQueryCommentError,
+		}
+		return resp, nil
+	}
+
+	limit := int(request.Limit)
+	if limit <= 0 {
+		limit = defaultRepliesLimit
+	}
+	if limit > maxRepliesLimit {
+		limit = maxRepliesLimit
+	}
+
+	// 2. 多取一条用于判断是否还有下一页，不返回与当前用户存在拉黑关系或被当前用户屏蔽的用户的回复
+	hidden, err := hiddenUsers(ctx, request.ActorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"user_id": request.ActorId,
+		}).Errorf("Failed to query hidden users")
+		logging.SetSpanError(span, err)
+		resp = &comment.ListRepliesResponse{
+			StatusCode: strings.UnableToQueryCommentErrorCode,
+			StatusMsg:  strings.UnableToQueryCommentError,
+		}
+		return resp, nil
+	}
+	query := c.deps.DB.WithContext(ctx).
+		Where("root_id = ?", request.CommentId).
+		Scopes(visibleComments, hideUsers(hidden))
+	if request.Cursor != nil {
+		query = query.Where("id > ?", *request.Cursor)
+	}
+	var replies []models.Comment
+	if err = query.Order("id").Limit(limit + 1).Find(&replies).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        err,
+			"comment_id": request.CommentId,
+		}).Errorf("Failed to list the replies")
+		logging.SetSpanError(span, err)
+		resp = &comment.ListRepliesResponse{
+			StatusCode: strings.UnableToQueryCommentErrorCode,
+			StatusMsg:  strings.UnableToQueryCommentError,
+		}
+		return resp, nil
+	}
+
+	resp = &comment.ListRepliesResponse{
+		StatusCode:  strings.ServiceOKCode,
+		StatusMsg:   strings.ServiceOK,
+		CommentList: make([]*comment.Comment, 0, len(replies)),
+	}
+	if len(replies) > limit {
+		replies = replies[:limit]
+		next := replies[limit-1].ID
+		resp.NextCursor = &next
+	}
+	if len(replies) == 0 {
+		return
+	}
+
+	// 3. 获取回复的用户信息
+	userIds := make([]uint32, 0, len(replies))
+	for _, reply := range replies {
+		userIds = append(userIds, reply.UserId)
+	}
+	userMap, err := queryUsers(ctx, request.ActorId, userIds)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":      err,
+			"user_ids": userIds,
+		}).Errorf("Unable to get user info")
+		logging.SetSpanError(span, err)
+		resp = &comment.ListRepliesResponse{
+			StatusCode: strings.UnableToQueryUserErrorCode,
+			StatusMsg:  strings.UnableToQueryUserError,
+		}
+		return resp, nil
+	}
+
+	for i := range replies {
+		resp.CommentList = append(resp.CommentList, convertComment(&replies[i], userMap))
+	}
+	if err = c.markLiked(ctx, request.ActorId, resp.CommentList); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        err,
+			"comment_id": request.CommentId,
+		}).Warnf("Failed to query the liked comments")
+		err = nil
+	}
+	if err = c.attachMentions(ctx, resp.CommentList); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":        err,
+			"comment_id": request.CommentId,
+		}).Warnf("Failed to query the mentions of the replies")
+		err = nil
+	}
+	logger.WithFields(logrus.Fields{
+		"response": resp,
+	}).Debugf("Process done.")
+	return
+}
+
+// previewReplies 批量查询顶层评论的回复数量，以及每条顶层评论最早的 previewReplyCount 条回复，数量与预览都不包含 hidden 中用户的回复
+func (c CommentServiceImpl) previewReplies(ctx context.Context, rootIds []uint32, hidden []uint32) (counts map[uint32]uint32, previews map[uint32][]models.Comment, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "PreviewReplies")
+	defer span.End()
+
+	previews = make(map[uint32][]models.Comment, len(rootIds))
+	if len(rootIds) == 0 {
+		counts = make(map[uint32]uint32)
+		return
+	}
+
+	counts, err = countReplies(c.deps.DB.WithContext(ctx), rootIds, hidden)
+	if err != nil || len(counts) == 0 {
+		return
+	}
+
+	// 按楼层编号后每层只取前几条，一次查询取出所有楼层的回复
+	ranked := c.deps.DB.WithContext(ctx).Model(&models.Comment{}).
+		Select("*, ROW_NUMBER() OVER (PARTITION BY root_id ORDER BY id) AS reply_rank").
+		Where("root_id IN ?", rootIds).
+		Scopes(visibleComments, hideUsers(hidden))
+	var replies []models.Comment
+	err = c.deps.DB.WithContext(ctx).
+		Table("(?) AS replies", ranked).
+		Where("reply_rank <= ?", previewReplyCount).
+		Order("id").
+		Find(&replies).Error
+	if err != nil {
+		return
+	}
+	for _, reply := range replies {
+		previews[reply.RootId] = append(previews[reply.RootId], reply)
+	}
+	return
+}
+
+// countReplies 批量统计顶层评论的回复数量，不包含 hidden 中用户的回复，没有回复的评论不在结果中
+func countReplies(db *gorm.DB, rootIds []uint32, hidden []uint32) (map[uint32]uint32, error) {
+	counts := make(map[uint32]uint32, len(rootIds))
+	if len(rootIds) == 0 {
+		return counts, nil
+	}
+	var rows []struct {
+		RootId uint32
+		Count  uint32
+	}
+	err := db.Model(&models.Comment{}).
+		Select("root_id, count(*) AS count").
+		Where("root_id IN ?", rootIds).
+		Scopes(visibleComments, hideUsers(hidden)).
+		Group("root_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.RootId] = row.Count
+	}
+	return counts, nil
+}
+
+// queryUsers 批量获取用户信息，重复的用户 id 只查询一次
+func queryUsers(ctx context.Context, actorId uint32, userIds []uint32) (map[uint32]*user.User, error) {
+	userMap := make(map[uint32]*user.User, len(userIds))
+	if len(userIds) == 0 {
+		return userMap, nil
+	}
+	usersResponse, err := userClient.GetUserInfos(ctx, &user.UsersRequest{
+		UserIds: userIds,
+		ActorId: actorId,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if usersResponse.StatusCode != strings.ServiceOKCode {
+		return nil, fmt.Errorf("user service returned status %d", usersResponse.StatusCode)
+	}
+	for _, u := range usersResponse.Users {
+		userMap[u.Id] = u
+	}
+	return userMap, nil
+}
+
+// convertComment 转换为返回给客户端的评论，已删除的评论不返回用户与内容
+func convertComment(pComment *models.Comment, userMap map[uint32]*user.User) *comment.Comment {
+	rComment := &comment.Comment{
+		Id:         pComment.ID,
+		CreateDate: pComment.CreatedAt.Format("01-02"),
+		ParentId:   pComment.ParentId,
+		RootId:     pComment.RootId,
+		Deleted:    pComment.Tombstoned,
+		LikeCount:  pComment.LikeCount,
+	}
+	if !pComment.Tombstoned {
+		rComment.User = userMap[pComment.UserId]
+		rComment.Content = pComment.Content
+		if pComment.EditedAt != nil {
+			editedAt := pComment.EditedAt.Unix()
+			rComment.EditedAt = &editedAt
+		}
+	}
+	return rComment
+}
+
+// removeComment 在事务中删除评论：仍有回复的评论只清空内容保留占位，
+// 否则直接删除，并向上清理因此不再有回复的占位评论
+func removeComment(tx *gorm.DB, commentId uint32) error {
+	for commentId != 0 {
+		var target models.Comment
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "parent_id", "tombstoned").
+			Where("id = ?", commentId).
+			Take(&target).Error; err != nil {
+			return err
+		}
+
+		var children int64
+		if err := tx.Model(&models.Comment{}).Where("parent_id = ?", commentId).Count(&children).Error; err != nil {
+			return err
+		}
+		if children > 0 {
+			if target.Tombstoned {
+				return nil
+			}
+			return tx.Model(&models.Comment{}).Where("id = ?", commentId).Updates(map[string]any{
+				"tombstoned": true,
+				"content":    "",
+			}).Error
+		}
+
+		if err := tx.Delete(&models.Comment{}, commentId).Error; err != nil {
+			return err
+		}
+		// 只有占位评论需要随最后一条回复一起清理
+		commentId = target.ParentId
+		if commentId != 0 {
+			var parent models.Comment
+			if err := tx.Select("tombstoned").Where("id = ?", commentId).Take(&parent).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return nil
+				}
+				return err
+			}
+			if !parent.Tombstoned {
+				return nil
+			}
+		}
+	}
+	return nil
+}
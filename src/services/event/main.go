@@ -6,6 +6,7 @@ import (
 	"GuGoTik/src/extra/gorse"
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/models"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/rabbitmq"
 	"context"
@@ -24,7 +25,9 @@ func exitOnError(err error) {
 }
 
 func main() {
-	conn, err := amqp.Dial(rabbitmq.BuildMQConnAddr())
+	container := deps.MustNew()
+
+	conn, err := container.DialMQ()
 	exitOnError(err)
 
 	defer func(conn *amqp.Connection) {
@@ -161,6 +164,9 @@ func Consume(ch *amqp.Channel, queueName string) {
 			case config.FavoriteRpcServerName:
 				types = "favorite"
 			}
+			if raw.Slice != "" {
+				types = raw.Slice
+			}
 			var feedbacks []gorse.Feedback
 			for _, id := range raw.VideoId {
 				feedbacks = append(feedbacks, gorse.Feedback{
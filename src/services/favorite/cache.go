@@ -0,0 +1,552 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/models"
+	"GuGoTik/src/storage/cached"
+	"GuGoTik/src/storage/database"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// 重建的 user_like 集合中总是带有一个分数为 0 的占位成员，没有点赞的用户也能命中缓存。
+// 真实成员的分数是点赞的时间戳，读取时只取分数大于 0 的成员
+const likePlaceholder = "0"
+
+const likeScoreMin = "(0"
+
+func userLikeKey(userId uint32) string {
+	return fmt.Sprintf("%suser_like_%d", config.EnvCfg.RedisPrefix, userId)
+}
+
+func videoLikeKey(videoId uint32) string {
+	return fmt.Sprintf("%svideo_like_%d", config.EnvCfg.RedisPrefix, videoId)
+}
+
+func userLikedKey(userId uint32) string {
+	return fmt.Sprintf("%suser_liked_%d", config.EnvCfg.RedisPrefix, userId)
+}
+
+// likeCounterTTL 点赞计数的过期时间，过期后从数据库重建，避免计数永久偏离数据库
+const likeCounterTTL = 24 * time.Hour
+
+// likeVersionTTL 版本号只需要覆盖一次重建的耗时
+const likeVersionTTL = time.Minute
+
+// refillAttempts 重建 user_like 集合时因为并发修改而重试的次数
+const refillAttempts = 3
+
+var errRefillConflict = errors.New("user likes are modified concurrently during refill")
+
+func userLikeVersionKey(userId uint32) string {
+	return fmt.Sprintf("%suser_like_version_%d", config.EnvCfg.RedisPrefix, userId)
+}
+
+// refillScript 只有在集合仍不存在且版本号没有变化时才写入重建的集合，返回 1 表示写入成功。
+// 集合不存在时的点赞与取消点赞会增加版本号，说明读取数据库之后发生了修改，重建的数据可能已经过期。
+// ZADD 分批执行，避免成员过多时超出 unpack 的限制
+var refillScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 1
+end
+if (redis.call('GET', KEYS[2]) or '0') ~= ARGV[1] then
+	return 0
+end
+for i = 2, #ARGV, 1000 do
+	redis.call('ZADD', KEYS[1], unpack(ARGV, i, math.min(i + 999, #ARGV)))
+end
+return 1
+`)
+
+// ensureUserLikes user_like 集合不存在时从数据库重建，重建总是读主库，避免刚删除的缓存被延迟的副本填回旧值
+func (c FavoriteServiceServerImpl) ensureUserLikes(ctx context.Context, userId uint32) error {
+	key := userLikeKey(userId)
+	for i := 0; i < refillAttempts; i++ {
+		existed, err := c.deps.Redis.Exists(ctx, key).Result()
+		if err != nil || existed > 0 {
+			return err
+		}
+
+		// 版本号需要在读取数据库之前读取
+		version, err := c.deps.Redis.Get(ctx, userLikeVersionKey(userId)).Result()
+		if err == redis.Nil {
+			version = "0"
+		} else if err != nil {
+			return err
+		}
+
+		var favorites []models.Favorite
+		if err := c.deps.DB.WithContext(database.WithPrimary(ctx)).
+			Select("video_id", "created_at").
+			Where("user_id = ?", userId).
+			Find(&favorites).Error; err != nil {
+			return err
+		}
+
+		args := make([]interface{}, 0, 2*len(favorites)+3)
+		args = append(args, version, 0, likePlaceholder)
+		for _, f := range favorites {
+			args = append(args, f.CreatedAt.Unix(), f.VideoId)
+		}
+		filled, err := refillScript.Run(ctx, c.deps.Redis, []string{key, userLikeVersionKey(userId)}, args...).Int64()
+		if err != nil || filled == 1 {
+			return err
+		}
+	}
+	return errRefillConflict
+}
+
+// userLikes 按点赞时间倒序返回用户点赞的视频
+func (c FavoriteServiceServerImpl) userLikes(ctx context.Context, userId uint32) ([]uint32, error) {
+	if err := c.ensureUserLikes(ctx, userId); err != nil {
+		return nil, err
+	}
+
+	members, err := c.deps.Redis.ZRevRangeByScore(ctx, userLikeKey(userId), &redis.ZRangeBy{
+		Min: likeScoreMin,
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	videoIds := make([]uint32, 0, len(members))
+	for _, member := range members {
+		id, err := strconv.ParseUint(member, 10, 32)
+		if err != nil {
+			continue
+		}
+		videoIds = append(videoIds, uint32(id))
+	}
+	return videoIds, nil
+}
+
+// isLiked 用户是否点赞了该视频
+func (c FavoriteServiceServerImpl) isLiked(ctx context.Context, userId uint32, videoId uint32) (bool, error) {
+	if err := c.ensureUserLikes(ctx, userId); err != nil {
+		return false, err
+	}
+
+	score, err := c.deps.Redis.ZScore(ctx, userLikeKey(userId), strconv.FormatUint(uint64(videoId), 10)).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	return score > 0, err
+}
+
+// countUserLikes 用户点赞的视频数量
+func (c FavoriteServiceServerImpl) countUserLikes(ctx context.Context, userId uint32) (int64, error) {
+	if err := c.ensureUserLikes(ctx, userId); err != nil {
+		return 0, err
+	}
+	return c.deps.Redis.ZCount(ctx, userLikeKey(userId), likeScoreMin, "+inf").Result()
+}
+
+// counterVersionKey 计数的版本号，计数不存在时的点赞与取消点赞会增加版本号。
+// 键名不是合法的 Id，对账时按前缀遍历计数会跳过它
+func counterVersionKey(key string) string {
+	return key + "_version"
+}
+
+// counterRefillScript 只有在计数仍不存在且版本号没有变化时才写入重建的计数，返回 1 表示写入成功。
+// 版本号变化说明读取数据库之后点赞发生了修改，重建的计数可能已经过期
+var counterRefillScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 1
+end
+if (redis.call('GET', KEYS[2]) or '0') ~= ARGV[1] then
+	return 0
+end
+redis.call('SET', KEYS[1], ARGV[2], 'EX', ARGV[3])
+return 1
+`)
+
+// counterVersion 读取计数的版本号，不存在时为 "0"
+func counterVersion(cmd *redis.StringCmd) (string, error) {
+	version, err := cmd.Result()
+	if err == redis.Nil {
+		return "0", nil
+	}
+	return version, err
+}
+
+// ensureCounter 计数不存在时由 load 从数据库重建。并发重建时保留先写入的值，
+// 重建期间计数被修改时不写入缓存，只返回读取到的数量
+func (c FavoriteServiceServerImpl) ensureCounter(ctx context.Context, key string, load func() (int64, error)) (int64, error) {
+	var valueCmd, versionCmd *redis.StringCmd
+	_, err := c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		valueCmd = pipe.Get(ctx, key)
+		versionCmd = pipe.Get(ctx, counterVersionKey(key))
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+	value, err := valueCmd.Int64()
+	if err != redis.Nil {
+		return value, err
+	}
+	// 版本号需要在读取数据库之前读取
+	version, err := counterVersion(versionCmd)
+	if err != nil {
+		return 0, err
+	}
+
+	count, err := load()
+	if err != nil {
+		return 0, err
+	}
+	err = counterRefillScript.Run(ctx, c.deps.Redis, []string{key, counterVersionKey(key)},
+		version, count, int64(likeCounterTTL.Seconds())).Err()
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+// countVideoLikes 视频获得的点赞数量
+func (c FavoriteServiceServerImpl) countVideoLikes(ctx context.Context, videoId uint32) (int64, error) {
+	return c.ensureCounter(ctx, videoLikeKey(videoId), func() (count int64, err error) {
+		err = c.deps.DB.WithContext(database.WithPrimary(ctx)).Model(&models.Favorite{}).Where("video_id = ?", videoId).Count(&count).Error
+		return
+	})
+}
+
+// countUserLiked 用户的视频总共获得的点赞数量
+func (c FavoriteServiceServerImpl) countUserLiked(ctx context.Context, userId uint32) (int64, error) {
+	return c.ensureCounter(ctx, userLikedKey(userId), func() (count int64, err error) {
+		err = c.deps.DB.WithContext(database.WithPrimary(ctx)).Model(&models.Favorite{}).Where("author_id = ?", userId).Count(&count).Error
+		return
+	})
+}
+
+// favoriteScript 原子地检查并修改点赞缓存，返回 1 表示生效，0 表示集合中已经是目标状态，-1 表示集合未缓存。
+// 集合未缓存时无法判断计数是否已经包含本次操作，于是删除计数，下一次读取时从数据库重建，
+// 同时增加集合与计数的版本号，使正在进行的重建放弃可能已经过期的数据。
+// 计数只在已经缓存时修改，并且不会小于 0；计数未缓存时增加它的版本号
+var favoriteScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 0 then
+	for i = 2, 3 do
+		redis.call('DEL', KEYS[i])
+	end
+	for i = 4, 6 do
+		redis.call('INCR', KEYS[i])
+		redis.call('EXPIRE', KEYS[i], ARGV[4])
+	end
+	return -1
+end
+
+local changed
+if ARGV[3] == '1' then
+	changed = redis.call('ZADD', KEYS[1], 'NX', ARGV[2], ARGV[1])
+else
+	changed = redis.call('ZREM', KEYS[1], ARGV[1])
+end
+if changed == 0 then
+	return 0
+end
+
+for i = 2, 3 do
+	if redis.call('EXISTS', KEYS[i]) == 1 then
+		if redis.call('INCRBY', KEYS[i], ARGV[3]) < 0 then
+			redis.call('SET', KEYS[i], 0)
+		end
+	else
+		redis.call('INCR', KEYS[i + 3])
+		redis.call('EXPIRE', KEYS[i + 3], ARGV[4])
+	end
+end
+return 1
+`)
+
+const (
+	favoriteCacheCold    = -1
+	favoriteCacheNoop    = 0
+	favoriteCacheApplied = 1
+)
+
+// applyFavorite 将已经写入数据库的点赞或取消点赞同步到缓存，delta 为 1 表示点赞，-1 表示取消点赞
+func (c FavoriteServiceServerImpl) applyFavorite(ctx context.Context, userId uint32, videoId uint32, authorId uint32, delta int) (int64, error) {
+	return favoriteScript.Run(ctx, c.deps.Redis,
+		[]string{
+			userLikeKey(userId), videoLikeKey(videoId), userLikedKey(authorId), userLikeVersionKey(userId),
+			counterVersionKey(videoLikeKey(videoId)), counterVersionKey(userLikedKey(authorId)),
+		},
+		videoId, time.Now().Unix(), delta, int64(likeVersionTTL.Seconds()),
+	).Int64()
+}
+
+// invalidateFavorite 删除点赞相关的缓存，下一次读取时从数据库重建
+func (c FavoriteServiceServerImpl) invalidateFavorite(ctx context.Context, userId uint32, videoId uint32, authorId uint32) error {
+	_, err := c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Del(ctx, userLikeKey(userId))
+		pipe.Del(ctx, videoLikeKey(videoId))
+		pipe.Del(ctx, userLikedKey(authorId))
+		for _, version := range []string{
+			userLikeVersionKey(userId),
+			counterVersionKey(videoLikeKey(videoId)),
+			counterVersionKey(userLikedKey(authorId)),
+		} {
+			pipe.Incr(ctx, version)
+			pipe.Expire(ctx, version, likeVersionTTL)
+		}
+		return nil
+	})
+	return err
+}
+
+// userReactionCache 用户对视频的回应，key 为 "用户Id-视频Id"，没有回应时缓存 0
+var userReactionCache *cached.Cache[string, uint32]
+
+// videoReactionsCache 视频各类回应的数量，key 为视频 Id
+var videoReactionsCache *cached.Cache[uint32, map[uint32]uint32]
+
+func newReactionCaches(db *gorm.DB) {
+	userReactionCache = cached.New[string, uint32]("UserReaction", cached.JSONCodec[uint32]{}, userReactionLoader(db)).
+		WithBatchLoader(userReactionsLoader(db))
+	videoReactionsCache = cached.New[uint32, map[uint32]uint32]("VideoReactions", cached.JSONCodec[map[uint32]uint32]{}, videoReactionsLoader(db))
+}
+
+func userReactionKey(userId uint32, videoId uint32) string {
+	return fmt.Sprintf("%d-%d", userId, videoId)
+}
+
+// userReactionLoader 没有回应同样视为找到，避免未回应的用户每次都查询数据库
+func userReactionLoader(db *gorm.DB) cached.Loader[string, uint32] {
+	return func(ctx context.Context, key string) (uint32, bool, error) {
+		var userId, videoId uint32
+		if _, err := fmt.Sscanf(key, "%d-%d", &userId, &videoId); err != nil {
+			return 0, false, err
+		}
+
+		var f models.Favorite
+		err := db.WithContext(database.WithPrimary(ctx)).
+			Select("reaction").
+			Where("user_id = ? AND video_id = ?", userId, videoId).
+			Take(&f).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, true, nil
+		}
+		return f.Reaction, err == nil, err
+	}
+}
+
+// userReactionsLoader 通过一次查询读取多个回应，没有回应的 key 同样返回 0
+func userReactionsLoader(db *gorm.DB) cached.BatchLoader[string, uint32] {
+	return func(ctx context.Context, keys []string) (map[string]uint32, error) {
+		reactions := make(map[string]uint32, len(keys))
+		pairs := make([][]any, 0, len(keys))
+		for _, key := range keys {
+			var userId, videoId uint32
+			if _, err := fmt.Sscanf(key, "%d-%d", &userId, &videoId); err != nil {
+				continue
+			}
+			reactions[key] = 0
+			pairs = append(pairs, []any{userId, videoId})
+		}
+		if len(pairs) == 0 {
+			return reactions, nil
+		}
+
+		var favorites []models.Favorite
+		if err := db.WithContext(database.WithPrimary(ctx)).
+			Select("user_id", "video_id", "reaction").
+			Where("(user_id, video_id) IN ?", pairs).
+			Find(&favorites).Error; err != nil {
+			return nil, err
+		}
+		for _, f := range favorites {
+			reactions[userReactionKey(f.UserId, f.VideoId)] = f.Reaction
+		}
+		return reactions, nil
+	}
+}
+
+func videoReactionsLoader(db *gorm.DB) cached.Loader[uint32, map[uint32]uint32] {
+	return func(ctx context.Context, videoId uint32) (map[uint32]uint32, bool, error) {
+		var rows []struct {
+			Reaction uint32
+			Count    uint32
+		}
+		if err := db.WithContext(database.WithPrimary(ctx)).Model(&models.Favorite{}).
+			Select("reaction, count(*) AS count").
+			Where("video_id = ?", videoId).
+			Group("reaction").
+			Scan(&rows).Error; err != nil {
+			return nil, false, err
+		}
+
+		counts := make(map[uint32]uint32, len(rows))
+		for _, row := range rows {
+			counts[row.Reaction] = row.Count
+		}
+		return counts, true, nil
+	}
+}
+
+// userReaction 用户对视频的回应，没有回应时返回 0
+func userReaction(ctx context.Context, userId uint32, videoId uint32) (uint32, error) {
+	reaction, _, err := userReactionCache.Get(ctx, userReactionKey(userId, videoId))
+	return reaction, err
+}
+
+// videoReactions 视频各类回应的数量
+func videoReactions(ctx context.Context, videoId uint32) (map[uint32]uint32, error) {
+	counts, _, err := videoReactionsCache.Get(ctx, videoId)
+	return counts, err
+}
+
+// invalidateReactions 删除回应相关的缓存，点赞与取消点赞同样会改变回应
+func invalidateReactions(ctx context.Context, userId uint32, videoId uint32) error {
+	return errors.Join(
+		userReactionCache.Delete(ctx, userReactionKey(userId, videoId)),
+		videoReactionsCache.Delete(ctx, videoId),
+	)
+}
+
+// videoStats 视频的点赞数以及用户对视频的点赞状态
+type videoStats struct {
+	count    int64
+	liked    bool
+	reaction uint32
+}
+
+// batchVideoStats 通过一次 Pipeline 读取多个视频的点赞数以及 actorId 是否点赞，缺失的计数通过一次查询从数据库重建。
+// actorId 为 0 时只返回点赞数
+func (c FavoriteServiceServerImpl) batchVideoStats(ctx context.Context, actorId uint32, videoIds []uint32) (map[uint32]*videoStats, error) {
+	stats := make(map[uint32]*videoStats, len(videoIds))
+	if len(videoIds) == 0 {
+		return stats, nil
+	}
+	if actorId != 0 {
+		if err := c.ensureUserLikes(ctx, actorId); err != nil {
+			return nil, err
+		}
+	}
+
+	counts := make(map[uint32]*redis.StringCmd, len(videoIds))
+	scores := make(map[uint32]*redis.FloatCmd, len(videoIds))
+	_, err := c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, videoId := range videoIds {
+			if _, ok := counts[videoId]; ok {
+				continue
+			}
+			counts[videoId] = pipe.Get(ctx, videoLikeKey(videoId))
+			if actorId != 0 {
+				scores[videoId] = pipe.ZScore(ctx, userLikeKey(actorId), strconv.FormatUint(uint64(videoId), 10))
+			}
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	var missed []uint32
+	for videoId, cmd := range counts {
+		stats[videoId] = &videoStats{}
+		count, err := cmd.Int64()
+		switch {
+		case err == redis.Nil:
+			missed = append(missed, videoId)
+		case err != nil:
+			return nil, err
+		default:
+			stats[videoId].count = count
+		}
+		if cmd, ok := scores[videoId]; ok {
+			score, err := cmd.Result()
+			if err != nil && err != redis.Nil {
+				return nil, err
+			}
+			stats[videoId].liked = score > 0
+		}
+	}
+
+	if len(missed) > 0 {
+		loaded, err := c.loadVideoLikeCounts(ctx, missed)
+		if err != nil {
+			return nil, err
+		}
+		for _, videoId := range missed {
+			stats[videoId].count = loaded[videoId]
+		}
+	}
+
+	// 只有点赞过的视频才需要查询回应类型
+	if actorId != 0 {
+		var keys []string
+		for videoId, s := range stats {
+			if s.liked {
+				keys = append(keys, userReactionKey(actorId, videoId))
+			}
+		}
+		if len(keys) > 0 {
+			reactions, err := userReactionCache.GetMany(ctx, keys)
+			if err != nil {
+				return nil, err
+			}
+			for videoId, s := range stats {
+				if s.liked {
+					s.reaction = reactions[userReactionKey(actorId, videoId)]
+				}
+			}
+		}
+	}
+	return stats, nil
+}
+
+// loadVideoLikeCounts 通过一次查询重建多个视频的点赞数，并发重建时保留先写入的值，
+// 重建期间点赞数被修改的视频不写入缓存
+func (c FavoriteServiceServerImpl) loadVideoLikeCounts(ctx context.Context, videoIds []uint32) (map[uint32]int64, error) {
+	// 版本号需要在读取数据库之前读取
+	versionCmds := make(map[uint32]*redis.StringCmd, len(videoIds))
+	_, err := c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, videoId := range videoIds {
+			versionCmds[videoId] = pipe.Get(ctx, counterVersionKey(videoLikeKey(videoId)))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+	versions := make(map[uint32]string, len(videoIds))
+	for videoId, cmd := range versionCmds {
+		if versions[videoId], err = counterVersion(cmd); err != nil {
+			return nil, err
+		}
+	}
+
+	var rows []struct {
+		VideoId uint32
+		Count   int64
+	}
+	if err := c.deps.DB.WithContext(database.WithPrimary(ctx)).Model(&models.Favorite{}).
+		Select("video_id, count(*) AS count").
+		Where("video_id IN ?", videoIds).
+		Group("video_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint32]int64, len(videoIds))
+	for _, row := range rows {
+		counts[row.VideoId] = row.Count
+	}
+	// Pipeline 中无法在 EVALSHA 失败后重试，使用 EVAL 发送脚本
+	_, err = c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for videoId, version := range versions {
+			key := videoLikeKey(videoId)
+			counterRefillScript.Eval(ctx, pipe, []string{key, counterVersionKey(key)},
+				version, counts[videoId], int64(likeCounterTTL.Seconds()))
+		}
+		return nil
+	})
+	return counts, err
+}
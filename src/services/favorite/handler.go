@@ -8,111 +8,86 @@ import (
 	"GuGoTik/src/rpc/favorite"
 	"GuGoTik/src/rpc/feed"
 	"GuGoTik/src/rpc/user"
-	redis2 "GuGoTik/src/storage/redis"
 	"GuGoTik/src/utils/audit"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
-	"GuGoTik/src/utils/rabbitmq"
+	"GuGoTik/src/utils/outbox"
+	"GuGoTik/src/utils/privacy"
 	"context"
-	"encoding/json"
-	"fmt"
 	"github.com/google/uuid"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"go.opentelemetry.io/otel/trace"
-	"strconv"
-	"sync"
-	"time"
 
-	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 var feedClient feed.FeedServiceClient
 var userClient user.UserServiceClient
 
-var conn *amqp.Connection
-
-var channel *amqp.Channel
-
 type FavoriteServiceServerImpl struct {
 	favorite.FavoriteServiceServer
+	deps *deps.Container
 }
 
-func exitOnError(err error) {
-	if err != nil {
-		panic(err)
-	}
-}
-
-func (c FavoriteServiceServerImpl) New() {
-	feedRpcConn := grpc2.Connect(config.FeedRpcServerName)
+func (c *FavoriteServiceServerImpl) New(container *deps.Container) {
+	c.deps = container
+	feedRpcConn := container.Dial(config.FeedRpcServerName)
 	feedClient = feed.NewFeedServiceClient(feedRpcConn)
-	userRpcConn := grpc2.Connect(config.UserRpcServerName)
+	userRpcConn := container.Dial(config.UserRpcServerName)
 	userClient = user.NewUserServiceClient(userRpcConn)
-
-	var err error
-
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
-	exitOnError(err)
-
-	channel, err = conn.Channel()
-	exitOnError(err)
-
-	err = channel.ExchangeDeclare(
-		strings.EventExchange,
-		"topic",
-		true,
-		false,
-		false,
-		false,
-		nil,
-	)
-	exitOnError(err)
+	newReactionCaches(container.DB)
 }
 
-func CloseMQConn() {
-	if err := conn.Close(); err != nil {
-		panic(err)
-	}
-
-	if err := channel.Close(); err != nil {
-		panic(err)
+func favoriteAuditAction(ctx context.Context, actorId uint32, videoId uint32, subName string, affectedData string) *models.Action {
+	return &models.Action{
+		Type:         strings.FavoriteIdActionLog,
+		Name:         strings.FavoriteNameActionLog,
+		SubName:      subName,
+		ServiceName:  strings.FavoriteServiceName,
+		ActorId:      actorId,
+		VideoId:      videoId,
+		AffectAction: 1,
+		AffectedData: affectedData,
+		EventId:      uuid.New().String(),
+		TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
+		SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
 	}
 }
 
-func produceFavorite(ctx context.Context, event models.RecommendEvent) {
-	ctx, span := tracing.Tracer.Start(ctx, "FavoriteEventPublisher")
-	defer span.End()
-	logging.SetSpanWithHostname(span)
-	logger := logging.LogService("FavoriteService.FavoriteEventPublisher").WithContext(ctx)
-	data, err := json.Marshal(event) // 序列化
-	if err != nil {
-		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("Error when marshal the event model")
-		logging.SetSpanError(span, err)
-		return
+// syncFavoriteCache 在数据库提交之后原子地更新点赞缓存，更新失败时删除缓存，删除也失败时由对账任务修正。
+// 点赞与取消点赞同样会改变用户的回应，回应缓存总是直接删除
+func (c FavoriteServiceServerImpl) syncFavoriteCache(ctx context.Context, logger *logrus.Entry, actorId uint32, videoId uint32, authorId uint32, delta int) {
+	if delta != 0 {
+		result, err := c.applyFavorite(ctx, actorId, videoId, authorId, delta)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"ActorId":  actorId,
+				"video_id": videoId,
+				"err":      err,
+			}).Warnf("Failed to update the favorite cache, dropping it")
+			if err = c.invalidateFavorite(ctx, actorId, videoId, authorId); err != nil {
+				logger.WithFields(logrus.Fields{
+					"ActorId":  actorId,
+					"video_id": videoId,
+					"err":      err,
+				}).Errorf("Failed to invalidate the favorite cache")
+			}
+		} else if result == favoriteCacheNoop {
+			// 数据库中的记录确实发生了变化，缓存却已经是目标状态，说明缓存与数据库存在偏差
+			logger.WithFields(logrus.Fields{
+				"ActorId":  actorId,
+				"video_id": videoId,
+			}).Warnf("Favorite cache was already in the target state")
+		}
 	}
 
-	headers := rabbitmq.InjectAMQPHeaders(ctx) // 注入headers
-
-	err = channel.PublishWithContext(ctx,
-		strings.EventExchange,
-		strings.FavoriteActionEvent,
-		false,
-		false,
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        data,
-			Headers:     headers,
-		})
-
-	if err != nil {
+	if err := invalidateReactions(ctx, actorId, videoId); err != nil {
 		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("Error when publishing the event model")
-		logging.SetSpanError(span, err)
-		return
+			"ActorId":  actorId,
+			"video_id": videoId,
+			"err":      err,
+		}).Errorf("Failed to invalidate the reaction cache")
 	}
 }
 
@@ -179,144 +154,54 @@ func (c FavoriteServiceServerImpl) FavoriteAction(ctx context.Context, req *favo
 	}
 
 	userLiked := VideosRes.VideoList[0].Author.Id // 被点赞用户的id
-	// 使用zset保存点赞数据 key：userId -> {[]value：videoId, []score：点赞的时间戳}
-	userId := fmt.Sprintf("%suser_like_%d", config.EnvCfg.RedisPrefix, req.ActorId)
-	videoId := fmt.Sprintf("%d", req.VideoId)
-	// 3. 检查当前点赞记录是否存在，防止重复点赞
-	// 查询redis，当前记录存在，返回一个时间戳，当前记录不存在，返回redis.Nil
-	value, err := redis2.Client.ZScore(ctx, userId, videoId).Result()
-
-	// redis.Nil以外的错误
-	if err != redis.Nil && err != nil {
-		logger.WithFields(logrus.Fields{
-			"ActorId":  req.ActorId,
-			"video_id": req.VideoId,
-			"err":      err,
-		}).Errorf("redis Service error")
-		logging.SetSpanError(span, err)
 
-		return
-	}
-	// redis中不存在该点赞记录
-	if err == redis.Nil {
-		err = nil
-	}
-	// 4. 处理重复点赞 1=点赞 2=取消点赞
-	if req.ActionType == 1 {
-		// 时间戳存在 说明重复点赞
-		if value > 0 {
-			resp = &favorite.FavoriteResponse{
-				StatusCode: strings.FavoriteServiceDuplicateCode,
-				StatusMsg:  strings.FavoriteServiceDuplicateError,
-			}
-			logger.WithFields(logrus.Fields{
-				"ActorId":  req.ActorId,
-				"video_id": req.VideoId,
-			}).Info("user duplicate like")
-			return
-		} else { // 正常点赞，开启redis事务
-			_, err = redis2.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-				videoId := fmt.Sprintf("%svideo_like_%d", config.EnvCfg.RedisPrefix, req.VideoId)   // 该视频的点赞数量，存入string
-				userLikedId := fmt.Sprintf("%suser_liked_%d", config.EnvCfg.RedisPrefix, userLiked) // 被赞用户的获赞总量，存入string
-				userLikeId := fmt.Sprintf("%suser_like_%d", config.EnvCfg.RedisPrefix, req.ActorId) // 用户的点赞记录，存入zset
-				pipe.IncrBy(ctx, videoId, 1)
-				pipe.IncrBy(ctx, userLikedId, 1)
-				pipe.ZAdd(ctx, userLikeId, redis.Z{Score: float64(time.Now().Unix()), Member: req.VideoId})
-				return nil
+	// 3. 写入点赞记录，唯一索引 (user_id, video_id) 保证重复点赞与重复取消点赞不会生效。
+	// 推荐反馈与审计记录在同一个事务中写入 Outbox
+	var applied bool
+	err = c.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if req.ActionType == 1 { // 1=点赞
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Favorite{
+				UserId:   req.ActorId,
+				VideoId:  req.VideoId,
+				AuthorId: userLiked,
+				Reaction: uint32(favorite.ReactionType_LIKE),
 			})
-			// Publish event to event_exchange and audit_exchange
-			wg := sync.WaitGroup{}
-			wg.Add(2)
-			go func() {
-				defer wg.Done()
-				produceFavorite(ctx, models.RecommendEvent{
-					ActorId: req.ActorId,
-					VideoId: []uint32{req.VideoId},
-					Type:    2,
-					Source:  config.FavoriteRpcServerName,
-				})
-			}()
-			go func() {
-				defer wg.Done()
-				action := &models.Action{
-					Type:         strings.FavoriteIdActionLog,
-					Name:         strings.FavoriteNameActionLog,
-					SubName:      strings.FavoriteUpActionSubLog,
-					ServiceName:  strings.FavoriteServiceName,
-					ActorId:      req.ActorId,
-					VideoId:      req.VideoId,
-					AffectAction: 1,
-					AffectedData: "1",
-					EventId:      uuid.New().String(),
-					TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
-					SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
-				}
-				audit.PublishAuditEvent(ctx, action, channel)
-			}()
-			wg.Wait()
-			if err == redis.Nil {
-				err = nil
+			if result.Error != nil {
+				return result.Error
 			}
-		}
-	} else {
-		// 时间戳不存在 说明重复取消点赞
-		if value == 0 {
-			resp = &favorite.FavoriteResponse{
-				StatusCode: strings.FavoriteServiceCancelCode,
-				StatusMsg:  strings.FavoriteServiceCancelError,
-			}
-
-			logger.WithFields(logrus.Fields{
-				"ActorId":  req.ActorId,
-				"video_id": req.VideoId,
-			}).Info("User did not like, cancel liking")
-			return
-		} else { // 正常取消点赞
-			_, err = redis2.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
-				videoId := fmt.Sprintf("%svideo_like_%d", config.EnvCfg.RedisPrefix, req.VideoId)     // 该视频的点赞数量
-				user_liked_id := fmt.Sprintf("%suser_liked_%d", config.EnvCfg.RedisPrefix, userLiked) // 被赞用户的获赞数量
-				user_like_Id := fmt.Sprintf("%suser_like_%d", config.EnvCfg.RedisPrefix, req.ActorId) // 用户的点赞
-				pipe.IncrBy(ctx, videoId, -1)
-				pipe.IncrBy(ctx, user_liked_id, -1)
-				pipe.ZRem(ctx, user_like_Id, req.VideoId)
+			if applied = result.RowsAffected > 0; !applied {
 				return nil
-			})
+			}
 
-			// Publish event to event_exchange and audit_exchange
-			wg := sync.WaitGroup{}
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-				action := &models.Action{
-					Type:         strings.FavoriteIdActionLog,
-					Name:         strings.FavoriteNameActionLog,
-					SubName:      strings.FavoriteDownActionSubLog,
-					ServiceName:  strings.FavoriteServiceName,
-					ActorId:      req.ActorId,
-					VideoId:      req.VideoId,
-					AffectAction: 1,
-					AffectedData: "-1",
-					EventId:      uuid.New().String(),
-					TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
-					SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
-				}
-				audit.PublishAuditEvent(ctx, action, channel)
-			}()
-			wg.Wait()
-
-			if err == redis.Nil {
-				err = nil
+			if err := outbox.Enqueue(ctx, tx, strings.EventExchange, strings.FavoriteActionEvent, models.RecommendEvent{
+				ActorId: req.ActorId,
+				VideoId: []uint32{req.VideoId},
+				Type:    2,
+				Source:  config.FavoriteRpcServerName,
+			}); err != nil {
+				return err
 			}
+			return audit.EnqueueAuditEvent(ctx, tx, favoriteAuditAction(ctx, req.ActorId, req.VideoId, strings.FavoriteUpActionSubLog, "1"))
 		}
 
-	}
+		// 2=取消点赞
+		result := tx.Where("user_id = ? AND video_id = ?", req.ActorId, req.VideoId).Delete(&models.Favorite{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if applied = result.RowsAffected > 0; !applied {
+			return nil
+		}
+		return audit.EnqueueAuditEvent(ctx, tx, favoriteAuditAction(ctx, req.ActorId, req.VideoId, strings.FavoriteDownActionSubLog, "-1"))
+	})
 
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"ActorId":     req.ActorId,
 			"video_id":    req.VideoId,
 			"action_type": req.ActionType, //点赞 1 2 取消点赞
-		}).Errorf("redis Service error")
+			"err":         err,
+		}).Errorf("Failed to write the favorite record")
 		logging.SetSpanError(span, err)
 
 		return &favorite.FavoriteResponse{
@@ -324,6 +209,35 @@ func (c FavoriteServiceServerImpl) FavoriteAction(ctx context.Context, req *favo
 			StatusMsg:  strings.FavoriteServiceError,
 		}, err
 	}
+
+	// 4. 处理重复点赞与重复取消点赞
+	if !applied {
+		logger.WithFields(logrus.Fields{
+			"ActorId":     req.ActorId,
+			"video_id":    req.VideoId,
+			"action_type": req.ActionType,
+		}).Info("Favorite action does not take effect")
+		if req.ActionType == 1 {
+			resp = &favorite.FavoriteResponse{
+				StatusCode: strings.FavoriteServiceDuplicateCode,
+				StatusMsg:  strings.FavoriteServiceDuplicateError,
+			}
+		} else {
+			resp = &favorite.FavoriteResponse{
+				StatusCode: strings.FavoriteServiceCancelCode,
+				StatusMsg:  strings.FavoriteServiceCancelError,
+			}
+		}
+		return
+	}
+
+	// 5. 先写入DB 再更新缓存
+	delta := 1
+	if req.ActionType != 1 {
+		delta = -1
+	}
+	c.syncFavoriteCache(ctx, logger, req.ActorId, req.VideoId, userLiked, delta)
+
 	resp = &favorite.FavoriteResponse{
 		StatusCode: strings.ServiceOKCode,
 		StatusMsg:  strings.ServiceOK,
@@ -373,9 +287,30 @@ func (c FavoriteServiceServerImpl) FavoriteList(ctx context.Context, req *favori
 		}
 		return
 	}
-	// 2. 获取用户点赞的视频id列表：ZRevRange获取key在指定排名内的所有成员，0表示最高分的成员，-1表示最低分的成员
-	userId := fmt.Sprintf("%suser_like_%d", config.EnvCfg.RedisPrefix, req.UserId)
-	arr, err := redis2.Client.ZRevRange(ctx, userId, 0, -1).Result()
+	// 私密账号只对本人与粉丝可见
+	visible, err := privacy.CanView(ctx, c.deps.DB, req.ActorId, req.UserId, userResponse.IsPrivate)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": req.ActorId,
+			"UserId":  req.UserId,
+		}).Errorf("Failed to check whether the user is visible")
+		logging.SetSpanError(span, err)
+		resp = &favorite.FavoriteListResponse{
+			StatusCode: strings.FavoriteServiceErrorCode,
+			StatusMsg:  strings.FavoriteServiceError,
+		}
+		return resp, nil
+	}
+	if !visible {
+		resp = &favorite.FavoriteListResponse{
+			StatusCode: strings.AccountPrivateCode,
+			StatusMsg:  strings.AccountPrivate,
+		}
+		return resp, nil
+	}
+	// 2. 获取用户点赞的视频id列表，按点赞时间倒序
+	res, err := c.userLikes(ctx, req.UserId)
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"ActorId": req.ActorId,
@@ -388,7 +323,7 @@ func (c FavoriteServiceServerImpl) FavoriteList(ctx context.Context, req *favori
 			StatusMsg:  strings.FavoriteServiceError,
 		}, err
 	}
-	if len(arr) == 0 {
+	if len(res) == 0 {
 		resp = &favorite.FavoriteListResponse{
 			StatusCode: strings.ServiceOKCode,
 			StatusMsg:  strings.ServiceOK,
@@ -397,12 +332,6 @@ func (c FavoriteServiceServerImpl) FavoriteList(ctx context.Context, req *favori
 		return resp, nil
 	}
 
-	// 将string数组转换为uint32数组
-	res := make([]uint32, len(arr))
-	for index, val := range arr {
-		num, _ := strconv.Atoi(val)
-		res[index] = uint32(num)
-	}
 	// 3. 调用feed服务查询具体视频信息
 	var VideoList []*feed.Video
 	value, err := feedClient.QueryVideos(ctx, &feed.QueryVideosRequest{
@@ -459,15 +388,8 @@ func (c FavoriteServiceServerImpl) IsFavorite(ctx context.Context, req *favorite
 		}, err
 	}
 
-	userId := fmt.Sprintf("%suser_like_%d", config.EnvCfg.RedisPrefix, req.ActorId)
-	videoId := fmt.Sprintf("%d", req.VideoId)
-
-	// 查询redis，当前记录存在，返回一个时间戳，当前记录不存在，返回redis.Nil
-	ok, err := redis2.Client.ZScore(ctx, userId, videoId).Result()
-
-	if err == redis.Nil {
-		err = nil
-	} else if err != nil {
+	ok, err := c.isLiked(ctx, req.ActorId, req.VideoId)
+	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"ActorId":  req.ActorId,
 			"video_id": req.VideoId,
@@ -480,7 +402,7 @@ func (c FavoriteServiceServerImpl) IsFavorite(ctx context.Context, req *favorite
 		}, err
 	}
 
-	if ok != 0 {
+	if ok {
 		resp = &favorite.IsFavoriteResponse{
 			StatusCode: strings.ServiceOKCode,
 			StatusMsg:  strings.ServiceOK,
@@ -526,13 +448,8 @@ func (c FavoriteServiceServerImpl) CountFavorite(ctx context.Context, req *favor
 		}, err
 	}
 	// 获取该视频的点赞数量
-	videoId := fmt.Sprintf("%svideo_like_%d", config.EnvCfg.RedisPrefix, req.VideoId)
-	value, err := redis2.Client.Get(ctx, videoId).Result()
-	var num int
-	if err == redis.Nil {
-		num = 0
-		err = nil
-	} else if err != nil {
+	num, err := c.countVideoLikes(ctx, req.VideoId)
+	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"video_id": req.VideoId,
 		}).Errorf("redis Service error")
@@ -542,8 +459,6 @@ func (c FavoriteServiceServerImpl) CountFavorite(ctx context.Context, req *favor
 			StatusCode: strings.FavoriteServiceErrorCode,
 			StatusMsg:  strings.FavoriteServiceError,
 		}, err
-	} else {
-		num, _ = strconv.Atoi(value)
 	}
 	resp = &favorite.CountFavoriteResponse{
 		StatusCode: strings.ServiceOKCode,
@@ -593,14 +508,9 @@ func (c FavoriteServiceServerImpl) CountUserFavorite(ctx context.Context, req *f
 		}
 		return
 	}
-	// ZCard用于获取zset中成员的数量，即用户点赞的视频数量
-	userLikeId := fmt.Sprintf("%suser_like_%d", config.EnvCfg.RedisPrefix, req.UserId)
-	value, err := redis2.Client.ZCard(ctx, userLikeId).Result()
-	var num int64
-	if err == redis.Nil {
-		num = 0
-		err = nil
-	} else if err != nil {
+	// 用户点赞的视频数量
+	num, err := c.countUserLikes(ctx, req.UserId)
+	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"user_id": req.UserId,
 		}).Errorf("redis Service error")
@@ -610,8 +520,6 @@ func (c FavoriteServiceServerImpl) CountUserFavorite(ctx context.Context, req *f
 			StatusCode: strings.FavoriteServiceErrorCode,
 			StatusMsg:  strings.FavoriteServiceError,
 		}, err
-	} else {
-		num = value
 	}
 
 	resp = &favorite.CountUserFavoriteResponse{
@@ -665,13 +573,8 @@ func (c FavoriteServiceServerImpl) CountUserTotalFavorited(ctx context.Context,
 	}
 
 	// 获取用户总共获得的点赞数量
-	userLikedId := fmt.Sprintf("%suser_liked_%d", config.EnvCfg.RedisPrefix, req.UserId)
-	value, err := redis2.Client.Get(ctx, userLikedId).Result()
-	var num int
-	if err == redis.Nil {
-		num = 0
-		err = nil
-	} else if err != nil {
+	num, err := c.countUserLiked(ctx, req.UserId)
+	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"err":     err,
 			"user_id": req.UserId,
@@ -683,8 +586,6 @@ func (c FavoriteServiceServerImpl) CountUserTotalFavorited(ctx context.Context,
 			StatusCode: strings.FavoriteServiceErrorCode,
 			StatusMsg:  strings.FavoriteServiceError,
 		}, err
-	} else {
-		num, _ = strconv.Atoi(value)
 	}
 	resp = &favorite.CountUserTotalFavoritedResponse{
 		StatusCode: strings.ServiceOKCode,
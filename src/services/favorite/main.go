@@ -5,14 +5,15 @@ import (
 	"GuGoTik/src/extra/profiling"
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/favorite"
-	"GuGoTik/src/utils/audit"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
 	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
 	"github.com/oklog/run"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/robfig/cron/v3"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
 	"net"
@@ -26,6 +27,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.FavoriteRpcServerName)
 
 	if err != nil {
@@ -71,16 +74,12 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.FavoriteRpcServerName, config.FavoriteRpcServerPort)
 
 	var srv FavoriteServiceServerImpl
+	srv.New(container)
 	favorite.RegisterFavoriteServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
-	defer CloseMQConn()
 	if err := consul.RegisterConsul(config.FavoriteRpcServerName, config.FavoriteRpcServerPort); err != nil {
 		log.Panicf("Rpc %s register consul happens error for: %v", config.FavoriteRpcServerName, err)
 	}
-	srv.New()
-
-	// Initialize the audit_exchange
-	audit.DeclareAuditExchange(channel)
 
 	srvMetrics.InitializeMetrics(s)
 
@@ -93,6 +92,23 @@ func main() {
 		log.Errorf("Rpc %s listen happens error for: %v", config.FavoriteRpcServerName, err)
 	})
 
+	// 定期以数据库为准修正 Redis 中的点赞缓存
+	cronRunner := cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
+	if _, err := cronRunner.AddFunc(config.EnvCfg.FavoriteReconcileCron, func() {
+		srv.reconcile(context.Background())
+	}); err != nil {
+		log.WithFields(logrus.Fields{
+			"err":  err,
+			"cron": config.EnvCfg.FavoriteReconcileCron,
+		}).Panicf("Cannot start favorite reconcile cron job")
+	}
+	g.Add(func() error {
+		cronRunner.Run()
+		return nil
+	}, func(error) {
+		<-cronRunner.Stop().Done()
+	})
+
 	httpSrv := &http.Server{Addr: config.EnvCfg.PodIpAddr + config.Metrics}
 	g.Add(func() error {
 		m := http.NewServeMux()
@@ -113,6 +129,9 @@ func main() {
 
 	g.Add(run.SignalHandler(context.Background(), syscall.SIGINT, syscall.SIGTERM))
 
+	// 启动时先执行一次，尽早完成历史点赞的导入
+	go srv.reconcile(context.Background())
+
 	if err := g.Run(); err != nil {
 		log.WithFields(logrus.Fields{
 			"err": err,
@@ -0,0 +1,312 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/favorite"
+	"GuGoTik/src/rpc/feed"
+	"GuGoTik/src/utils/audit"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/outbox"
+	"context"
+	"errors"
+	"sort"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// reactionFeedback 各类回应对应的推荐系统反馈类型，普通点赞沿用原来的 favorite
+var reactionFeedback = map[favorite.ReactionType]string{
+	favorite.ReactionType_LIKE: "favorite",
+	favorite.ReactionType_LOVE: "love",
+	favorite.ReactionType_HAHA: "haha",
+	favorite.ReactionType_WOW:  "wow",
+	favorite.ReactionType_SAD:  "sad",
+}
+
+// React 设置用户对视频的回应，每个用户对每个视频只保留一个回应，REACTION_NONE 表示撤销回应。
+// 新增回应等同于点赞，撤销回应等同于取消点赞，修改回应类型时点赞数量不变
+func (c FavoriteServiceServerImpl) React(ctx context.Context, req *favorite.ReactRequest) (resp *favorite.ReactResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ReactService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("FavoriteService.React").WithContext(ctx)
+
+	logger.WithFields(logrus.Fields{
+		"ActorId":  req.ActorId,
+		"video_id": req.VideoId,
+		"reaction": req.Reaction,
+	}).Debugf("Process start")
+
+	if _, ok := favorite.ReactionType_name[int32(req.Reaction)]; !ok {
+		resp = &favorite.ReactResponse{
+			StatusCode: strings.FavoriteReactionInvalidCode,
+			StatusMsg:  strings.FavoriteReactionInvalid,
+		}
+		return
+	}
+
+	// 1. 检查视频是否存在并获取作者
+	videosRes, err := feedClient.QueryVideos(ctx, &feed.QueryVideosRequest{
+		ActorId:  req.ActorId,
+		VideoIds: []uint32{req.VideoId},
+	})
+	if err != nil || videosRes.StatusCode != strings.ServiceOKCode {
+		logger.WithFields(logrus.Fields{
+			"ActorId":  req.ActorId,
+			"video_id": req.VideoId,
+			"err":      err,
+		}).Errorf("React call feed Service error")
+		logging.SetSpanError(span, err)
+		resp = &favorite.ReactResponse{
+			StatusCode: strings.FeedServiceInnerErrorCode,
+			StatusMsg:  strings.FeedServiceInnerError,
+		}
+		return
+	}
+	if len(videosRes.VideoList) == 0 {
+		resp = &favorite.ReactResponse{
+			StatusCode: strings.UnableToQueryVideoErrorCode,
+			StatusMsg:  strings.UnableToQueryVideoError,
+		}
+		return
+	}
+	if videosRes.VideoList[0].Author == nil {
+		resp = &favorite.ReactResponse{
+			StatusCode: strings.FeedServiceInnerErrorCode,
+			StatusMsg:  strings.FeedServiceInnerError,
+		}
+		return
+	}
+	authorId := videosRes.VideoList[0].Author.Id
+
+	// 2. 锁住已有的回应后再修改，并发的新增由唯一索引保证只有一个生效
+	var previous favorite.ReactionType
+	var applied bool
+	err = c.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.Favorite
+		lookup := func() error {
+			return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Select("id", "reaction").
+				Where("user_id = ? AND video_id = ?", req.ActorId, req.VideoId).
+				Take(&existing).Error
+		}
+
+		err := lookup()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if req.Reaction == favorite.ReactionType_REACTION_NONE {
+				return nil
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Favorite{
+				UserId:   req.ActorId,
+				VideoId:  req.VideoId,
+				AuthorId: authorId,
+				Reaction: uint32(req.Reaction),
+			})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				applied = true
+				return enqueueReaction(ctx, tx, req, previous)
+			}
+			// 并发的请求先写入了记录，重新读取后按修改回应处理
+			err = lookup()
+		}
+		if err != nil {
+			return err
+		}
+
+		previous = favorite.ReactionType(existing.Reaction)
+		if previous == req.Reaction {
+			return nil
+		}
+		applied = true
+		if req.Reaction == favorite.ReactionType_REACTION_NONE {
+			if err := tx.Delete(&models.Favorite{}, existing.ID).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Model(&existing).Update("reaction", uint32(req.Reaction)).Error; err != nil {
+			return err
+		}
+		return enqueueReaction(ctx, tx, req, previous)
+	})
+
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":  req.ActorId,
+			"video_id": req.VideoId,
+			"reaction": req.Reaction,
+			"err":      err,
+		}).Errorf("Failed to write the reaction")
+		logging.SetSpanError(span, err)
+		resp = &favorite.ReactResponse{
+			StatusCode: strings.FavoriteServiceErrorCode,
+			StatusMsg:  strings.FavoriteServiceError,
+		}
+		return
+	}
+
+	// 3. 处理重复回应与撤销不存在的回应
+	if !applied {
+		if req.Reaction == favorite.ReactionType_REACTION_NONE {
+			resp = &favorite.ReactResponse{
+				StatusCode: strings.FavoriteServiceCancelCode,
+				StatusMsg:  strings.FavoriteServiceCancelError,
+			}
+		} else {
+			resp = &favorite.ReactResponse{
+				StatusCode: strings.FavoriteServiceDuplicateCode,
+				StatusMsg:  strings.FavoriteServiceDuplicateError,
+			}
+		}
+		return
+	}
+
+	// 4. 只有新增与撤销回应会改变点赞数量
+	delta := 0
+	switch {
+	case previous == favorite.ReactionType_REACTION_NONE:
+		delta = 1
+	case req.Reaction == favorite.ReactionType_REACTION_NONE:
+		delta = -1
+	}
+	c.syncFavoriteCache(ctx, logger, req.ActorId, req.VideoId, authorId, delta)
+
+	resp = &favorite.ReactResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	logger.WithFields(logrus.Fields{
+		"response": resp,
+	}).Debugf("Process done.")
+	return
+}
+
+// enqueueReaction 在事务中写入回应的推荐反馈与审计记录，撤销回应不产生推荐反馈
+func enqueueReaction(ctx context.Context, tx *gorm.DB, req *favorite.ReactRequest, previous favorite.ReactionType) error {
+	if req.Reaction != favorite.ReactionType_REACTION_NONE {
+		if err := outbox.Enqueue(ctx, tx, strings.EventExchange, strings.FavoriteActionEvent, models.RecommendEvent{
+			ActorId: req.ActorId,
+			VideoId: []uint32{req.VideoId},
+			Type:    2,
+			Source:  config.FavoriteRpcServerName,
+			Slice:   reactionFeedback[req.Reaction],
+		}); err != nil {
+			return err
+		}
+	}
+	return audit.EnqueueAuditEvent(ctx, tx, favoriteAuditAction(ctx, req.ActorId, req.VideoId,
+		strings.FavoriteReactActionSubLog, previous.String()+"->"+req.Reaction.String()))
+}
+
+// GetReaction 用户对视频的回应
+func (c FavoriteServiceServerImpl) GetReaction(ctx context.Context, req *favorite.GetReactionRequest) (resp *favorite.GetReactionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "GetReactionService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("FavoriteService.GetReaction").WithContext(ctx)
+
+	reaction, err := userReaction(ctx, req.ActorId, req.VideoId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":  req.ActorId,
+			"video_id": req.VideoId,
+			"err":      err,
+		}).Errorf("Failed to get the reaction")
+		logging.SetSpanError(span, err)
+		resp = &favorite.GetReactionResponse{
+			StatusCode: strings.FavoriteServiceErrorCode,
+			StatusMsg:  strings.FavoriteServiceError,
+		}
+		return
+	}
+
+	resp = &favorite.GetReactionResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Reaction:   favorite.ReactionType(reaction),
+	}
+	return
+}
+
+// CountReactions 视频各类回应的数量，以及发出请求的用户自己的回应
+func (c FavoriteServiceServerImpl) CountReactions(ctx context.Context, req *favorite.CountReactionsRequest) (resp *favorite.CountReactionsResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "CountReactionsService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("FavoriteService.CountReactions").WithContext(ctx)
+
+	existed, err := feedClient.QueryVideoExisted(ctx, &feed.VideoExistRequest{
+		VideoId: req.VideoId,
+	})
+	if err != nil || existed.StatusCode != strings.ServiceOKCode {
+		logger.WithFields(logrus.Fields{
+			"video_id": req.VideoId,
+			"err":      err,
+		}).Errorf("feed Service error")
+		logging.SetSpanError(span, err)
+		resp = &favorite.CountReactionsResponse{
+			StatusCode: strings.FeedServiceInnerErrorCode,
+			StatusMsg:  strings.FeedServiceInnerError,
+		}
+		return
+	}
+	if !existed.Existed {
+		resp = &favorite.CountReactionsResponse{
+			StatusCode: strings.UnableToQueryVideoErrorCode,
+			StatusMsg:  strings.UnableToQueryVideoError,
+		}
+		return
+	}
+
+	counts, err := videoReactions(ctx, req.VideoId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"video_id": req.VideoId,
+			"err":      err,
+		}).Errorf("Failed to count the reactions")
+		logging.SetSpanError(span, err)
+		resp = &favorite.CountReactionsResponse{
+			StatusCode: strings.FavoriteServiceErrorCode,
+			StatusMsg:  strings.FavoriteServiceError,
+		}
+		return
+	}
+
+	resp = &favorite.CountReactionsResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Counts:     make([]*favorite.ReactionCount, 0, len(counts)),
+	}
+	for reaction, count := range counts {
+		if count == 0 {
+			continue
+		}
+		resp.Counts = append(resp.Counts, &favorite.ReactionCount{
+			Reaction: favorite.ReactionType(reaction),
+			Count:    count,
+		})
+	}
+	sort.Slice(resp.Counts, func(i, j int) bool {
+		return resp.Counts[i].Reaction < resp.Counts[j].Reaction
+	})
+
+	if req.ActorId != 0 {
+		reaction, err := userReaction(ctx, req.ActorId, req.VideoId)
+		if err != nil {
+			// 计数已经查到，自己的回应查询失败时不影响返回
+			logger.WithFields(logrus.Fields{
+				"ActorId":  req.ActorId,
+				"video_id": req.VideoId,
+				"err":      err,
+			}).Warnf("Failed to get the actor reaction")
+		}
+		resp.ActorReaction = favorite.ReactionType(reaction)
+	}
+	return
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+const reconcileBatchSize = 500
+
+const reconcileLockTTL = 30 * time.Minute
+
+var releaseLockScript = redis.NewScript(`
+if redis.call('GET', KEYS[1]) == ARGV[1] then
+	return redis.call('DEL', KEYS[1])
+end
+return 0
+`)
+
+func reconcileLockKey() string {
+	return config.EnvCfg.RedisPrefix + "favorite_reconcile_lock"
+}
+
+// backfilledKey 存在时说明 Redis 中历史的点赞记录已经导入数据库
+func backfilledKey() string {
+	return config.EnvCfg.RedisPrefix + "favorite_backfilled"
+}
+
+// reconcile 以数据库为准修正 Redis 中的点赞缓存，不一致的缓存直接删除，下一次读取时重建。
+// 第一次执行时先将只存在于 Redis 中的历史点赞导入数据库，避免历史数据被当作偏差删除
+func (c FavoriteServiceServerImpl) reconcile(ctx context.Context) {
+	ctx, span := tracing.Tracer.Start(ctx, "FavoriteReconcile")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("FavoriteService.Reconcile").WithContext(ctx)
+
+	// 多个副本同时运行时只需要一个执行
+	token := uuid.New().String()
+	locked, err := c.deps.Redis.SetNX(ctx, reconcileLockKey(), token, reconcileLockTTL).Result()
+	if err != nil || !locked {
+		return
+	}
+	defer releaseLockScript.Run(context.Background(), c.deps.Redis, []string{reconcileLockKey()}, token)
+
+	backfilled, err := c.deps.Redis.Exists(ctx, backfilledKey()).Result()
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Failed to check the backfill state")
+		logging.SetSpanError(span, err)
+		return
+	}
+	if backfilled == 0 {
+		imported, err := c.backfill(ctx)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Errorf("Failed to backfill favorites from redis")
+			logging.SetSpanError(span, err)
+			return
+		}
+		if err := c.deps.Redis.Set(ctx, backfilledKey(), time.Now().Unix(), 0).Err(); err != nil {
+			logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Errorf("Failed to mark favorites as backfilled")
+			logging.SetSpanError(span, err)
+			return
+		}
+		logger.WithFields(logrus.Fields{
+			"imported": imported,
+		}).Infof("Favorites in redis are backfilled to the database")
+	}
+
+	var dropped atomic.Int64
+	for _, job := range []struct {
+		match string
+		fix   func(ctx context.Context, keys []string) (int64, error)
+	}{
+		{match: "video_like_*", fix: c.reconcileCounters("video_like_", "video_id")},
+		{match: "user_liked_*", fix: c.reconcileCounters("user_liked_", "author_id")},
+		{match: "user_like_[0-9]*", fix: c.reconcileUserLikes},
+	} {
+		err := c.scanKeys(ctx, config.EnvCfg.RedisPrefix+job.match, func(keys []string) error {
+			count, err := job.fix(ctx, keys)
+			dropped.Add(count)
+			return err
+		})
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"err":   err,
+				"match": job.match,
+			}).Errorf("Failed to reconcile favorite cache")
+			logging.SetSpanError(span, err)
+		}
+	}
+
+	logger.WithFields(logrus.Fields{
+		"dropped": dropped.Load(),
+	}).Infof("Favorite cache reconciled")
+}
+
+// scanKeys 分批遍历匹配的键，集群模式下遍历每一个主节点
+func (c FavoriteServiceServerImpl) scanKeys(ctx context.Context, match string, fn func(keys []string) error) error {
+	scan := func(ctx context.Context, client redis.Cmdable) error {
+		var cursor uint64
+		for {
+			keys, next, err := client.Scan(ctx, cursor, match, reconcileBatchSize).Result()
+			if err != nil {
+				return err
+			}
+			if len(keys) > 0 {
+				if err := fn(keys); err != nil {
+					return err
+				}
+			}
+			if next == 0 {
+				return nil
+			}
+			cursor = next
+		}
+	}
+
+	if cluster, ok := c.deps.Redis.(*redis.ClusterClient); ok {
+		return cluster.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scan(ctx, client)
+		})
+	}
+	return scan(ctx, c.deps.Redis)
+}
+
+// parseKeyIds 从键名中解析出 Id，返回 Id 到键名的映射
+func parseKeyIds(keys []string, prefix string) map[uint32]string {
+	ids := make(map[uint32]string, len(keys))
+	for _, key := range keys {
+		id, err := strconv.ParseUint(strings.TrimPrefix(key, config.EnvCfg.RedisPrefix+prefix), 10, 32)
+		if err != nil {
+			continue
+		}
+		ids[uint32(id)] = key
+	}
+	return ids
+}
+
+// reconcileCounters 比较计数与数据库中按 column 分组的点赞数量，返回删除的缓存数量
+func (c FavoriteServiceServerImpl) reconcileCounters(prefix string, column string) func(ctx context.Context, keys []string) (int64, error) {
+	return func(ctx context.Context, keys []string) (int64, error) {
+		keyOf := parseKeyIds(keys, prefix)
+		if len(keyOf) == 0 {
+			return 0, nil
+		}
+
+		ids := make([]uint32, 0, len(keyOf))
+		cmds := make(map[uint32]*redis.StringCmd, len(keyOf))
+		_, err := c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+			for id, key := range keyOf {
+				ids = append(ids, id)
+				cmds[id] = pipe.Get(ctx, key)
+			}
+			return nil
+		})
+		if err != nil && err != redis.Nil {
+			return 0, err
+		}
+
+		var rows []struct {
+			Id    uint32
+			Count int64
+		}
+		if err := c.deps.DB.WithContext(database.WithPrimary(ctx)).Model(&models.Favorite{}).
+			Select(column+" AS id, count(*) AS count").
+			Where(column+" IN ?", ids).
+			Group(column).
+			Scan(&rows).Error; err != nil {
+			return 0, err
+		}
+		counts := make(map[uint32]int64, len(rows))
+		for _, row := range rows {
+			counts[row.Id] = row.Count
+		}
+
+		var stale []string
+		for id, cmd := range cmds {
+			cached, err := cmd.Int64()
+			if err == redis.Nil {
+				continue
+			}
+			if err != nil || cached != counts[id] {
+				stale = append(stale, keyOf[id])
+			}
+		}
+		return c.dropKeys(ctx, stale)
+	}
+}
+
+// reconcileUserLikes 比较用户的点赞集合与数据库中的点赞记录，返回删除的缓存数量
+func (c FavoriteServiceServerImpl) reconcileUserLikes(ctx context.Context, keys []string) (int64, error) {
+	keyOf := parseKeyIds(keys, "user_like_")
+	if len(keyOf) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]uint32, 0, len(keyOf))
+	cmds := make(map[uint32]*redis.StringSliceCmd, len(keyOf))
+	_, err := c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for id, key := range keyOf {
+			ids = append(ids, id)
+			cmds[id] = pipe.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: likeScoreMin, Max: "+inf"})
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var favorites []models.Favorite
+	if err := c.deps.DB.WithContext(database.WithPrimary(ctx)).
+		Select("user_id", "video_id").
+		Where("user_id IN ?", ids).
+		Find(&favorites).Error; err != nil {
+		return 0, err
+	}
+	liked := make(map[uint32]map[string]bool, len(ids))
+	for _, f := range favorites {
+		if liked[f.UserId] == nil {
+			liked[f.UserId] = map[string]bool{}
+		}
+		liked[f.UserId][strconv.FormatUint(uint64(f.VideoId), 10)] = true
+	}
+
+	var stale []string
+	for id, cmd := range cmds {
+		members := cmd.Val()
+		if len(members) != len(liked[id]) {
+			stale = append(stale, keyOf[id])
+			continue
+		}
+		for _, member := range members {
+			if !liked[id][member] {
+				stale = append(stale, keyOf[id])
+				break
+			}
+		}
+	}
+	return c.dropKeys(ctx, stale)
+}
+
+func (c FavoriteServiceServerImpl) dropKeys(ctx context.Context, keys []string) (int64, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+	_, err := c.deps.Redis.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Del(ctx, key)
+		}
+		return nil
+	})
+	return int64(len(keys)), err
+}
+
+// backfill 将 Redis 中历史的点赞记录导入数据库，已经存在的记录保持不变，返回导入的数量
+func (c FavoriteServiceServerImpl) backfill(ctx context.Context) (imported int64, err error) {
+	err = c.scanKeys(ctx, config.EnvCfg.RedisPrefix+"user_like_[0-9]*", func(keys []string) error {
+		keyOf := parseKeyIds(keys, "user_like_")
+
+		var favorites []models.Favorite
+		var videoIds []uint32
+		for userId, key := range keyOf {
+			members, err := c.deps.Redis.ZRangeByScoreWithScores(ctx, key, &redis.ZRangeBy{Min: likeScoreMin, Max: "+inf"}).Result()
+			if err != nil {
+				return err
+			}
+			for _, member := range members {
+				videoId, err := strconv.ParseUint(member.Member.(string), 10, 32)
+				if err != nil {
+					continue
+				}
+				favorites = append(favorites, models.Favorite{
+					UserId:    userId,
+					VideoId:   uint32(videoId),
+					CreatedAt: time.Unix(int64(member.Score), 0),
+				})
+				videoIds = append(videoIds, uint32(videoId))
+			}
+		}
+		if len(favorites) == 0 {
+			return nil
+		}
+
+		// 作者 Id 只保存在视频表中，已经删除的视频不再导入
+		var videos []models.Video
+		if err := c.deps.DB.WithContext(ctx).Select("id", "user_id").Where("id IN ?", videoIds).Find(&videos).Error; err != nil {
+			return err
+		}
+		authorOf := make(map[uint32]uint32, len(videos))
+		for _, video := range videos {
+			authorOf[video.ID] = video.UserId
+		}
+
+		rows := make([]models.Favorite, 0, len(favorites))
+		for _, f := range favorites {
+			if author, ok := authorOf[f.VideoId]; ok {
+				f.AuthorId = author
+				rows = append(rows, f)
+			}
+		}
+		if len(rows) == 0 {
+			return nil
+		}
+
+		result := c.deps.DB.WithContext(ctx).
+			Clauses(clause.OnConflict{DoNothing: true}).
+			CreateInBatches(rows, reconcileBatchSize)
+		if result.Error != nil {
+			return result.Error
+		}
+		atomic.AddInt64(&imported, result.RowsAffected)
+		return nil
+	})
+	return
+}
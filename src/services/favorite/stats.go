@@ -0,0 +1,164 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/favorite"
+	"GuGoTik/src/rpc/user"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultLikersLimit = 30
+	maxLikersLimit     = 100
+)
+
+// BatchVideoStats 一次返回多个视频的点赞数以及发出请求的用户的点赞状态，供 Feed 渲染视频列表使用
+func (c FavoriteServiceServerImpl) BatchVideoStats(ctx context.Context, req *favorite.BatchVideoStatsRequest) (resp *favorite.BatchVideoStatsResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "BatchVideoStatsService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("FavoriteService.BatchVideoStats").WithContext(ctx)
+
+	stats, err := c.batchVideoStats(ctx, req.ActorId, req.VideoIds)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":   req.ActorId,
+			"video_ids": req.VideoIds,
+			"err":       err,
+		}).Errorf("redis Service error")
+		logging.SetSpanError(span, err)
+		resp = &favorite.BatchVideoStatsResponse{
+			StatusCode: strings.FavoriteServiceErrorCode,
+			StatusMsg:  strings.FavoriteServiceError,
+		}
+		return
+	}
+
+	resp = &favorite.BatchVideoStatsResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Stats:      make([]*favorite.VideoStats, 0, len(stats)),
+	}
+	for _, videoId := range req.VideoIds {
+		s, ok := stats[videoId]
+		if !ok {
+			continue
+		}
+		// 重复的 id 只返回一次
+		delete(stats, videoId)
+		resp.Stats = append(resp.Stats, &favorite.VideoStats{
+			VideoId:       videoId,
+			FavoriteCount: uint32(s.count),
+			IsFavorite:    s.liked,
+			Reaction:      favorite.ReactionType(s.reaction),
+		})
+	}
+	return
+}
+
+// ListVideoLikers 视频作者按点赞时间倒序分页查看点赞的用户
+func (c FavoriteServiceServerImpl) ListVideoLikers(ctx context.Context, req *favorite.ListVideoLikersRequest) (resp *favorite.ListVideoLikersResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListVideoLikersService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("FavoriteService.ListVideoLikers").WithContext(ctx)
+
+	var video models.Video
+	err = c.deps.DB.WithContext(ctx).Select("id", "user_id").Where("id = ?", req.VideoId).Take(&video).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		resp = &favorite.ListVideoLikersResponse{
+			StatusCode: strings.UnableToQueryVideoErrorCode,
+			StatusMsg:  strings.UnableToQueryVideoError,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"video_id": req.VideoId,
+			"err":      err,
+		}).Errorf("Failed to query the video")
+		logging.SetSpanError(span, err)
+		resp = &favorite.ListVideoLikersResponse{
+			StatusCode: strings.FavoriteServiceErrorCode,
+			StatusMsg:  strings.FavoriteServiceError,
+		}
+		return
+	}
+	if video.UserId != req.ActorId {
+		resp = &favorite.ListVideoLikersResponse{
+			StatusCode: strings.FavoriteLikersForbiddenCode,
+			StatusMsg:  strings.FavoriteLikersForbidden,
+		}
+		return
+	}
+
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultLikersLimit
+	}
+	if limit > maxLikersLimit {
+		limit = maxLikersLimit
+	}
+
+	query := c.deps.DB.WithContext(ctx).Select("id", "user_id").Where("video_id = ?", req.VideoId)
+	if req.Cursor != nil {
+		query = query.Where("id < ?", *req.Cursor)
+	}
+	// 多取一条用于判断是否还有下一页
+	var favorites []models.Favorite
+	if err = query.Order("id DESC").Limit(limit + 1).Find(&favorites).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"video_id": req.VideoId,
+			"err":      err,
+		}).Errorf("Failed to list the likers")
+		logging.SetSpanError(span, err)
+		resp = &favorite.ListVideoLikersResponse{
+			StatusCode: strings.FavoriteServiceErrorCode,
+			StatusMsg:  strings.FavoriteServiceError,
+		}
+		return
+	}
+
+	resp = &favorite.ListVideoLikersResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	if len(favorites) > limit {
+		favorites = favorites[:limit]
+		next := favorites[limit-1].ID
+		resp.NextCursor = &next
+	}
+	if len(favorites) == 0 {
+		return
+	}
+
+	userIds := make([]uint32, 0, len(favorites))
+	for _, f := range favorites {
+		userIds = append(userIds, f.UserId)
+	}
+	users, err := userClient.GetUserInfos(ctx, &user.UsersRequest{
+		UserIds: userIds,
+		ActorId: req.ActorId,
+	})
+	if err != nil || users.StatusCode != strings.ServiceOKCode {
+		logger.WithFields(logrus.Fields{
+			"user_ids": userIds,
+			"err":      err,
+		}).Errorf("User service error")
+		logging.SetSpanError(span, err)
+		resp = &favorite.ListVideoLikersResponse{
+			StatusCode: strings.UserServiceInnerErrorCode,
+			StatusMsg:  strings.UserServiceInnerError,
+		}
+		return
+	}
+	resp.UserList = users.Users
+	return
+}
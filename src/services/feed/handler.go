@@ -9,11 +9,11 @@ import (
 	"GuGoTik/src/rpc/favorite"
 	"GuGoTik/src/rpc/feed"
 	"GuGoTik/src/rpc/recommend"
+	"GuGoTik/src/rpc/relation"
 	"GuGoTik/src/rpc/user"
 	"GuGoTik/src/storage/cached"
-	"GuGoTik/src/storage/database"
 	"GuGoTik/src/storage/file"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/rabbitmq"
 	"context"
@@ -31,6 +31,7 @@ import (
 
 type FeedServiceImpl struct {
 	feed.FeedServiceServer
+	deps *deps.Container
 }
 
 const (
@@ -41,6 +42,7 @@ var UserClient user.UserServiceClient
 var CommentClient comment.CommentServiceClient
 var FavoriteClient favorite.FavoriteServiceClient
 var RecommendClient recommend.RecommendServiceClient
+var RelationClient relation.RelationServiceClient
 
 var conn *amqp.Connection
 
@@ -52,19 +54,22 @@ func exitOnError(err error) {
 	}
 }
 
-func (s FeedServiceImpl) New() {
-	userRpcConn := grpc2.Connect(config.UserRpcServerName)
+func (s *FeedServiceImpl) New(container *deps.Container) {
+	s.deps = container
+	userRpcConn := container.Dial(config.UserRpcServerName)
 	UserClient = user.NewUserServiceClient(userRpcConn)
-	commentRpcConn := grpc2.Connect(config.CommentRpcServerName)
+	commentRpcConn := container.Dial(config.CommentRpcServerName)
 	CommentClient = comment.NewCommentServiceClient(commentRpcConn)
-	favoriteRpcConn := grpc2.Connect(config.FavoriteRpcServerName)
+	favoriteRpcConn := container.Dial(config.FavoriteRpcServerName)
 	FavoriteClient = favorite.NewFavoriteServiceClient(favoriteRpcConn)
-	recommendRpcConn := grpc2.Connect(config.RecommendRpcServiceName)
+	recommendRpcConn := container.Dial(config.RecommendRpcServiceName)
 	RecommendClient = recommend.NewRecommendServiceClient(recommendRpcConn)
+	relationRpcConn := container.Dial(config.RelationRpcServerName)
+	RelationClient = relation.NewRelationServiceClient(relationRpcConn)
 
 	var err error
 
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
+	conn, err = container.DialMQ()
 	exitOnError(err)
 
 	channel, err = conn.Channel()
@@ -168,7 +173,7 @@ func (s FeedServiceImpl) ListVideosByRecommend(ctx context.Context, request *fee
 		return resp, err
 	}
 	recommendVideoId := recommendResponse.VideoList
-	find, err := findRecommendVideos(ctx, recommendVideoId)
+	find, err := s.findRecommendVideos(ctx, recommendVideoId)
 
 	nextTimeStamp := uint64(latestTime)
 	if err != nil {
@@ -199,7 +204,32 @@ func (s FeedServiceImpl) ListVideosByRecommend(ctx context.Context, request *fee
 	if request.ActorId != nil {
 		actorId = *request.ActorId
 	}
-	videos := queryDetailed(ctx, logger, actorId, find)
+	// 不展示与当前用户存在拉黑关系或被当前用户屏蔽的作者的视频
+	find, err = filterHidden(ctx, actorId, find)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"actorId": actorId,
+		}).Warnf("func filterHidden meet trouble.")
+		logging.SetSpanError(span, err)
+		resp = &feed.ListFeedResponse{
+			StatusCode: strings.FeedServiceInnerErrorCode,
+			StatusMsg:  strings.FeedServiceInnerError,
+			NextTime:   nil,
+			VideoList:  nil,
+		}
+		return resp, err
+	}
+	if len(find) == 0 {
+		resp = &feed.ListFeedResponse{
+			StatusCode: strings.ServiceOKCode,
+			StatusMsg:  strings.ServiceOK,
+			NextTime:   &nextTimeStamp,
+			VideoList:  nil,
+		}
+		return resp, nil
+	}
+	videos := s.queryDetailed(ctx, logger, actorId, find)
 	if videos == nil {
 		logger.WithFields(logrus.Fields{
 			"videos": videos,
@@ -259,7 +289,7 @@ func (s FeedServiceImpl) ListVideos(ctx context.Context, request *feed.ListFeedR
 		}
 	}
 
-	find, nextTime, err := findVideos(ctx, latestTime)
+	find, nextTime, err := s.findVideos(ctx, latestTime)
 	nextTimeStamp := uint64(nextTime.UnixMilli())
 	if err != nil {
 		logger.WithFields(logrus.Fields{
@@ -289,7 +319,32 @@ func (s FeedServiceImpl) ListVideos(ctx context.Context, request *feed.ListFeedR
 	if request.ActorId != nil {
 		actorId = *request.ActorId
 	}
-	videos := queryDetailed(ctx, logger, actorId, find)
+	// 不展示与当前用户存在拉黑关系或被当前用户屏蔽的作者的视频
+	find, err = filterHidden(ctx, actorId, find)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"actorId": actorId,
+		}).Warnf("func filterHidden meet trouble.")
+		logging.SetSpanError(span, err)
+		resp = &feed.ListFeedResponse{
+			StatusCode: strings.FeedServiceInnerErrorCode,
+			StatusMsg:  strings.FeedServiceInnerError,
+			NextTime:   nil,
+			VideoList:  nil,
+		}
+		return resp, err
+	}
+	if len(find) == 0 {
+		resp = &feed.ListFeedResponse{
+			StatusCode: strings.ServiceOKCode,
+			StatusMsg:  strings.ServiceOK,
+			NextTime:   &nextTimeStamp,
+			VideoList:  nil,
+		}
+		return resp, nil
+	}
+	videos := s.queryDetailed(ctx, logger, actorId, find)
 	if videos == nil {
 		logger.WithFields(logrus.Fields{
 			"videos": videos,
@@ -335,7 +390,7 @@ func (s FeedServiceImpl) QueryVideos(ctx context.Context, req *feed.QueryVideosR
 	logging.SetSpanWithHostname(span)
 	logger := logging.LogService("FeedService.QueryVideos").WithContext(ctx)
 
-	rst, err := query(ctx, logger, req.ActorId, req.VideoIds)
+	rst, err := s.query(ctx, logger, req.ActorId, req.VideoIds)
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"rst": rst,
@@ -364,17 +419,20 @@ func (s FeedServiceImpl) QueryVideoExisted(ctx context.Context, req *feed.VideoE
 	logger := logging.LogService("FeedService.QueryVideoExisted").WithContext(ctx)
 	var video models.Video
 	_, err = cached.GetWithFunc(ctx, fmt.Sprintf("VideoExistedCached-%d", req.VideoId), func(ctx context.Context, key string) (string, error) {
-		row := database.Client.WithContext(ctx).Where("id = ?", req.VideoId).First(&video)
+		row := s.deps.DB.WithContext(ctx).Where("id = ?", req.VideoId).First(&video)
+		if errors.Is(row.Error, gorm.ErrRecordNotFound) {
+			return "false", cached.ErrNotFound
+		}
 		if row.Error != nil {
 			return "false", row.Error
 		}
 		return "true", nil
-	})
+	}, cached.WithNegativeTTL(30*time.Second))
 	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
+		if errors.Is(err, cached.ErrNotFound) {
 			logger.WithFields(logrus.Fields{
 				"video_id": req.VideoId,
-			}).Warnf("gorm.ErrRecordNotFound")
+			}).Warnf("Video does not exist")
 			logging.SetSpanError(span, err)
 			resp = &feed.VideoExistResponse{
 				StatusCode: strings.ServiceOKCode,
@@ -435,7 +493,7 @@ func (s FeedServiceImpl) QueryVideoSummaryAndKeywords(ctx context.Context, req *
 	}
 
 	video := models.Video{}
-	result := database.Client.WithContext(ctx).Where("id = ?", req.VideoId).First(&video)
+	result := s.deps.DB.WithContext(ctx).Where("id = ?", req.VideoId).First(&video)
 	if result.Error != nil {
 		logger.WithFields(logrus.Fields{
 			"VideoId": req.VideoId,
@@ -459,13 +517,13 @@ func (s FeedServiceImpl) QueryVideoSummaryAndKeywords(ctx context.Context, req *
 	return
 }
 
-func findVideos(ctx context.Context, latestTime int64) ([]*models.Video, time.Time, error) {
+func (s FeedServiceImpl) findVideos(ctx context.Context, latestTime int64) ([]*models.Video, time.Time, error) {
 	logger := logging.LogService("ListVideos.findVideos").WithContext(ctx)
 
 	nextTime := time.UnixMilli(latestTime)
 
 	var videos []*models.Video
-	result := database.Client.Where("created_at < ?", nextTime).
+	result := s.deps.DB.Where("created_at < ?", nextTime).
 		Order("created_at DESC").
 		Limit(VideoCount).
 		Find(&videos)
@@ -489,14 +547,14 @@ func findVideos(ctx context.Context, latestTime int64) ([]*models.Video, time.Ti
 	return videos, nextTime, nil
 }
 
-func findRecommendVideos(ctx context.Context, recommendVideoId []uint32) ([]*models.Video, error) {
+func (s FeedServiceImpl) findRecommendVideos(ctx context.Context, recommendVideoId []uint32) ([]*models.Video, error) {
 	logger := logging.LogService("ListVideos.findVideos").WithContext(ctx)
 	var videos []*models.Video
 	var ids []interface{}
 	for _, id := range recommendVideoId {
 		ids = append(ids, id)
 	}
-	result := database.Client.WithContext(ctx).Where("id IN ?", ids).Find(&videos)
+	result := s.deps.DB.WithContext(ctx).Where("id IN ?", ids).Find(&videos)
 
 	if result.Error != nil {
 		logger.WithFields(logrus.Fields{
@@ -508,8 +566,37 @@ func findRecommendVideos(ctx context.Context, recommendVideoId []uint32) ([]*mod
 	return videos, nil
 }
 
+// filterHidden 过滤掉与 actorId 存在拉黑关系或被 actorId 屏蔽的作者的视频，未登录时不过滤
+func filterHidden(ctx context.Context, actorId uint32, videos []*models.Video) ([]*models.Video, error) {
+	if actorId == 0 {
+		return videos, nil
+	}
+	resp, err := RelationClient.GetHiddenUsers(ctx, &relation.HiddenUsersRequest{ActorId: actorId})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != strings.ServiceOKCode {
+		return nil, fmt.Errorf("query hidden users failed: %s", resp.StatusMsg)
+	}
+
+	hidden := make(map[uint32]bool, len(resp.BlockedIds)+len(resp.MutedIds))
+	for _, id := range resp.BlockedIds {
+		hidden[id] = true
+	}
+	for _, id := range resp.MutedIds {
+		hidden[id] = true
+	}
+	kept := make([]*models.Video, 0, len(videos))
+	for _, video := range videos {
+		if !hidden[video.UserId] {
+			kept = append(kept, video)
+		}
+	}
+	return kept, nil
+}
+
 // 查询videoIds中的详细视频信息，返回视频列表，包括视频相关url，评论点赞数量，是否点赞
-func queryDetailed(ctx context.Context, logger *logrus.Entry, actorId uint32, videos []*models.Video) (respVideoList []*feed.Video) {
+func (s FeedServiceImpl) queryDetailed(ctx context.Context, logger *logrus.Entry, actorId uint32, videos []*models.Video) (respVideoList []*feed.Video) {
 	ctx, span := tracing.Tracer.Start(ctx, "queryDetailed")
 	defer span.End()
 	logging.SetSpanWithHostname(span)
@@ -525,39 +612,74 @@ func queryDetailed(ctx context.Context, logger *logrus.Entry, actorId uint32, vi
 		}
 	}
 
-	// 将User信息存入map，防止重复查询
+	// 通过一次批量查询获取所有作者的信息
 	userMap := make(map[uint32]*user.User)
+	userIds := make([]uint32, 0, len(videos))
 	for _, video := range videos {
-		userMap[video.UserId] = &user.User{}
+		userIds = append(userIds, video.UserId)
 	}
 
 	userWg := sync.WaitGroup{}
-	userWg.Add(len(userMap))
-	for userId := range userMap {
-		go func(userId uint32) {
-			defer userWg.Done()
-			userResponse, localErr := UserClient.GetUserInfo(ctx, &user.UserRequest{
-				UserId:  userId,
-				ActorId: actorId,
-			})
-			if localErr != nil || userResponse.StatusCode != strings.ServiceOKCode {
-				logger.WithFields(logrus.Fields{
-					"UserId": userId,
-					"cause":  localErr,
-				}).Warning("failed to get user info")
-				logging.SetSpanError(span, localErr)
+	userWg.Add(1)
+	go func() {
+		defer userWg.Done()
+		usersResponse, localErr := UserClient.GetUserInfos(ctx, &user.UsersRequest{
+			UserIds: userIds,
+			ActorId: actorId,
+		})
+		if localErr != nil || usersResponse.StatusCode != strings.ServiceOKCode {
+			logger.WithFields(logrus.Fields{
+				"UserIds": userIds,
+				"cause":   localErr,
+			}).Warning("failed to get user infos")
+			logging.SetSpanError(span, localErr)
+			return
+		}
+		for _, u := range usersResponse.Users {
+			userMap[u.Id] = u
+		}
+	}()
+
+	// 通过一次批量查询获取所有视频的点赞数以及当前用户的点赞状态
+	userWg.Add(1)
+	go func() {
+		defer userWg.Done()
+		videoIds := make([]uint32, 0, len(videos))
+		for _, video := range videos {
+			videoIds = append(videoIds, video.ID)
+		}
+		statsResponse, localErr := FavoriteClient.BatchVideoStats(ctx, &favorite.BatchVideoStatsRequest{
+			ActorId:  actorId,
+			VideoIds: videoIds,
+		})
+		if localErr != nil || statsResponse.StatusCode != strings.ServiceOKCode {
+			logger.WithFields(logrus.Fields{
+				"VideoIds": videoIds,
+				"cause":    localErr,
+			}).Warning("failed to fetch favorite stats")
+			logging.SetSpanError(span, localErr)
+			return
+		}
+		stats := make(map[uint32]*favorite.VideoStats, len(statsResponse.Stats))
+		for _, s := range statsResponse.Stats {
+			stats[s.VideoId] = s
+		}
+		for _, respVideo := range respVideoList {
+			if s, ok := stats[respVideo.Id]; ok {
+				respVideo.FavoriteCount = s.FavoriteCount
+				respVideo.IsFavorite = s.IsFavorite
+				respVideo.Reaction = uint32(s.Reaction)
 			}
-			userMap[userId] = userResponse.User
-		}(userId)
-	}
+		}
+	}()
 
 	wg := sync.WaitGroup{}
 	for i, v := range videos {
-		wg.Add(4)
+		wg.Add(3)
 		// a. 填充视频播放url
 		go func(i int, v *models.Video) {
 			defer wg.Done()
-			playUrl, localErr := file.GetLink(ctx, v.FileName, v.UserId)
+			playUrl, localErr := file.GetUserLink(ctx, s.deps.Storage, v.FileName, v.UserId)
 			if localErr != nil {
 				logger.WithFields(logrus.Fields{
 					"video_id":  v.ID,
@@ -573,7 +695,7 @@ func queryDetailed(ctx context.Context, logger *logrus.Entry, actorId uint32, vi
 		// b. 填充视频封面url
 		go func(i int, v *models.Video) {
 			defer wg.Done()
-			coverUrl, localErr := file.GetLink(ctx, v.CoverName, v.UserId)
+			coverUrl, localErr := file.GetUserLink(ctx, s.deps.Storage, v.CoverName, v.UserId)
 			if localErr != nil {
 				logger.WithFields(logrus.Fields{
 					"video_id":   v.ID,
@@ -586,24 +708,7 @@ func queryDetailed(ctx context.Context, logger *logrus.Entry, actorId uint32, vi
 			respVideoList[i].CoverUrl = coverUrl
 		}(i, v)
 
-		// c. 填充视频点赞数量
-		go func(i int, v *models.Video) {
-			defer wg.Done()
-			favoriteCount, localErr := FavoriteClient.CountFavorite(ctx, &favorite.CountFavoriteRequest{
-				VideoId: v.ID,
-			})
-			if localErr != nil {
-				logger.WithFields(logrus.Fields{
-					"video_id": v.ID,
-					"err":      localErr,
-				}).Warning("failed to fetch favorite count")
-				logging.SetSpanError(span, localErr)
-				return
-			}
-			respVideoList[i].FavoriteCount = favoriteCount.Count
-		}(i, v)
-
-		// d. 填充视频评论数量
+		// c. 填充视频评论数量
 		go func(i int, v *models.Video) {
 			defer wg.Done()
 			commentCount, localErr := CommentClient.CountComment(ctx, &comment.CountCommentRequest{
@@ -620,29 +725,6 @@ func queryDetailed(ctx context.Context, logger *logrus.Entry, actorId uint32, vi
 			}
 			respVideoList[i].CommentCount = commentCount.CommentCount
 		}(i, v)
-
-		// e. 填充用户是否点赞该视频
-		if actorId != 0 {
-			wg.Add(1)
-			go func(i int, v *models.Video) {
-				defer wg.Done()
-				isFavorite, localErr := FavoriteClient.IsFavorite(ctx, &favorite.IsFavoriteRequest{
-					ActorId: actorId,
-					VideoId: v.ID,
-				})
-				if localErr != nil {
-					logger.WithFields(logrus.Fields{
-						"video_id": v.ID,
-						"err":      localErr,
-					}).Warning("failed to fetch favorite status")
-					logging.SetSpanError(span, localErr)
-					return
-				}
-				respVideoList[i].IsFavorite = isFavorite.Result
-			}(i, v)
-		} else {
-			respVideoList[i].IsFavorite = false
-		}
 	}
 	userWg.Wait()
 	wg.Wait()
@@ -656,14 +738,14 @@ func queryDetailed(ctx context.Context, logger *logrus.Entry, actorId uint32, vi
 }
 
 // 查询videoIds中的详细视频信息，返回视频列表，包括视频相关url，评论点赞数量，是否点赞
-func query(ctx context.Context, logger *logrus.Entry, actorId uint32, videoIds []uint32) (resp []*feed.Video, err error) {
+func (s FeedServiceImpl) query(ctx context.Context, logger *logrus.Entry, actorId uint32, videoIds []uint32) (resp []*feed.Video, err error) {
 	var videos []*models.Video
 	//Gorm的操作，以后不需要在单独开span，通过传ctx的方式完成 "WithContext(ctx)"，如果在函数需要这样写，但是这个的目的是为了获取子 Span 的 ctx
-	err = database.Client.WithContext(ctx).Where("Id IN ?", videoIds).Find(&videos).Error
+	err = s.deps.DB.WithContext(ctx).Where("Id IN ?", videoIds).Find(&videos).Error
 	if err != nil {
 		return nil, err
 	}
-	return queryDetailed(ctx, logger, actorId, videos), nil
+	return s.queryDetailed(ctx, logger, actorId, videos), nil
 }
 
 // 检查时间戳是否合法并转为日期类型
@@ -6,6 +6,7 @@ import (
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/feed"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
@@ -24,6 +25,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.FeedRpcServerName)
 
 	if err != nil {
@@ -69,13 +72,13 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.FeedRpcServerName, config.FeedRpcServerPort)
 
 	var srv FeedServiceImpl
+	srv.New(container)
 	feed.RegisterFeedServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
 	defer CloseMQConn()
 	if err := consul.RegisterConsul(config.FeedRpcServerName, config.FeedRpcServerPort); err != nil {
 		log.Panicf("Rpc %s register consul happens error for: %v", config.FeedRpcServerName, err)
 	}
-	srv.New()
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
@@ -10,10 +10,10 @@ import (
 	"GuGoTik/src/rpc/recommend"
 	"GuGoTik/src/rpc/relation"
 	"GuGoTik/src/rpc/user"
-	"GuGoTik/src/storage/database"
-	"GuGoTik/src/storage/redis"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/mention"
+	"GuGoTik/src/utils/moderation"
 	"GuGoTik/src/utils/ptr"
 	"GuGoTik/src/utils/rabbitmq"
 	"context"
@@ -39,8 +39,12 @@ var chatClient chat.ChatServiceClient
 
 type MessageServiceImpl struct {
 	chat.ChatServiceServer
+	deps *deps.Container
 }
 
+// localModerator 发送消息前执行的本地审核链
+var localModerator moderation.Moderator
+
 // 连接
 var conn *amqp.Connection
 var channel *amqp.Channel
@@ -55,10 +59,16 @@ func failOnError(err error, msg string) {
 	}
 }
 
-func (c MessageServiceImpl) New() {
+func (c *MessageServiceImpl) New(container *deps.Container) {
+	c.deps = container
 	var err error
 
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
+	localModerator, err = moderation.NewLocalChain()
+	if err != nil {
+		panic(err)
+	}
+
+	conn, err = container.DialMQ()
 	failOnError(err, "Failed to connect to RabbitMQ")
 
 	channel, err = conn.Channel()
@@ -122,19 +132,19 @@ func (c MessageServiceImpl) New() {
 	)
 	failOnError(err, "Failed to bind queue to exchange")
 
-	userRpcConn := grpc2.Connect(config.UserRpcServerName)
+	userRpcConn := container.Dial(config.UserRpcServerName)
 	userClient = user.NewUserServiceClient(userRpcConn)
 
-	recommendRpcConn := grpc2.Connect(config.RecommendRpcServiceName)
+	recommendRpcConn := container.Dial(config.RecommendRpcServiceName)
 	recommendClient = recommend.NewRecommendServiceClient(recommendRpcConn)
 
-	relationRpcConn := grpc2.Connect(config.RelationRpcServerName)
+	relationRpcConn := container.Dial(config.RelationRpcServerName)
 	relationClient = relation.NewRelationServiceClient(relationRpcConn)
 
-	feedRpcConn := grpc2.Connect(config.FeedRpcServerName)
+	feedRpcConn := container.Dial(config.FeedRpcServerName)
 	feedClient = feed.NewFeedServiceClient(feedRpcConn)
 
-	chatRpcConn := grpc2.Connect(config.MessageRpcServerName)
+	chatRpcConn := container.Dial(config.MessageRpcServerName)
 	chatClient = chat.NewChatServiceClient(chatRpcConn)
 
 	cronRunner := cron.New(cron.WithSeconds())
@@ -185,7 +195,7 @@ func (c MessageServiceImpl) ChatAction(ctx context.Context, request *chat.Action
 	}).Debugf("Process start")
 
 	// Rate limiting
-	limiter := redis_rate.NewLimiter(redis.Client)
+	limiter := redis_rate.NewLimiter(c.deps.Redis)
 	limiterKey := chatActionLimitKey(request.ActorId)
 	limiterRes, err := limiter.Allow(ctx, limiterKey, redis_rate.PerSecond(chatActionMaxQPS))
 	if err != nil {
@@ -217,6 +227,30 @@ func (c MessageServiceImpl) ChatAction(ctx context.Context, request *chat.Action
 		return
 	}
 
+	// 封禁期间不能发布内容
+	until, banned, err := moderation.BannedUntil(ctx, c.deps.DB, request.ActorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to query the ban of the user")
+		logging.SetSpanError(span, err)
+		return &chat.ActionResponse{
+			StatusCode: strings.UnableToAddMessageErrorCode,
+			StatusMsg:  strings.UnableToAddMessageError,
+		}, err
+	}
+	if banned {
+		logger.WithFields(logrus.Fields{
+			"ActorId": request.ActorId,
+			"until":   until,
+		}).Infof("Banned user tried to send a message")
+		return &chat.ActionResponse{
+			StatusCode: strings.UserBannedCode,
+			StatusMsg:  strings.UserBanned,
+		}, nil
+	}
+
 	userResponse, err := userClient.GetUserExistInformation(ctx, &user.UserExistRequest{
 		UserId: request.UserId,
 	})
@@ -244,7 +278,61 @@ func (c MessageServiceImpl) ChatAction(ctx context.Context, request *chat.Action
 		}, nil
 	}
 
-	res, err = addMessage(ctx, request.ActorId, request.UserId, request.Content)
+	// 任意一方拉黑了另一方时不能发送消息
+	blockResponse, err := relationClient.IsBlocked(ctx, &relation.IsBlockedRequest{
+		ActorId: request.ActorId,
+		UserId:  request.UserId,
+	})
+	if err != nil || blockResponse.StatusCode != strings.ServiceOKCode {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"user_id": request.UserId,
+		}).Errorf("Relation service error")
+		logging.SetSpanError(span, err)
+
+		return &chat.ActionResponse{
+			StatusCode: strings.UnableToAddMessageErrorCode,
+			StatusMsg:  strings.UnableToAddMessageError,
+		}, err
+	}
+	if blockResponse.Result {
+		return &chat.ActionResponse{
+			StatusCode: strings.UserBlockedCode,
+			StatusMsg:  strings.UserBlocked,
+		}, nil
+	}
+
+	// 被本地审核拒绝的消息不能发送，需要人工复核的消息照常发送，由审核员决定是否删除
+	verdict, _ := localModerator.Moderate(ctx, request.Content)
+	if verdict.Decision == moderation.Reject {
+		logger.WithFields(logrus.Fields{
+			"ActorId": request.ActorId,
+			"user_id": request.UserId,
+			"reason":  verdict.Reason,
+		}).Infof("Message rejected by moderation")
+		return &chat.ActionResponse{
+			StatusCode: strings.ContentRejectedCode,
+			StatusMsg:  strings.ContentRejected,
+		}, nil
+	}
+	reviewReason := ""
+	if verdict.Decision != moderation.Allow {
+		reviewReason = verdict.Reason
+	}
+
+	// 解析消息中的 @，查询失败时按普通文本发送
+	mentions, mentionErr := mention.Resolve(ctx, userClient, relationClient, models.MentionInMessage, request.ActorId, request.Content)
+	if mentionErr != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     mentionErr,
+			"ActorId": request.ActorId,
+			"user_id": request.UserId,
+		}).Warnf("Failed to resolve the mentions of the message")
+		mentions = nil
+	}
+
+	res, err = c.addMessage(ctx, request.ActorId, request.UserId, request.Content, mentions, reviewReason)
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"err":          err,
@@ -315,12 +403,12 @@ func (c MessageServiceImpl) Chat(ctx context.Context, request *chat.ChatRequest)
 	var pMessageList []models.Message
 	var result *gorm.DB
 	if request.PreMsgTime == 0 {
-		result = database.Client.WithContext(ctx).
+		result = c.deps.DB.WithContext(ctx).
 			Where("conversation_id=?", conversationId).
 			Order("created_at").
 			Find(&pMessageList)
 	} else {
-		result = database.Client.WithContext(ctx).
+		result = c.deps.DB.WithContext(ctx).
 			Where("conversation_id=?", conversationId).
 			Where("created_at > ?", time.UnixMilli(int64(request.PreMsgTime)).Add(100*time.Millisecond)).
 			Order("created_at").
@@ -382,7 +470,7 @@ func (c MessageServiceImpl) Chat(ctx context.Context, request *chat.ChatRequest)
 	return
 }
 
-func addMessage(ctx context.Context, fromUserId uint32, toUserId uint32, Context string) (resp *chat.ActionResponse, err error) {
+func (c MessageServiceImpl) addMessage(ctx context.Context, fromUserId uint32, toUserId uint32, Context string, mentions []models.Mention, reviewReason string) (resp *chat.ActionResponse, err error) {
 	conversationId := fmt.Sprintf("%d_%d", toUserId, fromUserId)
 
 	if toUserId > fromUserId {
@@ -393,6 +481,8 @@ func addMessage(ctx context.Context, fromUserId uint32, toUserId uint32, Context
 		FromUserId:     fromUserId,
 		Content:        Context,
 		ConversationId: conversationId,
+		Mentions:       mentions,
+		ReviewReason:   reviewReason,
 	}
 	message.Model = gorm.Model{
 		CreatedAt: time.Now(),
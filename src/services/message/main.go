@@ -6,6 +6,7 @@ import (
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/chat"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
@@ -25,6 +26,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew(deps.WithES())
+
 	tp, err := tracing.SetTraceProvider(config.MessageRpcServerName)
 
 	if err != nil {
@@ -71,10 +74,10 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.MessageRpcServerName, config.MessageRpcServerPort)
 
 	var srv MessageServiceImpl
+	srv.New(container)
 	chat.RegisterChatServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
 	defer CloseMQConn()
-	srv.New()
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/storage/database/migrate"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+func usage() {
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [up|down|status]\n\n", os.Args[0])
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "  up      apply all pending migrations (default)\n")
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "  down    roll back the latest applied migrations\n")
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "  status  print the state of every migration\n\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	steps := flag.Int("steps", 1, "number of migrations to roll back with down")
+	timeout := flag.Duration("timeout", 10*time.Minute, "give up if the migrations do not finish within this duration")
+	flag.Usage = usage
+	flag.Parse()
+
+	command := "up"
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+	}
+
+	log := logging.LogService(config.Migrate)
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+
+	db, err := database.Open()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"err": err,
+		}).Fatalf("Cannot connect to the database")
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"err": err,
+		}).Fatalf("Cannot get the database connection")
+	}
+
+	migrator, err := migrate.New(sqlDB)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"err": err,
+		}).Fatalf("Cannot load migrations")
+	}
+
+	switch command {
+	case "up":
+		done, err := migrator.Up(ctx)
+		for _, migration := range done {
+			log.WithFields(logrus.Fields{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Infof("Migration applied")
+		}
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"err": err,
+			}).Fatalf("Failed to apply migrations")
+		}
+		log.WithFields(logrus.Fields{
+			"applied": len(done),
+			"version": migrator.Latest(),
+		}).Infof("Database schema is up to date")
+	case "down":
+		done, err := migrator.Down(ctx, *steps)
+		for _, migration := range done {
+			log.WithFields(logrus.Fields{
+				"version": migration.Version,
+				"name":    migration.Name,
+			}).Infof("Migration rolled back")
+		}
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"err": err,
+			}).Fatalf("Failed to roll back migrations")
+		}
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"err": err,
+			}).Fatalf("Failed to query migration status")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			_, _ = fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		_ = w.Flush()
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
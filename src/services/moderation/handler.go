@@ -0,0 +1,528 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/moderation"
+	"GuGoTik/src/storage/cached"
+	"GuGoTik/src/utils/audit"
+	"GuGoTik/src/utils/content"
+	"GuGoTik/src/utils/deps"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/mention"
+	moderation2 "GuGoTik/src/utils/moderation"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 50
+)
+
+// permanentBan 永久封禁使用的截止时间
+var permanentBan = time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+
+// errReviewNotFound 审核记录不存在
+var errReviewNotFound = errors.New("review not found")
+
+// errReviewHandled 审核记录已经被其他审核员处理
+var errReviewHandled = errors.New("review already handled")
+
+type ModerationServiceImpl struct {
+	moderation.ModerationServiceServer
+	deps *deps.Container
+}
+
+func (s *ModerationServiceImpl) New(container *deps.Container) {
+	s.deps = container
+}
+
+// isModerator 查询 actorId 是否为审核员，角色直接读数据库，避免缓存中的旧角色在撤销权限后仍然生效
+func (s ModerationServiceImpl) isModerator(ctx context.Context, actorId uint32) (bool, error) {
+	if actorId == 0 {
+		return false, nil
+	}
+	var user models.User
+	err := s.deps.DB.WithContext(ctx).Select("role").Where("id = ?", actorId).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return user.Role == models.UserRoleModerator, nil
+}
+
+func normalizeLimit(limit uint32) int {
+	if limit == 0 {
+		return defaultListLimit
+	}
+	if limit > maxListLimit {
+		return maxListLimit
+	}
+	return int(limit)
+}
+
+func validItemType(t moderation.ItemType) bool {
+	switch t {
+	case moderation.ItemType_ITEM_TYPE_UNSPECIFIED,
+		moderation.ItemType_ITEM_TYPE_COMMENT,
+		moderation.ItemType_ITEM_TYPE_VIDEO,
+		moderation.ItemType_ITEM_TYPE_MESSAGE:
+		return true
+	}
+	return false
+}
+
+func itemTypeName(t uint32) string {
+	switch t {
+	case models.ModerationItemComment:
+		return "comment"
+	case models.ModerationItemVideo:
+		return "video"
+	case models.ModerationItemMessage:
+		return "message"
+	}
+	return "unknown"
+}
+
+func moderationAuditAction(ctx context.Context, actorId uint32, subName string, attached string, affectUserId uint32, videoId uint32, reason string) *models.Action {
+	return &models.Action{
+		Type:         strings.ModerationIdActionLog,
+		Name:         strings.ModerationNameActionLog,
+		SubName:      subName,
+		ServiceName:  strings.ModerationServiceName,
+		Attached:     attached,
+		ActorId:      actorId,
+		VideoId:      videoId,
+		AffectUserId: affectUserId,
+		AffectAction: 2,
+		AffectedData: reason,
+		EventId:      uuid.New().String(),
+		TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
+		SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
+	}
+}
+
+func toRpcReview(r *models.ModerationReview) *moderation.ReviewItem {
+	return &moderation.ReviewItem{
+		Id:        r.ID,
+		Type:      moderation.ItemType(r.ItemType),
+		ItemId:    r.ItemId,
+		AuthorId:  r.AuthorId,
+		Content:   r.Content,
+		Reason:    r.Reason,
+		Status:    moderation.ReviewStatus(r.Status),
+		CreatedAt: r.CreatedAt.Unix(),
+	}
+}
+
+// applyReview 把审核结果同步到被审核的内容上，返回评论所在的视频，用于事务提交后清理评论数缓存。
+// 暂扣的评论发布时没有通知被 @ 的用户，通过复核时在同一个事务中补发
+func applyReview(ctx context.Context, tx *gorm.DB, review *models.ModerationReview, approved bool) (videoId uint32, err error) {
+	switch review.ItemType {
+	case models.ModerationItemComment:
+		var comment models.Comment
+		if err = tx.Select("id", "video_id").Where("id = ?", review.ItemId).Take(&comment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				// 评论已经被作者删除，只记录审核结果
+				return 0, nil
+			}
+			return
+		}
+		updates := map[string]any{"moderation_decision": uint32(moderation2.Reject)}
+		if approved {
+			updates = map[string]any{"moderation_decision": uint32(moderation2.Allow), "moderation_flagged": false}
+		}
+		if err = tx.Model(&comment).Updates(updates).Error; err != nil || !approved {
+			return comment.VideoId, err
+		}
+
+		var mentions map[uint32][]models.Mention
+		if mentions, err = mention.Load(ctx, tx, models.MentionInComment, []uint32{comment.ID}); err != nil {
+			return
+		}
+		err = mention.Notify(ctx, tx, comment.ID, comment.VideoId, mentions[comment.ID])
+		return comment.VideoId, err
+	case models.ModerationItemVideo:
+		if !approved {
+			if err = tx.Where("id = ?", review.ItemId).Delete(&models.Video{}).Error; err != nil {
+				return
+			}
+			// 被拒绝的视频不再引用原始视频的内容，没有其他引用时由存储回收器删除
+			_, err = content.ReleaseRawVideo(tx, review.ItemId)
+		}
+	case models.ModerationItemMessage:
+		if !approved {
+			err = tx.Where("id = ?", review.ItemId).Delete(&models.Message{}).Error
+		}
+	}
+	return
+}
+
+// handleReview 处理一条待审核内容，只有仍处于待审核状态的记录可以被处理，避免两个审核员重复处理
+func (s ModerationServiceImpl) handleReview(ctx context.Context, actorId uint32, reviewId uint32, approved bool, reason string) (videoId uint32, err error) {
+	status, subName := models.ModerationReviewRejected, strings.ModerationRejectActionSubLog
+	if approved {
+		status, subName = models.ModerationReviewApproved, strings.ModerationApproveActionSubLog
+	}
+
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var review models.ModerationReview
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", reviewId).Take(&review).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errReviewNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if review.Status != models.ModerationReviewPending {
+			return errReviewHandled
+		}
+
+		now := time.Now()
+		if err := tx.Model(&review).Updates(map[string]any{
+			"status":        status,
+			"reviewer_id":   actorId,
+			"review_reason": reason,
+			"reviewed_at":   &now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if videoId, err = applyReview(ctx, tx, &review, approved); err != nil {
+			return err
+		}
+
+		attachedVideoId := uint32(0)
+		if review.ItemType == models.ModerationItemVideo {
+			attachedVideoId = review.ItemId
+		} else if review.ItemType == models.ModerationItemComment {
+			attachedVideoId = videoId
+		}
+		return audit.EnqueueAuditEvent(ctx, tx, moderationAuditAction(ctx, actorId, subName,
+			fmt.Sprintf("%s:%d", itemTypeName(review.ItemType), review.ItemId), review.AuthorId, attachedVideoId, reason))
+	})
+	return
+}
+
+func (s ModerationServiceImpl) ListPending(ctx context.Context, request *moderation.ListPendingRequest) (resp *moderation.ListPendingResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListPendingService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("ModerationService.ListPending").WithContext(ctx)
+
+	ok, err := s.isModerator(ctx, request.ActorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to query the role of the actor")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ListPendingResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+	if !ok {
+		resp = &moderation.ListPendingResponse{
+			StatusCode: strings.ModerationForbiddenCode,
+			StatusMsg:  strings.ModerationForbidden,
+		}
+		return
+	}
+	if !validItemType(request.Type) {
+		resp = &moderation.ListPendingResponse{
+			StatusCode: strings.ModerationItemTypeInvalidCode,
+			StatusMsg:  strings.ModerationItemTypeInvalid,
+		}
+		return
+	}
+
+	limit := normalizeLimit(request.Limit)
+	query := s.deps.DB.WithContext(ctx).Where("status = ?", models.ModerationReviewPending)
+	if request.Type != moderation.ItemType_ITEM_TYPE_UNSPECIFIED {
+		query = query.Where("item_type = ?", uint32(request.Type))
+	}
+	if request.Cursor != nil {
+		query = query.Where("id > ?", *request.Cursor)
+	}
+
+	var reviews []models.ModerationReview
+	// 多取一条判断是否还有下一页
+	if err = query.Order("id").Limit(limit + 1).Find(&reviews).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"type":    request.Type,
+		}).Errorf("Failed to list the pending reviews")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ListPendingResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+
+	resp = &moderation.ListPendingResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	if len(reviews) > limit {
+		reviews = reviews[:limit]
+		next := reviews[limit-1].ID
+		resp.NextCursor = &next
+	}
+	for i := range reviews {
+		resp.Items = append(resp.Items, toRpcReview(&reviews[i]))
+	}
+	return
+}
+
+func (s ModerationServiceImpl) Approve(ctx context.Context, request *moderation.ApproveRequest) (resp *moderation.ModerationActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ApproveService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("ModerationService.Approve").WithContext(ctx)
+
+	return s.review(ctx, span, logger, request.ActorId, request.ReviewId, true, "")
+}
+
+func (s ModerationServiceImpl) Reject(ctx context.Context, request *moderation.RejectRequest) (resp *moderation.ModerationActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "RejectService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("ModerationService.Reject").WithContext(ctx)
+
+	return s.review(ctx, span, logger, request.ActorId, request.ReviewId, false, request.Reason)
+}
+
+// review Approve 与 Reject 的公共流程
+func (s ModerationServiceImpl) review(ctx context.Context, span trace.Span, logger *logrus.Entry, actorId uint32, reviewId uint32, approved bool, reason string) (resp *moderation.ModerationActionResponse, err error) {
+	ok, err := s.isModerator(ctx, actorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": actorId,
+		}).Errorf("Failed to query the role of the actor")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+	if !ok {
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationForbiddenCode,
+			StatusMsg:  strings.ModerationForbidden,
+		}
+		return
+	}
+
+	videoId, err := s.handleReview(ctx, actorId, reviewId, approved, reason)
+	switch {
+	case errors.Is(err, errReviewNotFound):
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationReviewNotFoundCode,
+			StatusMsg:  strings.ModerationReviewNotFound,
+		}
+		return resp, nil
+	case errors.Is(err, errReviewHandled):
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationReviewHandledCode,
+			StatusMsg:  strings.ModerationReviewHandled,
+		}
+		return resp, nil
+	case err != nil:
+		logger.WithFields(logrus.Fields{
+			"err":      err,
+			"ActorId":  actorId,
+			"ReviewId": reviewId,
+			"approved": approved,
+		}).Errorf("Failed to handle the review")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+
+	if videoId != 0 {
+		// 评论的可见性发生变化，评论数需要重新统计
+		cached.TagDelete(ctx, fmt.Sprintf("CommentCount-%d", videoId))
+	}
+
+	resp = &moderation.ModerationActionResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
+
+func (s ModerationServiceImpl) BanUser(ctx context.Context, request *moderation.BanUserRequest) (resp *moderation.ModerationActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "BanUserService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("ModerationService.BanUser").WithContext(ctx)
+
+	ok, err := s.isModerator(ctx, request.ActorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to query the role of the actor")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+	if !ok {
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationForbiddenCode,
+			StatusMsg:  strings.ModerationForbidden,
+		}
+		return
+	}
+
+	until := permanentBan
+	if request.DurationHours != 0 {
+		until = time.Now().Add(time.Duration(request.DurationHours) * time.Hour)
+	}
+
+	var existed bool
+	err = s.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("id = ?", request.UserId).Count(&count).Error; err != nil {
+			return err
+		}
+		if existed = count > 0; !existed {
+			return nil
+		}
+		if err := tx.Create(&models.UserBan{
+			UserId:      request.UserId,
+			ModeratorId: request.ActorId,
+			Reason:      request.Reason,
+			Until:       until,
+		}).Error; err != nil {
+			return err
+		}
+		return audit.EnqueueAuditEvent(ctx, tx, moderationAuditAction(ctx, request.ActorId, strings.ModerationBanActionSubLog,
+			fmt.Sprintf("user:%d", request.UserId), request.UserId, 0, request.Reason))
+	})
+
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to ban the user")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+	if !existed {
+		resp = &moderation.ModerationActionResponse{
+			StatusCode: strings.UserDoNotExistedCode,
+			StatusMsg:  strings.UserNotExisted,
+		}
+		return
+	}
+
+	resp = &moderation.ModerationActionResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
+
+func (s ModerationServiceImpl) ListHistory(ctx context.Context, request *moderation.ListHistoryRequest) (resp *moderation.ListHistoryResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListHistoryService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("ModerationService.ListHistory").WithContext(ctx)
+
+	ok, err := s.isModerator(ctx, request.ActorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to query the role of the actor")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ListHistoryResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+	if !ok {
+		resp = &moderation.ListHistoryResponse{
+			StatusCode: strings.ModerationForbiddenCode,
+			StatusMsg:  strings.ModerationForbidden,
+		}
+		return
+	}
+
+	limit := normalizeLimit(request.Limit)
+	query := s.deps.DB.WithContext(ctx).Where("type = ?", strings.ModerationIdActionLog)
+	if request.UserId != nil {
+		query = query.Where("affect_user_id = ?", *request.UserId)
+	}
+	if request.Cursor != nil {
+		query = query.Where("id < ?", *request.Cursor)
+	}
+
+	var actions []models.Action
+	// 审计记录由 MsgConsumer 异步写入，刚执行的操作可能稍后才出现在历史中
+	if err = query.Order("id DESC").Limit(limit + 1).Find(&actions).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to list the moderation history")
+		logging.SetSpanError(span, err)
+		resp = &moderation.ListHistoryResponse{
+			StatusCode: strings.ModerationServiceInnerErrorCode,
+			StatusMsg:  strings.ModerationServiceInnerError,
+		}
+		return
+	}
+
+	resp = &moderation.ListHistoryResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	if len(actions) > limit {
+		actions = actions[:limit]
+		next := uint64(actions[limit-1].ID)
+		resp.NextCursor = &next
+	}
+	for _, action := range actions {
+		resp.Actions = append(resp.Actions, &moderation.ModerationAction{
+			Id:           uint64(action.ID),
+			ModeratorId:  action.ActorId,
+			Action:       action.SubName,
+			Target:       action.Attached,
+			AffectUserId: action.AffectUserId,
+			Reason:       action.AffectedData,
+			CreatedAt:    action.CreatedAt.Unix(),
+		})
+	}
+	return
+}
@@ -7,8 +7,10 @@ import (
 	"GuGoTik/src/models"
 	"GuGoTik/src/rpc/chat"
 	"GuGoTik/src/storage/database"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/mention"
+	"GuGoTik/src/utils/moderation"
 	"GuGoTik/src/utils/rabbitmq"
 	"context"
 	"encoding/json"
@@ -22,6 +24,7 @@ import (
 	"github.com/sashabaranov/go-openai"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
 )
 
 var chatClient chat.ChatServiceClient
@@ -67,11 +70,13 @@ func CloseMQConn() {
 }
 
 func main() {
-	chatRpcConn := grpc2.Connect(config.MessageRpcServerName)
+	container := deps.MustNew()
+
+	chatRpcConn := container.Dial(config.MessageRpcServerName)
 	chatClient = chat.NewChatServiceClient(chatRpcConn)
 
 	var err error
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
+	conn, err = container.DialMQ()
 	failOnError(err, "Failed to connect to RabbitMQ")
 
 	tp, err := tracing.SetTraceProvider(config.MsgConsumer)
@@ -111,6 +116,14 @@ func main() {
 	)
 	failOnError(err, fmt.Sprintf("Failed to get %s exchange", strings.AuditExchange))
 
+	err = channel.ExchangeDeclare(
+		strings.NotificationExchange,
+		"topic",
+		true, false, false, false,
+		nil,
+	)
+	failOnError(err, fmt.Sprintf("Failed to get %s exchange", strings.NotificationExchange))
+
 	_, err = channel.QueueDeclare(
 		strings.MessageCommon,
 		true, false, false, false,
@@ -140,6 +153,13 @@ func main() {
 	)
 	failOnError(err, fmt.Sprintf("Failed to define %s queue", strings.AuditPicker))
 
+	_, err = channel.QueueDeclare(
+		strings.NotificationPicker,
+		true, false, false, false,
+		nil,
+	)
+	failOnError(err, fmt.Sprintf("Failed to define %s queue", strings.NotificationPicker))
+
 	err = channel.QueueBind(
 		strings.MessageCommon,
 		"message.#",
@@ -176,6 +196,15 @@ func main() {
 	)
 	failOnError(err, fmt.Sprintf("Failed to bind %s queue to %s exchange", strings.AuditPicker, strings.AuditExchange))
 
+	err = channel.QueueBind(
+		strings.NotificationPicker,
+		"notification.#",
+		strings.NotificationExchange,
+		false,
+		nil,
+	)
+	failOnError(err, fmt.Sprintf("Failed to bind %s queue to %s exchange", strings.NotificationPicker, strings.NotificationExchange))
+
 	go saveMessage(channel)
 	logger := logging.LogService("MessageSend")
 	logger.Infof(strings.MessageActionEvent + " is running now")
@@ -188,6 +217,10 @@ func main() {
 	logger = logging.LogService("AuditPublish")
 	logger.Infof(strings.AuditPublishEvent + " is running now")
 
+	go saveNotification(channel)
+	logger = logging.LogService("NotificationSave")
+	logger.Infof(strings.NotificationMentionEvent + " is running now")
+
 	go esSaveMessage(channel)
 	logger = logging.LogService("esSaveMessage")
 	logger.Infof(strings.VideoPicker + " is running now")
@@ -228,6 +261,8 @@ func saveMessage(channel *amqp.Channel) {
 			continue
 		}
 
+		// message 在循环中复用，先清空避免沿用上一条消息的 @
+		message = models.Message{}
 		if err := json.Unmarshal(body.Body, &message); err != nil {
 			logger.WithFields(logrus.Fields{
 				"from_id": message.FromUserId,
@@ -263,16 +298,28 @@ func saveMessage(channel *amqp.Channel) {
 		}).Debugf("Receive message event")
 
 		//可能会重新插入数据 开启事务 晚点改
-		//写入数据库
-
-		result := database.Client.WithContext(ctx).Create(&pmessage)
+		//写入数据库，消息中的 @、通知与人工复核记录在同一个事务中写入
+		txErr := database.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(&pmessage).Error; err != nil {
+				return err
+			}
+			if message.ReviewReason != "" {
+				if err := moderation.SubmitReview(ctx, tx, models.ModerationItemMessage, pmessage.ID, pmessage.FromUserId, pmessage.Content, message.ReviewReason); err != nil {
+					return err
+				}
+			}
+			if err := mention.Save(ctx, tx, pmessage.ID, message.Mentions); err != nil {
+				return err
+			}
+			return mention.Notify(ctx, tx, pmessage.ID, 0, message.Mentions)
+		})
 
-		if result.Error != nil {
+		if txErr != nil {
 			logger.WithFields(logrus.Fields{
 				"from_id": message.FromUserId,
 				"to_id":   message.ToUserId,
 				"content": message.Content,
-				"err":     result.Error,
+				"err":     txErr,
 			}).Errorf("Error when insert message to database.")
 			logging.SetSpanError(span, err)
 			err = body.Nack(false, true)
@@ -440,6 +487,7 @@ func saveAuditAction(channel *amqp.Channel) {
 			Attached:     action.Attached,
 			ActorId:      action.ActorId,
 			VideoId:      action.VideoId,
+			AffectUserId: action.AffectUserId,
 			AffectAction: action.AffectAction,
 			AffectedData: action.AffectedData,
 			EventId:      action.EventId,
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/rabbitmq"
+	"context"
+	"encoding/json"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm/clause"
+)
+
+// saveNotification 把通知事件写入 notifications，Outbox 可能重复投递，按 EventId 去重
+func saveNotification(channel *amqp.Channel) {
+	msg, err := channel.Consume(
+		strings.NotificationPicker,
+		"",
+		false, false, false, false,
+		nil,
+	)
+	failOnError(err, "Failed to Consume")
+
+	for body := range msg {
+		ctx := rabbitmq.ExtractAMQPHeaders(context.Background(), body.Headers)
+
+		ctx, span := tracing.Tracer.Start(ctx, "NotificationSaveService")
+		logger := logging.LogService("NotificationSave").WithContext(ctx)
+
+		var event models.NotificationEvent
+		if err := json.Unmarshal(body.Body, &event); err != nil {
+			// 无法解析的事件重新投递也不会成功，直接丢弃
+			logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Errorf("Error when unmarshaling the notification event.")
+			logging.SetSpanError(span, err)
+			if err := body.Nack(false, false); err != nil {
+				logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Errorf("Error when nack the notification event")
+			}
+			span.End()
+			continue
+		}
+
+		notification := models.Notification{
+			UserId:   event.UserId,
+			ActorId:  event.ActorId,
+			Type:     event.Type,
+			ItemType: event.ItemType,
+			ItemId:   event.ItemId,
+			VideoId:  event.VideoId,
+			EventId:  event.EventId,
+		}
+		if err := database.Client.WithContext(ctx).
+			Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "event_id"}}, DoNothing: true}).
+			Create(&notification).Error; err != nil {
+			logger.WithFields(logrus.Fields{
+				"err":     err,
+				"EventId": event.EventId,
+				"UserId":  event.UserId,
+			}).Errorf("Error when saving the notification")
+			logging.SetSpanError(span, err)
+			if err := body.Nack(false, true); err != nil {
+				logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Errorf("Error when nack the notification event")
+			}
+			span.End()
+			continue
+		}
+
+		if err := body.Ack(false); err != nil {
+			logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Errorf("Error when ack the notification event")
+			logging.SetSpanError(span, err)
+		}
+		span.End()
+	}
+}
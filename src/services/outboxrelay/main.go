@@ -0,0 +1,179 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/utils/deps"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/outbox"
+	"GuGoTik/src/utils/prom"
+	"context"
+	"flag"
+	"fmt"
+	"github.com/oklog/run"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+)
+
+func usage() {
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] [run|list|replay]\n\n", os.Args[0])
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "  run     keep publishing pending outbox messages to RabbitMQ (default)\n")
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "  list    print the outbox messages matched by -id, -status and -min-attempts\n")
+	_, _ = fmt.Fprintf(flag.CommandLine.Output(), "  replay  reset the matched messages which are not sent yet, so they will be published again\n\n")
+	flag.PrintDefaults()
+}
+
+func main() {
+	ids := flag.String("id", "", "comma separated message ids for list and replay")
+	status := flag.String("status", "", "only match messages with this status: pending, sent or dead")
+	minAttempts := flag.Int("min-attempts", 0, "only match messages which have been attempted at least this many times")
+	limit := flag.Int("limit", 50, "max number of messages printed by list")
+	flag.Usage = usage
+	flag.Parse()
+
+	command := "run"
+	if flag.NArg() > 0 {
+		command = flag.Arg(0)
+	}
+
+	container := deps.MustNew()
+	log := logging.LogService(config.OutboxRelay)
+
+	filter := outbox.Filter{
+		Status:      *status,
+		MinAttempts: *minAttempts,
+	}
+	if *ids != "" {
+		for _, id := range strings.Split(*ids, ",") {
+			parsed, err := strconv.ParseUint(strings.TrimSpace(id), 10, 64)
+			if err != nil {
+				log.WithFields(logrus.Fields{
+					"id": id,
+				}).Fatalf("Invalid message id")
+			}
+			filter.Ids = append(filter.Ids, parsed)
+		}
+	}
+
+	switch command {
+	case "run":
+		relay(container, log)
+	case "list":
+		filter.Limit = *limit
+		messages, err := outbox.List(context.Background(), container.DB, filter)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"err": err,
+			}).Fatalf("Failed to list outbox messages")
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(w, "ID\tEXCHANGE\tROUTING KEY\tSTATUS\tATTEMPTS\tNEXT ATTEMPT\tCREATED AT\tLAST ERROR")
+		for _, m := range messages {
+			_, _ = fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%d\t%s\t%s\t%s\n", m.ID, m.Exchange, m.RoutingKey, m.Status, m.Attempts,
+				m.NextAttemptAt.Format(time.RFC3339), m.CreatedAt.Format(time.RFC3339), m.LastError)
+		}
+		_ = w.Flush()
+	case "replay":
+		// 不加任何条件时拒绝执行，避免误操作重放整张表
+		if len(filter.Ids) == 0 && filter.Status == "" && filter.MinAttempts == 0 {
+			log.Fatalf("Replay needs at least one of -id, -status and -min-attempts")
+		}
+		replayed, err := outbox.Replay(context.Background(), container.DB, filter)
+		if err != nil {
+			log.WithFields(logrus.Fields{
+				"err": err,
+			}).Fatalf("Failed to replay outbox messages")
+		}
+		log.WithFields(logrus.Fields{
+			"replayed": replayed,
+		}).Infof("Outbox messages are scheduled to be published again")
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func relay(container *deps.Container, log *logrus.Entry) {
+	tp, err := tracing.SetTraceProvider(config.OutboxRelay)
+	if err != nil {
+		logging.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Panicf("Error to set the trace")
+	}
+	defer func() {
+		if err := tp.Shutdown(context.Background()); err != nil {
+			logging.Logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Errorf("Error to set the trace")
+		}
+	}()
+
+	interval, err := time.ParseDuration(config.EnvCfg.OutboxPollInterval)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"err":      err,
+			"interval": config.EnvCfg.OutboxPollInterval,
+		}).Panicf("Invalid outbox poll interval")
+	}
+	retention, err := time.ParseDuration(config.EnvCfg.OutboxRetention)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"err":       err,
+			"retention": config.EnvCfg.OutboxRetention,
+		}).Panicf("Invalid outbox retention")
+	}
+
+	r := &outbox.Relay{
+		DB:             container.DB,
+		DialMQ:         container.DialMQ,
+		BatchSize:      config.EnvCfg.OutboxBatchSize,
+		MaxAttempts:    config.EnvCfg.OutboxMaxAttempts,
+		BaseBackoff:    time.Second,
+		MaxBackoff:     10 * time.Minute,
+		ConfirmTimeout: 10 * time.Second,
+		Retention:      retention,
+	}
+	defer r.Close()
+
+	g := &run.Group{}
+	ctx, cancel := context.WithCancel(context.Background())
+	g.Add(func() error {
+		return r.Run(ctx, interval)
+	}, func(error) {
+		cancel()
+	})
+
+	httpSrv := &http.Server{Addr: config.EnvCfg.PodIpAddr + config.Metrics}
+	g.Add(func() error {
+		m := http.NewServeMux()
+		m.Handle("/metrics", promhttp.HandlerFor(
+			prom.Client,
+			promhttp.HandlerOpts{
+				EnableOpenMetrics: true,
+			},
+		))
+		httpSrv.Handler = m
+		log.Infof("Promethus now running")
+		return httpSrv.ListenAndServe()
+	}, func(error) {
+		if err := httpSrv.Close(); err != nil {
+			log.Errorf("Prometheus %s listen happens error for: %v", config.OutboxRelay, err)
+		}
+	})
+
+	g.Add(run.SignalHandler(context.Background(), syscall.SIGINT, syscall.SIGTERM))
+
+	log.Infof("%s is running now", config.OutboxRelay)
+	if err := g.Run(); err != nil {
+		log.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Error when running outbox relay")
+	}
+}
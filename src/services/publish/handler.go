@@ -9,12 +9,12 @@ import (
 	"GuGoTik/src/rpc/publish"
 	"GuGoTik/src/rpc/user"
 	"GuGoTik/src/storage/cached"
-	"GuGoTik/src/storage/database"
-	"GuGoTik/src/storage/file"
-	"GuGoTik/src/storage/redis"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/content"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/moderation"
 	"GuGoTik/src/utils/pathgen"
+	"GuGoTik/src/utils/privacy"
 	"GuGoTik/src/utils/rabbitmq"
 	"bytes"
 	"context"
@@ -23,6 +23,7 @@ import (
 	"github.com/go-redis/redis_rate/v10"
 	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 	"math/rand"
 	"net/http"
 	"strconv"
@@ -31,6 +32,7 @@ import (
 
 type PublishServiceImpl struct {
 	publish.PublishServiceServer
+	deps *deps.Container
 }
 
 var conn *amqp.Connection
@@ -40,6 +42,9 @@ var channel *amqp.Channel
 var FeedClient feed.FeedServiceClient
 var userClient user.UserServiceClient
 
+// localModerator 发布视频前对标题执行的本地审核链
+var localModerator moderation.Moderator
+
 func exitOnError(err error) {
 	if err != nil {
 		panic(err)
@@ -65,16 +70,20 @@ func createVideoLimitKey(userId uint32) string {
 	return fmt.Sprintf("%s-%d", createVideoLimitKeyPrefix, userId)
 }
 
-func (a PublishServiceImpl) New() {
-	FeedRpcConn := grpc2.Connect(config.FeedRpcServerName)
+func (a *PublishServiceImpl) New(container *deps.Container) {
+	a.deps = container
+	FeedRpcConn := container.Dial(config.FeedRpcServerName)
 	FeedClient = feed.NewFeedServiceClient(FeedRpcConn)
 
-	userRpcConn := grpc2.Connect(config.UserRpcServerName)
+	userRpcConn := container.Dial(config.UserRpcServerName)
 	userClient = user.NewUserServiceClient(userRpcConn)
 
 	var err error
 
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
+	localModerator, err = moderation.NewLocalChain()
+	exitOnError(err)
+
+	conn, err = container.DialMQ()
 	exitOnError(err)
 
 	channel, err = conn.Channel()
@@ -166,9 +175,31 @@ func (a PublishServiceImpl) ListVideo(ctx context.Context, req *publish.ListVide
 		}
 		return
 	}
+	// 私密账号只对本人与粉丝可见
+	visible, err := privacy.CanView(ctx, a.deps.DB, req.ActorId, req.UserId, userExistResp.IsPrivate)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": req.ActorId,
+			"UserId":  req.UserId,
+		}).Errorf("Failed to check whether the user is visible")
+		logging.SetSpanError(span, err)
+		resp = &publish.ListVideoResponse{
+			StatusCode: strings.PublishServiceInnerErrorCode,
+			StatusMsg:  strings.PublishServiceInnerError,
+		}
+		return resp, nil
+	}
+	if !visible {
+		resp = &publish.ListVideoResponse{
+			StatusCode: strings.AccountPrivateCode,
+			StatusMsg:  strings.AccountPrivate,
+		}
+		return resp, nil
+	}
 	// 简略查询视频信息，不包含点赞，评论数量，是否点赞
 	var videos []models.Video
-	err = database.Client.WithContext(ctx).
+	err = a.deps.DB.WithContext(ctx).
 		Where("user_id = ?", req.UserId).
 		Order("created_at DESC").
 		Find(&videos).Error
@@ -225,9 +256,9 @@ func (a PublishServiceImpl) CountVideo(ctx context.Context, req *publish.CountVi
 	countStringKey := fmt.Sprintf("VideoCount-%d", req.UserId)
 	countString, err := cached.GetWithFunc(ctx, countStringKey,
 		func(ctx context.Context, key string) (string, error) {
-			rCount, err := count(ctx, req.UserId)
+			rCount, err := a.count(ctx, req.UserId)
 			return strconv.FormatInt(rCount, 10), err
-		})
+		}, cached.WithStaleWhileRevalidate(time.Hour, 5*time.Minute))
 
 	if err != nil {
 		cached.TagDelete(ctx, "VideoCount")
@@ -253,11 +284,11 @@ func (a PublishServiceImpl) CountVideo(ctx context.Context, req *publish.CountVi
 	return
 }
 
-func count(ctx context.Context, userId uint32) (count int64, err error) {
+func (a PublishServiceImpl) count(ctx context.Context, userId uint32) (count int64, err error) {
 	ctx, span := tracing.Tracer.Start(ctx, "CountVideo")
 	defer span.End()
 	logger := logging.LogService("PublishService.CountVideo").WithContext(ctx)
-	result := database.Client.Model(&models.Video{}).WithContext(ctx).Where("user_id = ?", userId).Count(&count)
+	result := a.deps.DB.Model(&models.Video{}).WithContext(ctx).Where("user_id = ?", userId).Count(&count)
 
 	if result.Error != nil {
 		logger.WithFields(logrus.Fields{
@@ -280,7 +311,7 @@ func (a PublishServiceImpl) CreateVideo(ctx context.Context, request *publish.Cr
 	}).Infof("Create video requested.")
 
 	// Rate limiting
-	limiter := redis_rate.NewLimiter(redis.Client)
+	limiter := redis_rate.NewLimiter(a.deps.Redis)
 	limiterKey := createVideoLimitKey(request.ActorId)
 	limiterRes, err := limiter.Allow(ctx, limiterKey, redis_rate.PerSecond(createVideoMaxQPS))
 	if err != nil {
@@ -308,6 +339,43 @@ func (a PublishServiceImpl) CreateVideo(ctx context.Context, request *publish.Cr
 		return
 	}
 
+	// 封禁期间不能发布内容
+	until, banned, err := moderation.BannedUntil(ctx, a.deps.DB, request.ActorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to query the ban of the user")
+		logging.SetSpanError(span, err)
+		return &publish.CreateVideoResponse{
+			StatusCode: strings.VideoServiceInnerErrorCode,
+			StatusMsg:  strings.VideoServiceInnerError,
+		}, err
+	}
+	if banned {
+		logger.WithFields(logrus.Fields{
+			"ActorId": request.ActorId,
+			"until":   until,
+		}).Infof("Banned user tried to publish a video")
+		return &publish.CreateVideoResponse{
+			StatusCode: strings.UserBannedCode,
+			StatusMsg:  strings.UserBanned,
+		}, nil
+	}
+
+	// 标题被本地审核拒绝时不能发布，需要人工复核的视频照常处理，由审核员决定是否删除
+	verdict, _ := localModerator.Moderate(ctx, request.Title)
+	if verdict.Decision == moderation.Reject {
+		logger.WithFields(logrus.Fields{
+			"ActorId": request.ActorId,
+			"reason":  verdict.Reason,
+		}).Infof("Video title rejected by moderation")
+		return &publish.CreateVideoResponse{
+			StatusCode: strings.ContentRejectedCode,
+			StatusMsg:  strings.ContentRejected,
+		}, nil
+	}
+
 	// 检测视频格式
 	detectedContentType := http.DetectContentType(request.Data)
 	if detectedContentType != "video/mp4" {
@@ -326,14 +394,12 @@ func (a PublishServiceImpl) CreateVideo(ctx context.Context, request *publish.Cr
 	// 创建一个新的随机数生成器
 	r := rand.New(rand.NewSource(time.Now().UnixNano()))
 	videoId := r.Uint32()
-	fileName := pathgen.GenerateRawVideoName(request.ActorId, request.Title, videoId)
 	coverName := pathgen.GenerateCoverName(request.ActorId, request.Title, videoId)
-	// 上传视频
-	_, err = file.Upload(ctx, fileName, reader)
+	// 上传视频，视频按内容寻址保存，相同内容的视频只保存一份
+	uploadOutput, err := a.deps.Storage.UploadContentAddressed(ctx, reader, ".mp4")
 	if err != nil {
 		logger.WithFields(logrus.Fields{
-			"file_name": fileName,
-			"err":       err,
+			"err": err,
 		}).Debug("failed to upload video")
 		resp = &publish.CreateVideoResponse{
 			StatusCode: strings.VideoServiceInnerErrorCode,
@@ -341,25 +407,61 @@ func (a PublishServiceImpl) CreateVideo(ctx context.Context, request *publish.Cr
 		}
 		return
 	}
+	fileName := uploadOutput.FileName
 	logger.WithFields(logrus.Fields{
 		"file_name": fileName,
+		"size":      uploadOutput.Size,
+		"existed":   uploadOutput.Existed,
 	}).Debug("uploaded video")
 
 	raw := &models.RawVideo{
-		ActorId:   request.ActorId,
-		VideoId:   videoId,
-		Title:     request.Title,
-		FileName:  fileName,
-		CoverName: coverName,
+		ActorId:     request.ActorId,
+		VideoId:     videoId,
+		Title:       request.Title,
+		FileName:    fileName,
+		CoverName:   coverName,
+		ContentHash: uploadOutput.Checksum,
 	}
-	result := database.Client.Create(&raw)
-	if result.Error != nil {
+	// RawVideo、内容引用计数与人工复核记录在同一事务中写入
+	err = a.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// 复用的文件可能在上传之后、加锁之前被回收，加锁后确认文件仍然存在，不存在时重新写入
+		if err := content.Lock(tx, fileName); err != nil {
+			return err
+		}
+		if uploadOutput.Existed {
+			existed, err := a.deps.Storage.IsFileExist(ctx, fileName)
+			if err != nil {
+				return err
+			}
+			if !existed {
+				if _, err := a.deps.Storage.UploadContentAddressed(ctx, bytes.NewReader(request.Data), ".mp4"); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tx.Create(&raw).Error; err != nil {
+			return err
+		}
+		if verdict.Decision != moderation.Allow {
+			if err := moderation.SubmitReview(ctx, tx, models.ModerationItemVideo, videoId, request.ActorId, request.Title, verdict.Reason); err != nil {
+				return err
+			}
+		}
+		return content.Reference(tx, uploadOutput.Checksum, fileName, uploadOutput.Size)
+	})
+	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"file_name":  raw.FileName,
 			"cover_name": raw.CoverName,
 			"err":        err,
 		}).Errorf("Error when updating rawVideo information to database")
-		logging.SetSpanError(span, result.Error)
+		logging.SetSpanError(span, err)
+		resp = &publish.CreateVideoResponse{
+			StatusCode: strings.VideoServiceInnerErrorCode,
+			StatusMsg:  strings.VideoServiceInnerError,
+		}
+		return
 	}
 
 	marshal, err := json.Marshal(raw)
@@ -6,6 +6,7 @@ import (
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/publish"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
@@ -24,6 +25,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.PublishRpcServerName)
 
 	if err != nil {
@@ -72,13 +75,13 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.PublishRpcServerName, config.PublishRpcServerPort)
 
 	var srv PublishServiceImpl
+	srv.New(container)
 	publish.RegisterPublishServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
 	defer CloseMQConn()
 	if err := consul.RegisterConsul(config.PublishRpcServerName, config.PublishRpcServerPort); err != nil {
 		log.Panicf("Rpc %s register consul happens error for: %v", config.PublishRpcServerName, err)
 	}
-	srv.New()
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
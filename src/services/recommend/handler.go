@@ -6,7 +6,7 @@ import (
 	"GuGoTik/src/extra/gorse"
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/recommend"
-	"GuGoTik/src/storage/redis"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"context"
 	"fmt"
@@ -17,9 +17,11 @@ import (
 
 type RecommendServiceImpl struct {
 	recommend.RecommendServiceServer
+	deps *deps.Container
 }
 
-func (a RecommendServiceImpl) New() {
+func (a *RecommendServiceImpl) New(container *deps.Container) {
+	a.deps = container
 	gorseClient = gorse.NewGorseClient(config.EnvCfg.GorseAddr, config.EnvCfg.GorseApiKey)
 }
 
@@ -33,7 +35,7 @@ func (a RecommendServiceImpl) GetRecommendInformation(ctx context.Context, reque
 
 	var offset int
 	if request.Offset == -1 {
-		ids, err := getVideoIds(ctx, strconv.Itoa(int(request.UserId)), int(request.Number))
+		ids, err := a.getVideoIds(ctx, strconv.Itoa(int(request.UserId)), int(request.Number))
 
 		if err != nil {
 			logger.WithFields(logrus.Fields{
@@ -136,7 +138,7 @@ func (a RecommendServiceImpl) RegisterRecommendUser(ctx context.Context, request
 	return
 }
 
-func getVideoIds(ctx context.Context, actorId string, num int) (ids []uint32, err error) {
+func (a RecommendServiceImpl) getVideoIds(ctx context.Context, actorId string, num int) (ids []uint32, err error) {
 	ctx, span := tracing.Tracer.Start(ctx, "GetRecommendAutoService")
 	defer span.End()
 	logging.SetSpanWithHostname(span)
@@ -160,7 +162,7 @@ func getVideoIds(ctx context.Context, actorId string, num int) (ids []uint32, er
 		}
 
 		for _, id := range vIds {
-			res := redis.Client.SIsMember(ctx, key, id)
+			res := a.deps.Redis.SIsMember(ctx, key, id)
 			if res.Err() != nil && res.Err() != redis2.Nil {
 				logger.WithFields(logrus.Fields{
 					"err":     err,
@@ -202,7 +204,7 @@ func getVideoIds(ctx context.Context, actorId string, num int) (ids []uint32, er
 		}).Infof("Get recommend information")
 
 		if len(idsStr) != 0 {
-			res := redis.Client.SAdd(ctx, key, idsStr)
+			res := a.deps.Redis.SAdd(ctx, key, idsStr)
 			if res.Err() != nil {
 				if err != nil {
 					logger.WithFields(logrus.Fields{
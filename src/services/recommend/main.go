@@ -6,6 +6,7 @@ import (
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/recommend"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
@@ -24,6 +25,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.RecommendRpcServiceName)
 
 	if err != nil {
@@ -70,10 +73,10 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.RecommendRpcServiceName, config.RecommendRpcServicePort)
 
 	var srv RecommendServiceImpl
+	srv.New(container)
 	recommend.RegisterRecommendServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
 
-	srv.New()
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
@@ -0,0 +1,492 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/relation"
+	"GuGoTik/src/storage/cached"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// 重建的用户集合中总是带有一个占位成员，没有记录的用户也能命中缓存，读取时跳过该成员
+const idSetPlaceholder = "0"
+
+const idSetTTL = 24 * time.Hour
+
+// idSetVersionTTL 版本号只需要覆盖一次重建的耗时
+const idSetVersionTTL = time.Minute
+
+// errAlreadyBlocked 已经拉黑过该用户
+var errAlreadyBlocked = errors.New("user already blocked")
+
+// set: actorID拉黑的用户 key: block_list_actorID
+func blockListKey(actorId uint32) string {
+	return config.EnvCfg.RedisPrefix + fmt.Sprintf("block_list_%d", actorId)
+}
+
+// set: 拉黑userID的用户 key: blocked_by_userID
+func blockedByKey(userId uint32) string {
+	return config.EnvCfg.RedisPrefix + fmt.Sprintf("blocked_by_%d", userId)
+}
+
+// set: actorID屏蔽的用户 key: mute_list_actorID
+func muteListKey(actorId uint32) string {
+	return config.EnvCfg.RedisPrefix + fmt.Sprintf("mute_list_%d", actorId)
+}
+
+// idSetVersionKey 用户集合的版本号，集合被删除时增加，用于发现重建期间发生的修改
+func idSetVersionKey(key string) string {
+	return key + "_version"
+}
+
+// idSetRefillScript 只有在集合仍不存在且版本号没有变化时才写入重建的集合。
+// 版本号变化说明读取数据库之后集合被删除过，重建的数据可能已经过期。
+// SADD 分批执行，避免成员过多时超出 unpack 的限制
+var idSetRefillScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	return 1
+end
+if (redis.call('GET', KEYS[2]) or '0') ~= ARGV[1] then
+	return 0
+end
+for i = 3, #ARGV, 1000 do
+	redis.call('SADD', KEYS[1], unpack(ARGV, i, math.min(i + 999, #ARGV)))
+end
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return 1
+`)
+
+// invalidateIdSets 增加版本号后删除用户集合，正在进行的重建不会写回删除之前读取的数据
+func (r RelationServiceImpl) invalidateIdSets(ctx context.Context, keys ...string) error {
+	_, err := r.deps.Redis.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		for _, key := range keys {
+			pipe.Incr(ctx, idSetVersionKey(key))
+			pipe.Expire(ctx, idSetVersionKey(key), idSetVersionTTL)
+			pipe.Del(ctx, key)
+		}
+		return nil
+	})
+	return err
+}
+
+// loadIdSet 读取缓存的用户集合，未命中时通过 column 从主库的 model 表重建
+func (r RelationServiceImpl) loadIdSet(ctx context.Context, key string, model interface{}, column string, where string, id uint32) ([]uint32, error) {
+	members, err := r.deps.Redis.SMembers(ctx, key).Result()
+	if err == nil && len(members) > 0 {
+		ids := make([]uint32, 0, len(members))
+		for _, member := range members {
+			if member == idSetPlaceholder {
+				continue
+			}
+			userId, err := strconv.ParseUint(member, 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, uint32(userId))
+		}
+		return ids, nil
+	}
+
+	// 版本号需要在读取数据库之前读取
+	version, err := r.deps.Redis.Get(ctx, idSetVersionKey(key)).Result()
+	if err == redis.Nil {
+		version = "0"
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ids []uint32
+	if err := r.deps.DB.WithContext(database.WithPrimary(ctx)).
+		Model(model).
+		Where(where, id).
+		Pluck(column, &ids).Error; err != nil {
+		return nil, err
+	}
+
+	// 版本号变化时不写入缓存，由下一次读取重建
+	args := make([]interface{}, 0, len(ids)+3)
+	args = append(args, version, int64(idSetTTL/time.Second), idSetPlaceholder)
+	for _, userId := range ids {
+		args = append(args, userId)
+	}
+	err = idSetRefillScript.Run(ctx, r.deps.Redis, []string{key, idSetVersionKey(key)}, args...).Err()
+	if err != nil {
+		logging.Logger.WithFields(logrus.Fields{
+			"err": err,
+			"key": key,
+		}).Warnf("Failed to cache the user set")
+	}
+	return ids, nil
+}
+
+// blockedIds 查询 actorId 拉黑的用户
+func (r RelationServiceImpl) blockedIds(ctx context.Context, actorId uint32) ([]uint32, error) {
+	return r.loadIdSet(ctx, blockListKey(actorId), &models.UserBlock{}, "user_id", "actor_id = ?", actorId)
+}
+
+// blockedByIds 查询拉黑了 userId 的用户
+func (r RelationServiceImpl) blockedByIds(ctx context.Context, userId uint32) ([]uint32, error) {
+	return r.loadIdSet(ctx, blockedByKey(userId), &models.UserBlock{}, "actor_id", "user_id = ?", userId)
+}
+
+// mutedIds 查询 actorId 屏蔽的用户
+func (r RelationServiceImpl) mutedIds(ctx context.Context, actorId uint32) ([]uint32, error) {
+	return r.loadIdSet(ctx, muteListKey(actorId), &models.UserMute{}, "user_id", "actor_id = ?", actorId)
+}
+
+// isBlocked 任意一方拉黑了另一方时返回 true
+func (r RelationServiceImpl) isBlocked(ctx context.Context, actorId uint32, userId uint32) (bool, error) {
+	ids, err := r.blockedIds(ctx, actorId)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == userId {
+			return true, nil
+		}
+	}
+	ids, err = r.blockedByIds(ctx, actorId)
+	if err != nil {
+		return false, err
+	}
+	for _, id := range ids {
+		if id == userId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// invalidateBlockCache 先写入DB 再删除缓存
+func (r RelationServiceImpl) invalidateBlockCache(ctx context.Context, actorId uint32, userId uint32, span trace.Span, logger *logrus.Entry) {
+	if err := r.invalidateIdSets(ctx, blockListKey(actorId), blockedByKey(userId)); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": actorId,
+			"UserId":  userId,
+		}).Errorf("Failed to delete the block cache")
+		logging.SetSpanError(span, err)
+	}
+}
+
+// removeFollowEdges 在 tx 所在的事务中删除双方之间的关注关系与关注请求，返回被删除的关注关系，
+// 由调用方在事务提交后通过 syncRemovedFollowEdges 更新缓存
+func removeFollowEdges(tx *gorm.DB, actorId uint32, userId uint32) (removed []models.Relation, err error) {
+	if err = tx.Where("(actor_id = ? AND user_id = ?) OR (actor_id = ? AND user_id = ?)", actorId, userId, userId, actorId).
+		Delete(&models.FollowRequest{}).Error; err != nil {
+		return
+	}
+
+	err = tx.Unscoped().Clauses(clause.Returning{}).
+		Where("(actor_id = ? AND user_id = ?) OR (actor_id = ? AND user_id = ?)", actorId, userId, userId, actorId).
+		Delete(&removed).Error
+	return
+}
+
+// syncRemovedFollowEdges 关注关系删除并提交后更新关注相关的缓存，更新失败时已经记录日志，不影响其他缓存的更新
+func (r RelationServiceImpl) syncRemovedFollowEdges(ctx context.Context, removed []models.Relation, span trace.Span, logger *logrus.Entry) {
+	for _, rel := range removed {
+		_ = r.updateFollowListCache(ctx, rel.ActorId, rel, false, span, logger)
+		_ = r.updateFollowerListCache(ctx, rel.UserId, rel, false, span, logger)
+		_ = r.updateFollowCountCache(ctx, rel.ActorId, false, span, logger)
+		_ = r.updateFollowerCountCache(ctx, rel.UserId, false, span, logger)
+		cached.TagDelete(ctx, fmt.Sprintf("IsFollowedCache-%d-%d", rel.UserId, rel.ActorId))
+	}
+}
+
+// Block 拉黑用户，同时删除双方之间的关注关系
+func (r RelationServiceImpl) Block(ctx context.Context, request *relation.BlockRequest) (resp *relation.BlockResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "BlockService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.Block").WithContext(ctx)
+
+	if request.UserId == request.ActorId {
+		resp = &relation.BlockResponse{
+			StatusCode: strings.UnableToRelateYourselfErrorCode,
+			StatusMsg:  strings.UnableToRelateYourselfError,
+		}
+		return
+	}
+
+	ok, err := isUserExist(ctx, request.ActorId, request.UserId, span, logger)
+	if err != nil || !ok {
+		resp = &relation.BlockResponse{
+			StatusCode: strings.UnableToQueryUserErrorCode,
+			StatusMsg:  strings.UnableToQueryUserError,
+		}
+		return
+	}
+
+	var removed []models.Relation
+	err = r.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserBlock{
+			ActorId: request.ActorId,
+			UserId:  request.UserId,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errAlreadyBlocked
+		}
+		var err error
+		removed, err = removeFollowEdges(tx, request.ActorId, request.UserId)
+		return err
+	})
+
+	if errors.Is(err, errAlreadyBlocked) {
+		resp = &relation.BlockResponse{
+			StatusCode: strings.AlreadyBlockedCode,
+			StatusMsg:  strings.AlreadyBlocked,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to block the user")
+		logging.SetSpanError(span, err)
+		resp = &relation.BlockResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+
+	r.invalidateBlockCache(ctx, request.ActorId, request.UserId, span, logger)
+	r.syncRemovedFollowEdges(ctx, removed, span, logger)
+	resp = &relation.BlockResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
+
+// Unblock 取消拉黑，被删除的关注关系不会恢复
+func (r RelationServiceImpl) Unblock(ctx context.Context, request *relation.BlockRequest) (resp *relation.BlockResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "UnblockService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.Unblock").WithContext(ctx)
+
+	result := r.deps.DB.WithContext(ctx).
+		Where("actor_id = ? AND user_id = ?", request.ActorId, request.UserId).
+		Delete(&models.UserBlock{})
+	if result.Error != nil {
+		err = result.Error
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to unblock the user")
+		logging.SetSpanError(span, err)
+		resp = &relation.BlockResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+	if result.RowsAffected == 0 {
+		resp = &relation.BlockResponse{
+			StatusCode: strings.BlockNotFoundCode,
+			StatusMsg:  strings.BlockNotFound,
+		}
+		return
+	}
+
+	r.invalidateBlockCache(ctx, request.ActorId, request.UserId, span, logger)
+	resp = &relation.BlockResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
+
+// ListBlocked 按拉黑时间倒序列出当前用户拉黑的用户
+func (r RelationServiceImpl) ListBlocked(ctx context.Context, request *relation.ListBlockedRequest) (resp *relation.ListBlockedResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListBlockedService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.ListBlocked").WithContext(ctx)
+
+	var ids []uint32
+	if err = r.deps.DB.WithContext(ctx).
+		Model(&models.UserBlock{}).
+		Where("actor_id = ?", request.ActorId).
+		Order("id desc").
+		Pluck("user_id", &ids).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to retrieve block list")
+		logging.SetSpanError(span, err)
+		resp = &relation.ListBlockedResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+
+	// 拉黑列表中的用户都与当前用户存在拉黑关系，不传入当前用户，避免被批量查询过滤
+	userList, err := r.idList2UserList(ctx, ids, 0, logger, span)
+	if err != nil {
+		resp = &relation.ListBlockedResponse{
+			StatusCode: strings.UnableToQueryUserErrorCode,
+			StatusMsg:  strings.UnableToQueryUserError,
+		}
+		return
+	}
+
+	resp = &relation.ListBlockedResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		UserList:   userList,
+	}
+	return
+}
+
+// Mute 屏蔽或取消屏蔽用户，重复操作不会报错
+func (r RelationServiceImpl) Mute(ctx context.Context, request *relation.MuteRequest) (resp *relation.MuteResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "MuteService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.Mute").WithContext(ctx)
+
+	if request.UserId == request.ActorId {
+		resp = &relation.MuteResponse{
+			StatusCode: strings.UnableToRelateYourselfErrorCode,
+			StatusMsg:  strings.UnableToRelateYourselfError,
+		}
+		return
+	}
+
+	db := r.deps.DB.WithContext(ctx)
+	if request.Unmute {
+		err = db.Where("actor_id = ? AND user_id = ?", request.ActorId, request.UserId).Delete(&models.UserMute{}).Error
+	} else {
+		var ok bool
+		ok, err = isUserExist(ctx, request.ActorId, request.UserId, span, logger)
+		if err != nil || !ok {
+			resp = &relation.MuteResponse{
+				StatusCode: strings.UnableToQueryUserErrorCode,
+				StatusMsg:  strings.UnableToQueryUserError,
+			}
+			return
+		}
+		err = db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserMute{
+			ActorId: request.ActorId,
+			UserId:  request.UserId,
+		}).Error
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+			"Unmute":  request.Unmute,
+		}).Errorf("Failed to mute the user")
+		logging.SetSpanError(span, err)
+		resp = &relation.MuteResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+
+	if err = r.invalidateIdSets(ctx, muteListKey(request.ActorId)); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to delete the mute cache")
+		logging.SetSpanError(span, err)
+		err = nil
+	}
+
+	resp = &relation.MuteResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
+
+func (r RelationServiceImpl) IsBlocked(ctx context.Context, request *relation.IsBlockedRequest) (resp *relation.IsBlockedResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "IsBlockedService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.IsBlocked").WithContext(ctx)
+
+	result, err := r.isBlocked(ctx, request.ActorId, request.UserId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("IsBlockedService failed")
+		logging.SetSpanError(span, err)
+		resp = &relation.IsBlockedResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+
+	resp = &relation.IsBlockedResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Result:     result,
+	}
+	return
+}
+
+func (r RelationServiceImpl) GetHiddenUsers(ctx context.Context, request *relation.HiddenUsersRequest) (resp *relation.HiddenUsersResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "GetHiddenUsersService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.GetHiddenUsers").WithContext(ctx)
+
+	blocked, err := r.blockedIds(ctx, request.ActorId)
+	var blockedBy, muted []uint32
+	if err == nil {
+		blockedBy, err = r.blockedByIds(ctx, request.ActorId)
+	}
+	if err == nil {
+		muted, err = r.mutedIds(ctx, request.ActorId)
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("GetHiddenUsersService failed")
+		logging.SetSpanError(span, err)
+		resp = &relation.HiddenUsersResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+
+	resp = &relation.HiddenUsersResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		BlockedIds: append(blocked, blockedBy...),
+		MutedIds:   muted,
+	}
+	return
+}
@@ -8,23 +8,19 @@ import (
 	"GuGoTik/src/rpc/relation"
 	"GuGoTik/src/rpc/user"
 	"GuGoTik/src/storage/cached"
-	"GuGoTik/src/storage/database"
-	redis2 "GuGoTik/src/storage/redis"
 	"GuGoTik/src/utils/audit"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
-	"GuGoTik/src/utils/rabbitmq"
 	"context"
 	"errors"
 	"fmt"
 	"github.com/go-redis/redis_rate/v10"
 	"github.com/google/uuid"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel/trace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"strconv"
-	"sync"
 	"time"
 )
 
@@ -36,39 +32,17 @@ const actionRelationMaxQPS = 3
 
 type RelationServiceImpl struct {
 	relation.RelationServiceServer
+	deps *deps.Container
 }
 
 func actionRelationLimitKey(userId uint32) string {
 	return fmt.Sprintf("%s-%d", actionRelationLimitKeyPrefix, userId)
 }
 
-func exitOnError(err error) {
-	if err != nil {
-		panic(err)
-	}
-}
-
-func CloseMQConn() {
-	if err := conn.Close(); err != nil {
-		panic(err)
-	}
-
-	if err := channel.Close(); err != nil {
-		panic(err)
-	}
-}
-
-func (r RelationServiceImpl) New() {
-	userRPCConn := grpc2.Connect(config.UserRpcServerName)
+func (r *RelationServiceImpl) New(container *deps.Container) {
+	r.deps = container
+	userRPCConn := container.Dial(config.UserRpcServerName)
 	userClient = user.NewUserServiceClient(userRPCConn)
-
-	var err error
-
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
-	exitOnError(err)
-
-	channel, err = conn.Channel()
-	exitOnError(err)
 }
 
 func (r RelationServiceImpl) Follow(ctx context.Context, request *relation.RelationActionRequest) (resp *relation.RelationActionResponse, err error) {
@@ -78,7 +52,7 @@ func (r RelationServiceImpl) Follow(ctx context.Context, request *relation.Relat
 	logger := logging.LogService("RelationService.Follow").WithContext(ctx)
 
 	//限流
-	limiter := redis_rate.NewLimiter(redis2.Client)
+	limiter := redis_rate.NewLimiter(r.deps.Redis)
 	limiterKey := actionRelationLimitKey(request.ActorId)
 	limiterRes, err := limiter.Allow(ctx, limiterKey, redis_rate.PerSecond(actionRelationMaxQPS))
 	if err != nil {
@@ -164,13 +138,37 @@ func (r RelationServiceImpl) Follow(ctx context.Context, request *relation.Relat
 		return
 	}
 
+	// 任意一方拉黑了另一方时不能关注
+	blocked, err := r.isBlocked(ctx, request.ActorId, request.UserId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to query the block relation")
+		logging.SetSpanError(span, err)
+
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.UnableToFollowErrorCode,
+			StatusMsg:  strings.UnableToFollowError,
+		}
+		return
+	}
+	if blocked {
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.UserBlockedCode,
+			StatusMsg:  strings.UserBlocked,
+		}
+		return
+	}
+
 	rRelation := models.Relation{
 		ActorId: request.ActorId, // 关注者的 ID
 		UserId:  request.UserId,  // 被关注者的 ID
 	}
 
 	// 开始事务
-	tx := database.Client.WithContext(ctx).Begin()
+	tx := r.deps.DB.WithContext(ctx).Begin()
 	defer func() {
 		if err != nil {
 			tx.Rollback()
@@ -196,77 +194,56 @@ func (r RelationServiceImpl) Follow(ctx context.Context, request *relation.Relat
 		}
 		return
 	}
-	// 不存在就插入一条关注记录
-	if err = tx.Create(&rRelation).Error; err != nil {
+	// 私密账号需要对方同意，只写入关注请求
+	if userExist.IsPrivate {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.FollowRequest{
+			ActorId: request.ActorId,
+			UserId:  request.UserId,
+		})
+		if err = result.Error; err != nil {
+			resp = &relation.RelationActionResponse{
+				StatusCode: strings.UnableToFollowErrorCode,
+				StatusMsg:  strings.UnableToFollowError,
+			}
+			logging.SetSpanError(span, err)
+			return
+		}
+		if result.RowsAffected == 0 {
+			resp = &relation.RelationActionResponse{
+				StatusCode: strings.FollowRequestPendingCode,
+				StatusMsg:  strings.FollowRequestPending,
+			}
+			return
+		}
 		resp = &relation.RelationActionResponse{
-			StatusCode: strings.UnableToFollowErrorCode,
-			StatusMsg:  strings.UnableToFollowError,
+			StatusCode: strings.ServiceOKCode,
+			StatusMsg:  strings.ServiceOK,
+			Pending:    true,
 		}
-		logging.SetSpanError(span, err)
-		return
-	}
-	// set: key:follow_list_actorID val:userID
-	if err = updateFollowListCache(ctx, request.ActorId, rRelation, true, span, logger); err != nil {
-		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("failed to update follow list cache")
-		logging.SetSpanError(span, err)
 		return
 	}
-	// set: key:follower_list_userID val:actorID
-	if err = updateFollowerListCache(ctx, request.UserId, rRelation, true, span, logger); err != nil {
-		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("failed to update follower list cache")
-		logging.SetSpanError(span, err)
-		return
-	}
-	// 关注+1
-	if err = updateFollowCountCache(ctx, request.ActorId, true, span, logger); err != nil {
-		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("failed to update follow count cache")
+	// 账号切换为公开前发出的关注请求不再需要处理
+	if err = tx.Where("actor_id = ? AND user_id = ?", request.ActorId, request.UserId).Delete(&models.FollowRequest{}).Error; err != nil {
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.UnableToFollowErrorCode,
+			StatusMsg:  strings.UnableToFollowError,
+		}
 		logging.SetSpanError(span, err)
 		return
 	}
-	// 粉丝+1
-	if err = updateFollowerCountCache(ctx, request.UserId, true, span, logger); err != nil {
-		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("failed to update follower count cache")
-		logging.SetSpanError(span, err)
+	// 不存在就插入一条关注记录
+	if err = r.addFollow(ctx, tx, rRelation, span, logger); err != nil {
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.UnableToFollowErrorCode,
+			StatusMsg:  strings.UnableToFollowError,
+		}
 		return
 	}
-	// 先写入DB 再删除缓存
-	cached.TagDelete(ctx, fmt.Sprintf("IsFollowedCache-%d-%d", request.UserId, request.ActorId))
 	resp = &relation.RelationActionResponse{
 		StatusCode: strings.ServiceOKCode,
 		StatusMsg:  strings.ServiceOK,
 	}
 
-	// Publish event to event_exchange and audit_exchange
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		action := &models.Action{
-			Type:         strings.FollowIdActionLog,
-			Name:         strings.FollowNameActionLog,
-			SubName:      strings.FollowUpActionSubLog,
-			ServiceName:  strings.FollowServiceName,
-			ActorId:      request.ActorId,
-			VideoId:      0,
-			AffectUserId: request.UserId,
-			AffectAction: 1,
-			AffectedData: "1",
-			EventId:      uuid.New().String(),
-			TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
-			SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
-		}
-		audit.PublishAuditEvent(ctx, action, channel)
-	}()
-	wg.Wait()
-
 	return
 }
 
@@ -339,11 +316,33 @@ func (r RelationServiceImpl) Unfollow(ctx context.Context, request *relation.Rel
 
 	// Check if relation exists before deleting
 	existingRelation := models.Relation{}
-	result := database.Client.WithContext(ctx).
+	result := r.deps.DB.WithContext(ctx).
 		Where(&rRelation).
 		First(&existingRelation)
 
 	if result.Error != nil {
+		// 还没有被同意的关注请求同样可以取消
+		cancelled, cancelErr := r.cancelFollowRequest(ctx, request.ActorId, request.UserId)
+		if cancelErr != nil {
+			logger.WithFields(logrus.Fields{
+				"err":     cancelErr,
+				"ActorId": request.ActorId,
+				"UserId":  request.UserId,
+			}).Errorf("failed to cancel the follow request")
+			logging.SetSpanError(span, cancelErr)
+			resp = &relation.RelationActionResponse{
+				StatusCode: strings.UnableToUnFollowErrorCode,
+				StatusMsg:  strings.UnableToUnFollowError,
+			}
+			return
+		}
+		if cancelled {
+			resp = &relation.RelationActionResponse{
+				StatusCode: strings.ServiceOKCode,
+				StatusMsg:  strings.ServiceOK,
+			}
+			return
+		}
 		resp = &relation.RelationActionResponse{
 			StatusCode: strings.RelationNotFoundErrorCode,
 			StatusMsg:  strings.RelationNotFoundError,
@@ -351,7 +350,7 @@ func (r RelationServiceImpl) Unfollow(ctx context.Context, request *relation.Rel
 		return
 	}
 
-	tx := database.Client.WithContext(ctx).Begin()
+	tx := r.deps.DB.WithContext(ctx).Begin()
 	defer func() {
 		if err != nil {
 			tx.Rollback()
@@ -368,8 +367,30 @@ func (r RelationServiceImpl) Unfollow(ctx context.Context, request *relation.Rel
 		logging.SetSpanError(span, err)
 		return
 	}
+	// 审计记录与关系变更在同一个事务中写入 Outbox
+	if err = audit.EnqueueAuditEvent(ctx, tx, &models.Action{
+		Type:         strings.FollowIdActionLog,
+		Name:         strings.FollowNameActionLog,
+		SubName:      strings.FollowDownActionSubLog,
+		ServiceName:  strings.FollowServiceName,
+		ActorId:      request.ActorId,
+		VideoId:      0,
+		AffectUserId: request.UserId,
+		AffectAction: 1,
+		AffectedData: "-1",
+		EventId:      uuid.New().String(),
+		TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
+		SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
+	}); err != nil {
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.UnableToUnFollowErrorCode,
+			StatusMsg:  strings.UnableToUnFollowError,
+		}
+		logging.SetSpanError(span, err)
+		return
+	}
 
-	if err = updateFollowListCache(ctx, request.ActorId, rRelation, false, span, logger); err != nil {
+	if err = r.updateFollowListCache(ctx, request.ActorId, rRelation, false, span, logger); err != nil {
 		logger.WithFields(logrus.Fields{
 			"err": err,
 		}).Errorf("failed to update follow list cache")
@@ -377,7 +398,7 @@ func (r RelationServiceImpl) Unfollow(ctx context.Context, request *relation.Rel
 		return
 	}
 
-	if err = updateFollowerListCache(ctx, request.UserId, rRelation, false, span, logger); err != nil {
+	if err = r.updateFollowerListCache(ctx, request.UserId, rRelation, false, span, logger); err != nil {
 		logger.WithFields(logrus.Fields{
 			"err": err,
 		}).Errorf("failed to update follower list cache")
@@ -385,7 +406,7 @@ func (r RelationServiceImpl) Unfollow(ctx context.Context, request *relation.Rel
 		return
 	}
 
-	if err = updateFollowCountCache(ctx, request.ActorId, false, span, logger); err != nil {
+	if err = r.updateFollowCountCache(ctx, request.ActorId, false, span, logger); err != nil {
 		logger.WithFields(logrus.Fields{
 			"err": err,
 		}).Errorf("failed to update follow count cache")
@@ -393,7 +414,7 @@ func (r RelationServiceImpl) Unfollow(ctx context.Context, request *relation.Rel
 		return
 	}
 
-	if err = updateFollowerCountCache(ctx, request.UserId, false, span, logger); err != nil {
+	if err = r.updateFollowerCountCache(ctx, request.UserId, false, span, logger); err != nil {
 		logger.WithFields(logrus.Fields{
 			"err": err,
 		}).Errorf("failed to update follower count cache")
@@ -406,29 +427,6 @@ func (r RelationServiceImpl) Unfollow(ctx context.Context, request *relation.Rel
 		StatusMsg:  strings.ServiceOK,
 	}
 
-	// Publish event to event_exchange and audit_exchange
-	wg := sync.WaitGroup{}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		action := &models.Action{
-			Type:         strings.FollowIdActionLog,
-			Name:         strings.FollowNameActionLog,
-			SubName:      strings.FollowDownActionSubLog,
-			ServiceName:  strings.FollowServiceName,
-			ActorId:      request.ActorId,
-			VideoId:      0,
-			AffectUserId: request.UserId,
-			AffectAction: 1,
-			AffectedData: "-1",
-			EventId:      uuid.New().String(),
-			TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
-			SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
-		}
-		audit.PublishAuditEvent(ctx, action, channel)
-	}()
-	wg.Wait()
-
 	return
 }
 
@@ -500,7 +498,7 @@ func (r RelationServiceImpl) CountFollowList(ctx context.Context, request *relat
 	}
 
 	var count int64
-	result := database.Client.WithContext(ctx).
+	result := r.deps.DB.WithContext(ctx).
 		Model(&models.Relation{}).
 		Where("actor_id = ?", request.UserId).
 		Count(&count)
@@ -595,7 +593,7 @@ func (r RelationServiceImpl) CountFollowerList(ctx context.Context, request *rel
 	}
 
 	var count int64
-	result := database.Client.WithContext(ctx).
+	result := r.deps.DB.WithContext(ctx).
 		Model(&models.Relation{}).
 		Where("user_id = ?", request.UserId).
 		Count(&count)
@@ -640,7 +638,7 @@ func (r RelationServiceImpl) GetFriendList(ctx context.Context, request *relatio
 
 	//followList
 	cacheKey := config.EnvCfg.RedisPrefix + fmt.Sprintf("follow_list_%d", request.UserId)
-	followIdList, err := redis2.Client.SMembers(ctx, cacheKey).Result()
+	followIdList, err := r.deps.Redis.SMembers(ctx, cacheKey).Result()
 	var followRelationList []models.Relation
 	// 构建关注列表的用户 ID 映射
 	followingMap := make(map[uint32]bool)
@@ -659,7 +657,7 @@ func (r RelationServiceImpl) GetFriendList(ctx context.Context, request *relatio
 					"err": err,
 				}).Errorf("Redis exists illegal id %s", id)
 				logging.SetSpanError(span, err)
-				_, err := redis2.Client.Del(ctx, cacheKey).Result()
+				_, err := r.deps.Redis.Del(ctx, cacheKey).Result()
 				if err != nil {
 					logger.WithFields(logrus.Fields{
 						"id":  id,
@@ -679,7 +677,7 @@ func (r RelationServiceImpl) GetFriendList(ctx context.Context, request *relatio
 		}).Errorf("Err when read Redis or no data in Redis")
 		logging.SetSpanError(span, err)
 
-		followResult := database.Client.WithContext(ctx).
+		followResult := r.deps.DB.WithContext(ctx).
 			Where("actor_id = ?", request.UserId).
 			Find(&followRelationList)
 		if followResult.Error != nil {
@@ -699,13 +697,13 @@ func (r RelationServiceImpl) GetFriendList(ctx context.Context, request *relatio
 		}
 		// 写入缓存
 		for _, rel := range followRelationList {
-			redis2.Client.SAdd(ctx, cacheKey, rel.UserId)
+			r.deps.Redis.SAdd(ctx, cacheKey, rel.UserId)
 		}
 	}
 
 	//followerList
 	cacheKey = config.EnvCfg.RedisPrefix + fmt.Sprintf("follower_list_%d", request.UserId)
-	followerIdList, err := redis2.Client.SMembers(ctx, cacheKey).Result()
+	followerIdList, err := r.deps.Redis.SMembers(ctx, cacheKey).Result()
 	var followerRelationList []models.Relation
 	followerIdListInt := make([]uint32, len(followerIdList))
 	db = false
@@ -722,7 +720,7 @@ func (r RelationServiceImpl) GetFriendList(ctx context.Context, request *relatio
 					"err": err,
 				}).Errorf("Redis exists illegal id %s", id)
 				logging.SetSpanError(span, err)
-				_, err := redis2.Client.Del(ctx, cacheKey).Result()
+				_, err := r.deps.Redis.Del(ctx, cacheKey).Result()
 				if err != nil {
 					logger.WithFields(logrus.Fields{
 						"id":  id,
@@ -742,7 +740,7 @@ func (r RelationServiceImpl) GetFriendList(ctx context.Context, request *relatio
 		}).Errorf("Err when read Redis or no data in Redis")
 		logging.SetSpanError(span, err)
 
-		followerResult := database.Client.WithContext(ctx).
+		followerResult := r.deps.DB.WithContext(ctx).
 			Where("user_id = ?", request.UserId).
 			Find(&followerRelationList)
 		if followerResult.Error != nil {
@@ -761,51 +759,30 @@ func (r RelationServiceImpl) GetFriendList(ctx context.Context, request *relatio
 			followerIdListInt[index] = rel.ActorId
 		}
 		for _, rel := range followerRelationList {
-			redis2.Client.SAdd(ctx, cacheKey, rel.ActorId)
+			r.deps.Redis.SAdd(ctx, cacheKey, rel.ActorId)
 		}
 	}
 
-	// 构建互相关注的用户列表（既关注了关注者又被关注者所关注的用户）
-	mutualFriends := make([]*user.User, 0)
-
-	var mu sync.Mutex
-	var wg sync.WaitGroup
-
+	// 构建互相关注的用户列表（既关注了关注者又被关注者所关注的用户），通过一次批量查询获取用户信息
+	friendIdList := make([]uint32, 0)
 	for _, id := range followerIdListInt {
-		wg.Add(1)
-
-		go func(id uint32) {
-			defer wg.Done()
-
-			if followingMap[id] {
-
-				userResponse, err := userClient.GetUserInfo(ctx, &user.UserRequest{
-					UserId:  id,
-					ActorId: request.ActorId,
-				})
-
-				if err != nil || userResponse.StatusCode != strings.ServiceOKCode {
-					logger.WithFields(logrus.Fields{
-						"err":        err,
-						"followerId": id,
-					}).Errorf("Unable to get information about users who follow each other")
-					logging.SetSpanError(span, err)
-					resp = &relation.FriendListResponse{
-						StatusCode: strings.UnableToGetFriendListErrorCode,
-						StatusMsg:  strings.UnableToGetFriendListError,
-						UserList:   nil,
-					}
-				} else {
-					mu.Lock()
-					mutualFriends = append(mutualFriends, userResponse.User)
-					mu.Unlock()
-				}
-
-			}
-		}(id)
+		if followingMap[id] {
+			friendIdList = append(friendIdList, id)
+		}
 	}
 
-	wg.Wait()
+	mutualFriends, err := r.idList2UserList(ctx, friendIdList, request.ActorId, logger, span)
+	if err != nil {
+		resp = &relation.FriendListResponse{
+			StatusCode: strings.UnableToGetFriendListErrorCode,
+			StatusMsg:  strings.UnableToGetFriendListError,
+		}
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Unable to get information about users who follow each other")
+		logging.SetSpanError(span, err)
+		return
+	}
 
 	resp = &relation.FriendListResponse{
 		StatusCode: strings.ServiceOKCode,
@@ -825,7 +802,7 @@ func (r RelationServiceImpl) IsFollow(ctx context.Context, request *relation.IsF
 
 	res, err := cached.GetWithFunc(ctx, fmt.Sprintf("IsFollowedCache-%d-%d", request.UserId, request.ActorId), func(ctx context.Context, key string) (string, error) {
 		var count int64
-		row := database.Client.WithContext(ctx).
+		row := r.deps.DB.WithContext(ctx).
 			Model(&models.Relation{}).
 			Where("user_id = ? AND actor_id = ?", request.UserId, request.ActorId).
 			Count(&count)
@@ -833,7 +810,7 @@ func (r RelationServiceImpl) IsFollow(ctx context.Context, request *relation.IsF
 			return "false", row.Error
 		}
 		return strconv.FormatInt(count, 10), nil
-	})
+	}, cached.WithStaleWhileRevalidate(time.Hour, 5*time.Minute))
 
 	if err != nil {
 		logger.WithFields(logrus.Fields{
@@ -874,13 +851,35 @@ func (r RelationServiceImpl) GetFollowList(ctx context.Context, request *relatio
 		return
 	}
 
+	visible, err := r.canView(ctx, request.ActorId, request.UserId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to check the privacy of the user")
+		logging.SetSpanError(span, err)
+		resp = &relation.FollowListResponse{
+			StatusCode: strings.UnableToQueryUserErrorCode,
+			StatusMsg:  strings.UnableToQueryUserError,
+		}
+		return
+	}
+	if !visible {
+		resp = &relation.FollowListResponse{
+			StatusCode: strings.AccountPrivateCode,
+			StatusMsg:  strings.AccountPrivate,
+		}
+		return
+	}
+
 	cacheKey := config.EnvCfg.RedisPrefix + fmt.Sprintf("follow_list_%d", request.UserId)
-	followIdList, err := redis2.Client.SMembers(ctx, cacheKey).Result()
+	followIdList, err := r.deps.Redis.SMembers(ctx, cacheKey).Result()
 	followIdListInt := make([]uint32, 0, len(followIdList))
 	var followList []models.Relation
 
 	if err != nil {
-		result := database.Client.WithContext(ctx).
+		result := r.deps.DB.WithContext(ctx).
 			Where("actor_id = ?", request.UserId).
 			Order("created_at desc").
 			Find(&followList)
@@ -899,7 +898,7 @@ func (r RelationServiceImpl) GetFollowList(ctx context.Context, request *relatio
 		}
 
 		for index, rel := range followList {
-			redis2.Client.SAdd(ctx, cacheKey, rel.UserId)
+			r.deps.Redis.SAdd(ctx, cacheKey, rel.UserId)
 			followIdListInt[index] = rel.UserId
 		}
 	} else {
@@ -954,13 +953,35 @@ func (r RelationServiceImpl) GetFollowerList(ctx context.Context, request *relat
 		return
 	}
 
+	visible, err := r.canView(ctx, request.ActorId, request.UserId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to check the privacy of the user")
+		logging.SetSpanError(span, err)
+		resp = &relation.FollowerListResponse{
+			StatusCode: strings.UnableToQueryUserErrorCode,
+			StatusMsg:  strings.UnableToQueryUserError,
+		}
+		return
+	}
+	if !visible {
+		resp = &relation.FollowerListResponse{
+			StatusCode: strings.AccountPrivateCode,
+			StatusMsg:  strings.AccountPrivate,
+		}
+		return
+	}
+
 	cacheKey := config.EnvCfg.RedisPrefix + fmt.Sprintf("follower_list_%d", request.UserId)
-	followerIdList, err := redis2.Client.SMembers(ctx, cacheKey).Result()
+	followerIdList, err := r.deps.Redis.SMembers(ctx, cacheKey).Result()
 	followerIdListInt := make([]uint32, 0, len(followerIdList))
 	var followerList []models.Relation
 
 	if err != nil {
-		result := database.Client.WithContext(ctx).
+		result := r.deps.DB.WithContext(ctx).
 			Where("user_id = ?", request.UserId).
 			Order("created_at desc").
 			Find(&followerList)
@@ -979,7 +1000,7 @@ func (r RelationServiceImpl) GetFollowerList(ctx context.Context, request *relat
 		}
 
 		for index, rel := range followerList {
-			redis2.Client.SAdd(ctx, cacheKey, rel.UserId)
+			r.deps.Redis.SAdd(ctx, cacheKey, rel.UserId)
 			followerIdListInt[index] = rel.UserId
 		}
 	} else {
@@ -1021,60 +1042,39 @@ func (r RelationServiceImpl) GetFollowerList(ctx context.Context, request *relat
 
 func (r RelationServiceImpl) idList2UserList(ctx context.Context, idList []uint32, actorID uint32, logger *logrus.Entry, span trace.Span) ([]*user.User, error) {
 
-	var wg sync.WaitGroup
-	var mu sync.Mutex
-	var wgErrors []error
 	var err error
 
 	maxRetries := 3
 	retryInterval := 1
 
-	rUserList := make([]*user.User, 0, len(idList))
-
-	for _, id := range idList {
-		wg.Add(1)
-		go func(id uint32) {
-			defer wg.Done()
-
-			retryCount := 0
-			for retryCount < maxRetries {
-				userResponse, err := userClient.GetUserInfo(ctx, &user.UserRequest{
-					UserId:  id,
-					ActorId: actorID,
-				})
-
-				if err != nil || userResponse.StatusCode != strings.ServiceOKCode {
-					logger.WithFields(logrus.Fields{
-						"err":    err,
-						"userId": id,
-					}).Errorf("Unable to get user information")
-					retryCount++
-					time.Sleep(time.Duration(retryInterval) * time.Second)
-					continue
-				} else {
-					mu.Lock()
-					rUserList = append(rUserList, userResponse.User)
-					mu.Unlock()
-					break
-				}
-			}
-
-			if retryCount >= maxRetries {
-				logging.SetSpanError(span, err)
-			}
-		}(id)
+	if len(idList) == 0 {
+		return make([]*user.User, 0), nil
 	}
 
-	wg.Wait()
+	// 通过一次批量查询获取所有用户的信息，返回的顺序与 idList 一致
+	for retryCount := 0; retryCount < maxRetries; retryCount++ {
+		var usersResponse *user.UsersResponse
+		usersResponse, err = userClient.GetUserInfos(ctx, &user.UsersRequest{
+			UserIds: idList,
+			ActorId: actorID,
+		})
 
-	if len(wgErrors) > 0 {
+		if err == nil && usersResponse.StatusCode == strings.ServiceOKCode {
+			return usersResponse.Users, nil
+		}
+
+		if err == nil {
+			err = fmt.Errorf("user service returns status %d: %s", usersResponse.StatusCode, usersResponse.StatusMsg)
+		}
 		logger.WithFields(logrus.Fields{
-			"errorNum": wgErrors,
-		}).Errorf("%d user information fails to be queried", len(wgErrors))
-		return nil, fmt.Errorf("%d user information fails to be queried", len(wgErrors))
+			"err":     err,
+			"userIds": idList,
+		}).Errorf("Unable to get user information")
+		time.Sleep(time.Duration(retryInterval) * time.Second)
 	}
 
-	return rUserList, nil
+	logging.SetSpanError(span, err)
+	return nil, fmt.Errorf("%d user information fails to be queried", len(idList))
 }
 
 func string2Int(s []string, logger *logrus.Entry, span trace.Span) (i []uint32, err error) {
@@ -1099,14 +1099,14 @@ func string2Int(s []string, logger *logrus.Entry, span trace.Span) (i []uint32,
 // followOp = true  ->  follow
 // followOp = false ->  unfollow
 // set:actorID关注userID key:follow_list_actorID val:userID
-func updateFollowListCache(ctx context.Context, actorID uint32, relation models.Relation, followOp bool, span trace.Span, logger *logrus.Entry) (err error) {
+func (r RelationServiceImpl) updateFollowListCache(ctx context.Context, actorID uint32, relation models.Relation, followOp bool, span trace.Span, logger *logrus.Entry) (err error) {
 
 	cacheKey := config.EnvCfg.RedisPrefix + fmt.Sprintf("follow_list_%d", actorID)
 
 	if followOp {
-		_, err = redis2.Client.SAdd(ctx, cacheKey, relation.UserId).Result()
+		_, err = r.deps.Redis.SAdd(ctx, cacheKey, relation.UserId).Result()
 	} else {
-		_, err = redis2.Client.SRem(ctx, cacheKey, relation.UserId).Result()
+		_, err = r.deps.Redis.SRem(ctx, cacheKey, relation.UserId).Result()
 	}
 	if err != nil {
 		logger.WithFields(logrus.Fields{
@@ -1118,14 +1118,14 @@ func updateFollowListCache(ctx context.Context, actorID uint32, relation models.
 }
 
 // set:userID被关注actorID key:follower_list_userID val:actorID
-func updateFollowerListCache(ctx context.Context, userID uint32, relation models.Relation, followOp bool, span trace.Span, logger *logrus.Entry) (err error) {
+func (r RelationServiceImpl) updateFollowerListCache(ctx context.Context, userID uint32, relation models.Relation, followOp bool, span trace.Span, logger *logrus.Entry) (err error) {
 	cacheKey := config.EnvCfg.RedisPrefix + fmt.Sprintf("follower_list_%d", userID)
 
 	if followOp {
-		_, err = redis2.Client.SAdd(ctx, cacheKey, relation.ActorId).Result()
+		_, err = r.deps.Redis.SAdd(ctx, cacheKey, relation.ActorId).Result()
 
 	} else {
-		_, err = redis2.Client.SRem(ctx, cacheKey, relation.ActorId).Result()
+		_, err = r.deps.Redis.SRem(ctx, cacheKey, relation.ActorId).Result()
 	}
 	if err != nil {
 		logger.WithFields(logrus.Fields{
@@ -1137,7 +1137,7 @@ func updateFollowerListCache(ctx context.Context, userID uint32, relation models
 }
 
 // string: actorID关注数量 key: follow_count_actorID val: count±1
-func updateFollowCountCache(ctx context.Context, actorID uint32, followOp bool, span trace.Span, logger *logrus.Entry) error {
+func (r RelationServiceImpl) updateFollowCountCache(ctx context.Context, actorID uint32, followOp bool, span trace.Span, logger *logrus.Entry) error {
 	cacheKey := fmt.Sprintf("follow_count_%d", actorID)
 	var count uint32
 
@@ -1173,7 +1173,7 @@ func updateFollowCountCache(ctx context.Context, actorID uint32, followOp bool,
 	} else {
 		// not hit in cache
 		var dbCount int64
-		result := database.Client.WithContext(ctx).
+		result := r.deps.DB.WithContext(ctx).
 			Model(&models.Relation{}).
 			Where("actor_id = ?", actorID).
 			Count(&dbCount)
@@ -1208,7 +1208,7 @@ func updateFollowCountCache(ctx context.Context, actorID uint32, followOp bool,
 }
 
 // string: userID粉丝数量 key: follower_count_userID val: count±1
-func updateFollowerCountCache(ctx context.Context, userID uint32, followOp bool, span trace.Span, logger *logrus.Entry) error {
+func (r RelationServiceImpl) updateFollowerCountCache(ctx context.Context, userID uint32, followOp bool, span trace.Span, logger *logrus.Entry) error {
 	cacheKey := fmt.Sprintf("follower_count_%d", userID)
 	var count uint32
 
@@ -1244,7 +1244,7 @@ func updateFollowerCountCache(ctx context.Context, userID uint32, followOp bool,
 	} else {
 		// not hit in cache
 		var dbCount int64
-		result := database.Client.WithContext(ctx).
+		result := r.deps.DB.WithContext(ctx).
 			Model(&models.Relation{}).
 			Where("user_id = ?", userID).
 			Count(&dbCount)
@@ -1313,3 +1313,64 @@ func isUserExist(ctx context.Context, actorID uint32, userID uint32, span trace.
 	ok = true
 	return
 }
+
+// addFollow 在 tx 所在的事务中写入关注记录与审计记录，并更新关注相关的缓存
+func (r RelationServiceImpl) addFollow(ctx context.Context, tx *gorm.DB, rRelation models.Relation, span trace.Span, logger *logrus.Entry) (err error) {
+	if err = tx.Create(&rRelation).Error; err != nil {
+		logging.SetSpanError(span, err)
+		return
+	}
+	// 审计记录与关系变更在同一个事务中写入 Outbox
+	if err = audit.EnqueueAuditEvent(ctx, tx, &models.Action{
+		Type:         strings.FollowIdActionLog,
+		Name:         strings.FollowNameActionLog,
+		SubName:      strings.FollowUpActionSubLog,
+		ServiceName:  strings.FollowServiceName,
+		ActorId:      rRelation.ActorId,
+		VideoId:      0,
+		AffectUserId: rRelation.UserId,
+		AffectAction: 1,
+		AffectedData: "1",
+		EventId:      uuid.New().String(),
+		TraceId:      trace.SpanContextFromContext(ctx).TraceID().String(),
+		SpanId:       trace.SpanContextFromContext(ctx).SpanID().String(),
+	}); err != nil {
+		logging.SetSpanError(span, err)
+		return
+	}
+	// set: key:follow_list_actorID val:userID
+	if err = r.updateFollowListCache(ctx, rRelation.ActorId, rRelation, true, span, logger); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("failed to update follow list cache")
+		logging.SetSpanError(span, err)
+		return
+	}
+	// set: key:follower_list_userID val:actorID
+	if err = r.updateFollowerListCache(ctx, rRelation.UserId, rRelation, true, span, logger); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("failed to update follower list cache")
+		logging.SetSpanError(span, err)
+		return
+	}
+	// 关注+1
+	if err = r.updateFollowCountCache(ctx, rRelation.ActorId, true, span, logger); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("failed to update follow count cache")
+		logging.SetSpanError(span, err)
+		return
+	}
+	// 粉丝+1
+	if err = r.updateFollowerCountCache(ctx, rRelation.UserId, true, span, logger); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("failed to update follower count cache")
+		logging.SetSpanError(span, err)
+		return
+	}
+	// 先写入DB 再删除缓存
+	cached.TagDelete(ctx, fmt.Sprintf("IsFollowedCache-%d-%d", rRelation.UserId, rRelation.ActorId))
+	return
+}
@@ -5,15 +5,14 @@ import (
 	"GuGoTik/src/extra/profiling"
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/rpc/relation"
-	"GuGoTik/src/utils/audit"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
 	grpcprom "github.com/grpc-ecosystem/go-grpc-middleware/providers/prometheus"
 	"github.com/oklog/run"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
-	amqp "github.com/rabbitmq/amqp091-go"
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
@@ -25,10 +24,9 @@ import (
 	"syscall"
 )
 
-var conn = &amqp.Connection{}
-var channel = &amqp.Channel{}
-
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.RelationRpcServerName)
 
 	if err != nil {
@@ -75,15 +73,10 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.RelationRpcServerName, config.RelationRpcServerPort)
 
 	var srv RelationServiceImpl
+	srv.New(container)
 	relation.RegisterRelationServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
 
-	srv.New()
-
-	// Initialize the audit_exchange
-	audit.DeclareAuditExchange(channel)
-	defer CloseMQConn()
-
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/relation"
+	"GuGoTik/src/rpc/user"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/privacy"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// errFollowRequestNotFound 关注请求不存在或已经处理
+var errFollowRequestNotFound = errors.New("follow request not found")
+
+// cancelFollowRequest 撤回 actorId 对 userId 发出的关注请求，请求不存在时返回 false
+func (r RelationServiceImpl) cancelFollowRequest(ctx context.Context, actorId uint32, userId uint32) (bool, error) {
+	result := r.deps.DB.WithContext(ctx).
+		Where("actor_id = ? AND user_id = ?", actorId, userId).
+		Delete(&models.FollowRequest{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// canView 判断 actorId 能否查看 userId 的关注与粉丝列表，私密账号只对本人与粉丝可见
+func (r RelationServiceImpl) canView(ctx context.Context, actorId uint32, userId uint32) (bool, error) {
+	if actorId == userId {
+		return true, nil
+	}
+	userExist, err := userClient.GetUserExistInformation(ctx, &user.UserExistRequest{UserId: userId})
+	if err != nil {
+		return false, err
+	}
+	if userExist.StatusCode != strings.ServiceOKCode {
+		return false, fmt.Errorf("user service returns status %d: %s", userExist.StatusCode, userExist.StatusMsg)
+	}
+	return privacy.CanView(ctx, r.deps.DB, actorId, userId, userExist.IsPrivate)
+}
+
+// ListFollowRequests 列出等待当前用户同意的关注请求
+func (r RelationServiceImpl) ListFollowRequests(ctx context.Context, request *relation.FollowRequestListRequest) (resp *relation.FollowRequestListResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ListFollowRequestsService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.ListFollowRequests").WithContext(ctx)
+
+	var ids []uint32
+	if err = r.deps.DB.WithContext(ctx).
+		Model(&models.FollowRequest{}).
+		Where("user_id = ?", request.ActorId).
+		Order("id desc").
+		Pluck("actor_id", &ids).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+		}).Errorf("Failed to retrieve follow requests")
+		logging.SetSpanError(span, err)
+		resp = &relation.FollowRequestListResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+
+	userList, err := r.idList2UserList(ctx, ids, request.ActorId, logger, span)
+	if err != nil {
+		resp = &relation.FollowRequestListResponse{
+			StatusCode: strings.UnableToQueryUserErrorCode,
+			StatusMsg:  strings.UnableToQueryUserError,
+		}
+		return
+	}
+
+	resp = &relation.FollowRequestListResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		UserList:   userList,
+	}
+	return
+}
+
+// AcceptFollowRequest 同意 user_id 发出的关注请求，user_id 成为 actor_id 的粉丝
+func (r RelationServiceImpl) AcceptFollowRequest(ctx context.Context, request *relation.RelationActionRequest) (resp *relation.RelationActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "AcceptFollowRequestService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.AcceptFollowRequest").WithContext(ctx)
+
+	rRelation := models.Relation{
+		ActorId: request.UserId,  // 发出请求的用户
+		UserId:  request.ActorId, // 私密账号
+	}
+	err = r.deps.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("actor_id = ? AND user_id = ?", rRelation.ActorId, rRelation.UserId).Delete(&models.FollowRequest{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errFollowRequestNotFound
+		}
+
+		var count int64
+		if err := tx.Model(&models.Relation{}).Where("actor_id = ? AND user_id = ?", rRelation.ActorId, rRelation.UserId).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		return r.addFollow(ctx, tx, rRelation, span, logger)
+	})
+
+	if errors.Is(err, errFollowRequestNotFound) {
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.FollowRequestNotFoundCode,
+			StatusMsg:  strings.FollowRequestNotFound,
+		}
+		return resp, nil
+	}
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to accept the follow request")
+		logging.SetSpanError(span, err)
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.UnableToFollowErrorCode,
+			StatusMsg:  strings.UnableToFollowError,
+		}
+		return
+	}
+
+	resp = &relation.RelationActionResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
+
+// RejectFollowRequest 拒绝 user_id 发出的关注请求
+func (r RelationServiceImpl) RejectFollowRequest(ctx context.Context, request *relation.RelationActionRequest) (resp *relation.RelationActionResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "RejectFollowRequestService")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("RelationService.RejectFollowRequest").WithContext(ctx)
+
+	rejected, err := r.cancelFollowRequest(ctx, request.UserId, request.ActorId)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"ActorId": request.ActorId,
+			"UserId":  request.UserId,
+		}).Errorf("Failed to reject the follow request")
+		logging.SetSpanError(span, err)
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.RelationServiceIntErrorCode,
+			StatusMsg:  strings.RelationServiceIntError,
+		}
+		return
+	}
+	if !rejected {
+		resp = &relation.RelationActionResponse{
+			StatusCode: strings.FollowRequestNotFoundCode,
+			StatusMsg:  strings.FollowRequestNotFound,
+		}
+		return
+	}
+
+	resp = &relation.RelationActionResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+	}
+	return
+}
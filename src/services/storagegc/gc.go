@@ -0,0 +1,401 @@
+package main
+
+import (
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/storage/file"
+	"GuGoTik/src/utils/content"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/prom"
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 文件类型，用于日志与监控指标
+const (
+	kindTemp         = "temp"         // Upload 中断遗留的临时文件
+	kindIntermediate = "intermediate" // 已处理完成的视频的原始视频与音频
+	kindOrphan       = "orphan"       // 没有任何记录引用的文件，例如水印图片、上传失败的视频
+)
+
+// pathgen 生成的文件名均为 sha256 十六进制加后缀，GC 只处理这类文件，避免误删 FS_PATH 下的其他文件
+var managedFileName = regexp.MustCompile(`^[0-9a-f]{64}\.(mp4|png|mp3)$`)
+
+var (
+	reclaimedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gugotik",
+		Subsystem: "storage_gc",
+		Name:      "reclaimed_bytes_total",
+		Help:      "Bytes reclaimed by the storage garbage collector.",
+	}, []string{"kind", "dry_run"})
+	deletedFiles = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gugotik",
+		Subsystem: "storage_gc",
+		Name:      "deleted_files_total",
+		Help:      "Files deleted by the storage garbage collector.",
+	}, []string{"kind", "dry_run"})
+	failedFiles = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: "gugotik",
+		Subsystem: "storage_gc",
+		Name:      "failed_files_total",
+		Help:      "Files the storage garbage collector failed to delete.",
+	})
+	lastRunTimestamp = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: "gugotik",
+		Subsystem: "storage_gc",
+		Name:      "last_run_timestamp_seconds",
+		Help:      "Unix timestamp of the last finished storage garbage collection.",
+	})
+)
+
+func init() {
+	prom.Client.MustRegister(reclaimedBytes, deletedFiles, failedFiles, lastRunTimestamp)
+}
+
+// Collector 将存储中的文件与 RawVideo/Video/ContentObject 记录对照，删除不再需要的文件
+type Collector struct {
+	GracePeriod time.Duration // 修改时间在该时间段内的文件不会被删除
+	DryRun      bool          // 只记录将被删除的文件，不真正删除
+}
+
+// Report 一次 GC 的统计结果
+type Report struct {
+	Scanned        int
+	Deleted        int
+	Failed         int
+	ReclaimedBytes int64
+}
+
+// references 记录仍被引用的文件名，value 表示该文件是否为可回收的中间产物
+type references map[string]bool
+
+func (c *Collector) Run(ctx context.Context) (report Report, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "StorageGC-Run")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("StorageGC.Run").WithContext(ctx)
+	logger.WithFields(logrus.Fields{
+		"grace_period": c.GracePeriod.String(),
+		"dry_run":      c.DryRun,
+	}).Infof("Storage garbage collection start")
+
+	startedAt := time.Now()
+	deadline := startedAt.Add(-c.GracePeriod)
+	result, err := loadReferences(ctx, deadline)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Failed to load file references from database")
+		logging.SetSpanError(span, err)
+		return
+	}
+	refs := result.refs
+
+	if !c.DryRun {
+		// 没能释放的 RawVideo 在扫描之后有了对应的 Video 记录，保留它的原始视频
+		for _, name := range releaseStaleRawVideos(ctx, result.stale) {
+			refs[name] = false
+		}
+	}
+
+	dryRun := strconv.FormatBool(c.DryRun)
+	err = file.Walk(ctx, func(object file.ObjectInfo) error {
+		var kind string
+		switch {
+		case file.IsTempFile(object.Name):
+			kind = kindTemp
+		case managedFileName.MatchString(object.Name):
+			intermediate, referenced := refs[object.Name]
+			switch {
+			case !referenced:
+				kind = kindOrphan
+			case intermediate:
+				kind = kindIntermediate
+			default:
+				return nil
+			}
+		default:
+			return nil
+		}
+		report.Scanned++
+
+		if object.ModTime.After(deadline) {
+			return nil
+		}
+
+		fileLogger := logger.WithFields(logrus.Fields{
+			"file_name": object.Name,
+			"kind":      kind,
+			"size":      object.Size,
+			"mod_time":  object.ModTime,
+		})
+
+		checksum, shared := result.contents[object.Name]
+		if c.DryRun {
+			// 重复上传不会修改共享文件的修改时间，再次确认扫描之后没有新的引用
+			if shared {
+				touched, err := contentTouchedSince(ctx, checksum, startedAt)
+				if err != nil || touched {
+					return nil
+				}
+			}
+			fileLogger.Infof("Dry run, file would be deleted")
+		} else {
+			deleted, err := deleteFile(ctx, object.Name, shared, kind == kindOrphan, startedAt)
+			if err != nil {
+				fileLogger.WithFields(logrus.Fields{
+					"err": err,
+				}).Warnf("Failed to delete file")
+				report.Failed++
+				failedFiles.Inc()
+				return nil
+			}
+			if !deleted {
+				fileLogger.Debugf("Content is referenced again after scanning, skip")
+				return nil
+			}
+			fileLogger.Debugf("File deleted")
+		}
+
+		report.Deleted++
+		report.ReclaimedBytes += object.Size
+		deletedFiles.WithLabelValues(kind, dryRun).Inc()
+		reclaimedBytes.WithLabelValues(kind, dryRun).Add(float64(object.Size))
+		return nil
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Failed to walk the storage")
+		logging.SetSpanError(span, err)
+		return
+	}
+
+	lastRunTimestamp.SetToCurrentTime()
+	logger.WithFields(logrus.Fields{
+		"scanned":         report.Scanned,
+		"deleted":         report.Deleted,
+		"failed":          report.Failed,
+		"reclaimed_bytes": report.ReclaimedBytes,
+		"dry_run":         c.DryRun,
+	}).Infof("Storage garbage collection done")
+	return
+}
+
+// scanResult 一次扫描数据库得到的文件引用情况
+type scanResult struct {
+	refs     references
+	contents map[string]string // 按内容寻址保存的文件名到内容摘要的映射
+	stale    []models.RawVideo // 超过宽限期仍没有对应 Video 记录的 RawVideo
+}
+
+// loadReferences 读取所有 Video、RawVideo 与 ContentObject 记录引用的文件：
+// 视频成品与封面始终保留；原始视频与音频在转写完成后成为可回收的中间产物；
+// 没有对应 Video 记录的 RawVideo 在宽限期内视为正在处理，超过宽限期后视为上传或处理失败；
+// 原始视频按内容寻址保存，引用计数大于扫描到的 RawVideo 数量或在宽限期内被引用过的内容不会被回收
+func loadReferences(ctx context.Context, deadline time.Time) (*scanResult, error) {
+	ctx, span := tracing.Tracer.Start(ctx, "StorageGC-LoadReferences")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+
+	refs := make(references)
+	keep := func(name string) {
+		if name != "" {
+			refs[name] = false
+		}
+	}
+	release := func(name string) {
+		if _, ok := refs[name]; !ok && name != "" {
+			refs[name] = true
+		}
+	}
+
+	// key 为视频 Id，value 表示该视频是否已经处理完成
+	processed := make(map[uint32]bool)
+	var intermediates []string
+	var videos []models.Video
+	// 转写内容可能很长，这里只取第一个字符判断是否已经转写
+	result := database.Client.WithContext(ctx).
+		Select("id", "file_name", "cover_name", "audio_file_name", "left(transcript, 1) AS transcript").
+		FindInBatches(&videos, 500, func(tx *gorm.DB, batch int) error {
+			for _, video := range videos {
+				keep(video.FileName)
+				keep(video.CoverName)
+				done := video.FileName != "" && video.Transcript != ""
+				processed[video.ID] = done
+				if done {
+					intermediates = append(intermediates, video.AudioFileName)
+				} else {
+					keep(video.AudioFileName)
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		logging.SetSpanError(span, result.Error)
+		return nil, result.Error
+	}
+
+	// key 为内容摘要，value 为扫描到的仍然有效的 RawVideo 数量
+	live := make(map[string]int64)
+	var stale []models.RawVideo
+	var raws []models.RawVideo
+	result = database.Client.WithContext(ctx).
+		Select("id", "video_id", "file_name", "content_hash", "created_at").
+		FindInBatches(&raws, 500, func(tx *gorm.DB, batch int) error {
+			for _, raw := range raws {
+				done, ok := processed[raw.VideoId]
+				switch {
+				case !ok && raw.CreatedAt.Before(deadline):
+					stale = append(stale, raw)
+					continue
+				case !ok:
+					keep(raw.FileName)
+				case done:
+					intermediates = append(intermediates, raw.FileName)
+				default:
+					keep(raw.FileName)
+				}
+				if raw.ContentHash != "" {
+					live[raw.ContentHash]++
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		logging.SetSpanError(span, result.Error)
+		return nil, result.Error
+	}
+
+	// 引用计数包含了扫描到的失败上传，比较前需要先减去
+	for _, raw := range stale {
+		if raw.ContentHash != "" {
+			live[raw.ContentHash]--
+		}
+	}
+
+	contents := make(map[string]string)
+	var objects []models.ContentObject
+	result = database.Client.WithContext(ctx).
+		Select("checksum", "file_name", "ref_count", "updated_at").
+		FindInBatches(&objects, 500, func(tx *gorm.DB, batch int) error {
+			for _, object := range objects {
+				contents[object.FileName] = object.Checksum
+				// 扫描 RawVideo 之后又有新的上传引用了该内容
+				if object.UpdatedAt.After(deadline) || object.RefCount > live[object.Checksum] {
+					keep(object.FileName)
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		logging.SetSpanError(span, result.Error)
+		return nil, result.Error
+	}
+
+	// 中间产物只有在没有被其他记录保留时才会被回收
+	for _, name := range intermediates {
+		release(name)
+	}
+	return &scanResult{
+		refs:     refs,
+		contents: contents,
+		stale:    stale,
+	}, nil
+}
+
+// releaseStaleRawVideos 删除上传或处理失败的 RawVideo，并在同一事务中减少内容的引用计数。
+// 在扫描之后有了对应 Video 记录的 RawVideo 不会被删除，返回它们的原始视频文件名
+func releaseStaleRawVideos(ctx context.Context, stale []models.RawVideo) (kept []string) {
+	ctx, span := tracing.Tracer.Start(ctx, "StorageGC-ReleaseStaleRawVideos")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("StorageGC.ReleaseStaleRawVideos").WithContext(ctx)
+
+	for _, raw := range stale {
+		released := false
+		err := database.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			var count int64
+			if err := tx.Model(&models.Video{}).Where("id = ?", raw.VideoId).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return nil
+			}
+
+			var err error
+			released, err = content.ReleaseRawVideo(tx, raw.VideoId)
+			return err
+		})
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"err":      err,
+				"video_id": raw.VideoId,
+			}).Warnf("Failed to release stale raw video")
+			logging.SetSpanError(span, err)
+			released = false
+		}
+
+		if !released {
+			kept = append(kept, raw.FileName)
+			continue
+		}
+		logger.WithFields(logrus.Fields{
+			"video_id":  raw.VideoId,
+			"file_name": raw.FileName,
+		}).Debugf("Stale raw video released")
+	}
+	return
+}
+
+// contentTouchedSince 判断内容在 since 之后是否被新的上传引用
+func contentTouchedSince(ctx context.Context, checksum string, since time.Time) (bool, error) {
+	var objects []models.ContentObject
+	result := database.Client.WithContext(ctx).
+		Select("updated_at").
+		Where("checksum = ?", checksum).
+		Limit(1).
+		Find(&objects)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return len(objects) > 0 && objects[0].UpdatedAt.After(since), nil
+}
+
+// deleteFile 持有文件锁删除文件，删除前再次确认扫描之后没有新的上传引用了该内容：
+// 重复上传不会修改共享文件的修改时间，扫描时还没有内容记录的文件也可能在扫描之后被引用。
+// 没有任何引用的内容在文件删除后同时删除内容记录
+func deleteFile(ctx context.Context, fileName string, shared bool, orphan bool, since time.Time) (deleted bool, err error) {
+	checksum := strings.TrimSuffix(fileName, path.Ext(fileName))
+	err = database.Client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := content.Lock(tx, fileName); err != nil {
+			return err
+		}
+
+		var objects []models.ContentObject
+		if err := tx.Select("updated_at").Where("checksum = ?", checksum).Limit(1).Find(&objects).Error; err != nil {
+			return err
+		}
+		if len(objects) > 0 && (!shared || objects[0].UpdatedAt.After(since)) {
+			return nil
+		}
+
+		if err := file.Delete(ctx, fileName); err != nil {
+			return err
+		}
+		deleted = true
+		if !shared || !orphan {
+			return nil
+		}
+		return tx.Where("checksum = ? AND ref_count = 0", checksum).Delete(&models.ContentObject{}).Error
+	})
+	return
+}
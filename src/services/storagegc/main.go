@@ -0,0 +1,112 @@
+package main
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/utils/deps"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/prom"
+	"context"
+	"flag"
+	"github.com/oklog/run"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+)
+
+func main() {
+	// 默认以定时任务的方式运行，也可以使用 -once 作为管理命令执行一次
+	once := flag.Bool("once", false, "run the garbage collection once and exit")
+	dryRun := flag.Bool("dry-run", config.EnvCfg.StorageGCDryRun == "enable", "only report the files which would be deleted")
+	grace := flag.String("grace", config.EnvCfg.StorageGCGracePeriod, "files modified within this period will never be deleted")
+	flag.Parse()
+
+	deps.MustNew()
+
+	tp, err := tracing.SetTraceProvider(config.StorageGC)
+	if err != nil {
+		logging.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Panicf("Error to set the trace")
+	}
+	defer func() {
+		if err := tp.Shutdown(context.Background()); err != nil {
+			logging.Logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Errorf("Error to set the trace")
+		}
+	}()
+
+	log := logging.LogService(config.StorageGC)
+
+	gracePeriod, err := time.ParseDuration(*grace)
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"err":   err,
+			"grace": *grace,
+		}).Panicf("Invalid grace period")
+	}
+
+	collector := &Collector{
+		GracePeriod: gracePeriod,
+		DryRun:      *dryRun,
+	}
+
+	if *once {
+		if _, err := collector.Run(context.Background()); err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	cronRunner := cron.New(cron.WithSeconds(), cron.WithChain(cron.SkipIfStillRunning(cron.DefaultLogger)))
+	_, err = cronRunner.AddFunc(config.EnvCfg.StorageGCCron, func() {
+		_, _ = collector.Run(context.Background())
+	})
+	if err != nil {
+		log.WithFields(logrus.Fields{
+			"err":  err,
+			"cron": config.EnvCfg.StorageGCCron,
+		}).Panicf("Cannot start storage gc cron job")
+	}
+
+	g := &run.Group{}
+	g.Add(func() error {
+		cronRunner.Run()
+		return nil
+	}, func(error) {
+		<-cronRunner.Stop().Done()
+	})
+
+	httpSrv := &http.Server{Addr: config.EnvCfg.PodIpAddr + config.Metrics}
+	g.Add(func() error {
+		m := http.NewServeMux()
+		m.Handle("/metrics", promhttp.HandlerFor(
+			prom.Client,
+			promhttp.HandlerOpts{
+				EnableOpenMetrics: true,
+			},
+		))
+		httpSrv.Handler = m
+		log.Infof("Promethus now running")
+		return httpSrv.ListenAndServe()
+	}, func(error) {
+		if err := httpSrv.Close(); err != nil {
+			log.Errorf("Prometheus %s listen happens error for: %v", config.StorageGC, err)
+		}
+	})
+
+	g.Add(run.SignalHandler(context.Background(), syscall.SIGINT, syscall.SIGTERM))
+
+	log.Infof("%s is running now", config.StorageGC)
+	if err := g.Run(); err != nil {
+		log.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Error when running storage gc")
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/user"
+	"GuGoTik/src/utils/ptr"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// groupCount 按 column 分组统计 model 表中属于 ids 的记录数量，没有记录的 id 不在结果中
+func groupCount(db *gorm.DB, model interface{}, column string, ids []uint32) (map[uint32]uint32, error) {
+	var rows []struct {
+		Id    uint32
+		Count uint32
+	}
+	if err := db.Model(model).
+		Select(column+" AS id, count(*) AS count").
+		Where(column+" IN ?", ids).
+		Group(column).
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[uint32]uint32, len(rows))
+	for _, row := range rows {
+		counts[row.Id] = row.Count
+	}
+	return counts, nil
+}
+
+// fillUsersCounts 批量填充用户的关注、粉丝、作品、获赞与点赞数量以及 actorId 是否关注这些用户，
+// 每种数量只对所有用户执行一次分组查询，不再对每个用户分别调用下游服务
+func (a UserServiceImpl) fillUsersCounts(ctx context.Context, users []*user.User, actorId uint32) error {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]uint32, 0, len(users))
+	for _, u := range users {
+		ids = append(ids, u.Id)
+	}
+
+	db := a.deps.DB.WithContext(ctx)
+	var followCounts, followerCounts, workCounts, favoritedCounts, favoriteCounts map[uint32]uint32
+	for _, query := range []struct {
+		counts *map[uint32]uint32
+		model  interface{}
+		column string
+	}{
+		{counts: &followCounts, model: &models.Relation{}, column: "actor_id"},
+		{counts: &followerCounts, model: &models.Relation{}, column: "user_id"},
+		{counts: &workCounts, model: &models.Video{}, column: "user_id"},
+		{counts: &favoritedCounts, model: &models.Favorite{}, column: "author_id"},
+		{counts: &favoriteCounts, model: &models.Favorite{}, column: "user_id"},
+	} {
+		counts, err := groupCount(db, query.model, query.column, ids)
+		if err != nil {
+			return err
+		}
+		*query.counts = counts
+	}
+
+	following := make(map[uint32]bool)
+	if actorId != 0 {
+		var followIds []uint32
+		if err := db.Model(&models.Relation{}).
+			Where("actor_id = ? AND user_id IN ?", actorId, ids).
+			Pluck("user_id", &followIds).Error; err != nil {
+			return err
+		}
+		for _, id := range followIds {
+			following[id] = true
+		}
+	}
+
+	for _, u := range users {
+		u.FollowCount = ptr.Ptr(followCounts[u.Id])
+		u.FollowerCount = ptr.Ptr(followerCounts[u.Id])
+		u.WorkCount = ptr.Ptr(workCounts[u.Id])
+		u.TotalFavorited = ptr.Ptr(favoritedCounts[u.Id])
+		u.FavoriteCount = ptr.Ptr(favoriteCounts[u.Id])
+		u.IsFollow = following[u.Id]
+	}
+	return nil
+}
@@ -10,16 +10,18 @@ import (
 	"GuGoTik/src/rpc/relation"
 	"GuGoTik/src/rpc/user"
 	"GuGoTik/src/storage/cached"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"context"
 	"sync"
 
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
 type UserServiceImpl struct {
 	user.UserServiceServer
+	deps *deps.Container
 }
 
 var relationClient relation.RelationServiceClient
@@ -28,14 +30,52 @@ var publishClient publish.PublishServiceClient
 
 var favoriteClient favorite.FavoriteServiceClient
 
-func (a UserServiceImpl) New() {
-	relationConn := grpc2.Connect(config.RelationRpcServerName)
+// userDB 注入的数据库，用于不经过缓存的查询
+var userDB *gorm.DB
+
+// maxResolveUserNames ResolveUserNames 一次最多查询的用户名数量
+const maxResolveUserNames = 50
+
+// userInfoCache 用户信息的 Memory-Redis-DB 多级缓存，在 New 中使用注入的数据库创建
+var userInfoCache *cached.Cache[uint32, models.User]
+
+// userLoader 从数据库读取用户信息
+func userLoader(db *gorm.DB) cached.Loader[uint32, models.User] {
+	return func(ctx context.Context, userId uint32) (userModel models.User, found bool, err error) {
+		result := db.WithContext(ctx).Where("id = ?", userId).Limit(1).Find(&userModel)
+		return userModel, result.RowsAffected != 0, result.Error
+	}
+}
+
+// usersLoader 通过一次查询从数据库读取多个用户的信息
+func usersLoader(db *gorm.DB) cached.BatchLoader[uint32, models.User] {
+	return func(ctx context.Context, userIds []uint32) (map[uint32]models.User, error) {
+		var userModels []models.User
+		if err := db.WithContext(ctx).Where("id IN ?", userIds).Find(&userModels).Error; err != nil {
+			return nil, err
+		}
+
+		users := make(map[uint32]models.User, len(userModels))
+		for _, userModel := range userModels {
+			users[userModel.ID] = userModel
+		}
+		return users, nil
+	}
+}
+
+func (a *UserServiceImpl) New(container *deps.Container) {
+	a.deps = container
+	userDB = container.DB
+	userInfoCache = cached.New[uint32, models.User]("UserInfo", cached.JSONCodec[models.User]{}, userLoader(container.DB)).
+		WithBatchLoader(usersLoader(container.DB))
+
+	relationConn := container.Dial(config.RelationRpcServerName)
 	relationClient = relation.NewRelationServiceClient(relationConn)
 
-	publishConn := grpc2.Connect(config.PublishRpcServerName)
+	publishConn := container.Dial(config.PublishRpcServerName)
 	publishClient = publish.NewPublishServiceClient(publishConn)
 
-	favoriteConn := grpc2.Connect(config.FavoriteRpcServerName)
+	favoriteConn := container.Dial(config.FavoriteRpcServerName)
 	favoriteClient = favorite.NewFavoriteServiceClient(favoriteConn)
 }
 
@@ -46,9 +86,7 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 	logging.SetSpanWithHostname(span)
 	logger := logging.LogService("UserService.GetUserInfo").WithContext(ctx)
 
-	var userModel models.User
-	userModel.ID = request.UserId
-	ok, err := cached.ScanGet(ctx, "UserInfo", &userModel)
+	userModel, ok, err := userInfoCache.Get(ctx, request.UserId)
 
 	if err != nil {
 
@@ -71,6 +109,34 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 		return
 	}
 
+	// 与当前用户存在拉黑关系时不返回用户信息
+	if request.ActorId != 0 && request.ActorId != request.UserId {
+		blockResp, blockErr := relationClient.IsBlocked(ctx, &relation.IsBlockedRequest{
+			ActorId: request.ActorId,
+			UserId:  request.UserId,
+		})
+		if blockErr != nil || blockResp.StatusCode != strings.ServiceOKCode {
+			logger.WithFields(logrus.Fields{
+				"err":     blockErr,
+				"user":    request.UserId,
+				"ActorId": request.ActorId,
+			}).Errorf("Relation service error")
+			logging.SetSpanError(span, blockErr)
+			resp = &user.UserResponse{
+				StatusCode: strings.UserServiceInnerErrorCode,
+				StatusMsg:  strings.UserServiceInnerError,
+			}
+			return
+		}
+		if blockResp.Result {
+			resp = &user.UserResponse{
+				StatusCode: strings.UserBlockedCode,
+				StatusMsg:  strings.UserBlocked,
+			}
+			return
+		}
+	}
+
 	resp = &user.UserResponse{
 		StatusCode: strings.ServiceOKCode,
 		StatusMsg:  strings.ServiceOK,
@@ -86,20 +152,160 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 			TotalFavorited:  nil,
 			WorkCount:       nil,
 			FavoriteCount:   nil,
+			IsPrivate:       userModel.Private,
 		},
 	}
 
+	if fillUserCounts(ctx, logger, resp.User, request.ActorId) {
+		resp = &user.UserResponse{
+			StatusCode: strings.AuthServiceInnerErrorCode,
+			StatusMsg:  strings.AuthServiceInnerError,
+		}
+		return
+	}
+
+	return
+}
+
+// GetUserInfos 批量查询用户信息，用户信息通过一次缓存批量查询获取，不存在的用户以及与当前用户存在拉黑关系的用户会被跳过
+func (a UserServiceImpl) GetUserInfos(ctx context.Context, request *user.UsersRequest) (resp *user.UsersResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "GetUserInfos")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("UserService.GetUserInfos").WithContext(ctx)
+
+	userModels, err := userInfoCache.GetMany(ctx, request.UserIds)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"userIds": request.UserIds,
+		}).Errorf("Error when selecting user infos")
+		logging.SetSpanError(span, err)
+		resp = &user.UsersResponse{
+			StatusCode: strings.UserServiceInnerErrorCode,
+			StatusMsg:  strings.UserServiceInnerError,
+		}
+		return resp, nil
+	}
+
+	// 与当前用户存在拉黑关系的用户，通过一次查询获取
+	blocked := make(map[uint32]bool)
+	if request.ActorId != 0 {
+		hiddenResp, hiddenErr := relationClient.GetHiddenUsers(ctx, &relation.HiddenUsersRequest{
+			ActorId: request.ActorId,
+		})
+		if hiddenErr != nil || hiddenResp.StatusCode != strings.ServiceOKCode {
+			logger.WithFields(logrus.Fields{
+				"err":     hiddenErr,
+				"ActorId": request.ActorId,
+			}).Errorf("Relation service error")
+			logging.SetSpanError(span, hiddenErr)
+			resp = &user.UsersResponse{
+				StatusCode: strings.UserServiceInnerErrorCode,
+				StatusMsg:  strings.UserServiceInnerError,
+			}
+			return resp, nil
+		}
+		for _, id := range hiddenResp.BlockedIds {
+			blocked[id] = true
+		}
+	}
+
+	users := make([]*user.User, 0, len(request.UserIds))
+	added := make(map[uint32]bool, len(request.UserIds))
+	for _, userId := range request.UserIds {
+		userModel, ok := userModels[userId]
+		if !ok || added[userId] || blocked[userId] {
+			continue
+		}
+		added[userId] = true
+		users = append(users, &user.User{
+			Id:              userId,
+			Name:            userModel.UserName,
+			IsFollow:        false,
+			Avatar:          &userModel.Avatar,
+			BackgroundImage: &userModel.BackgroundImage,
+			Signature:       &userModel.Signature,
+			IsPrivate:       userModel.Private,
+		})
+	}
+
+	if err = a.fillUsersCounts(ctx, users, request.ActorId); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":     err,
+			"userIds": request.UserIds,
+		}).Errorf("Error when counting user infos")
+		logging.SetSpanError(span, err)
+		resp = &user.UsersResponse{
+			StatusCode: strings.UserServiceInnerErrorCode,
+			StatusMsg:  strings.UserServiceInnerError,
+		}
+		return resp, nil
+	}
+
+	resp = &user.UsersResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Users:      users,
+	}
+	return
+}
+
+func (a UserServiceImpl) GetUserExistInformation(ctx context.Context, request *user.UserExistRequest) (resp *user.UserExistResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "GetUserExisted")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("UserService.GetUserExisted").WithContext(ctx)
+
+	userModel, ok, err := userInfoCache.Get(ctx, request.UserId)
+
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Error when selecting user info")
+		logging.SetSpanError(span, err)
+		resp = &user.UserExistResponse{
+			StatusCode: strings.UserServiceInnerErrorCode,
+			StatusMsg:  strings.UserServiceInnerError,
+			Existed:    false,
+		}
+		return
+	}
+
+	if !ok {
+		resp = &user.UserExistResponse{
+			StatusCode: strings.ServiceOKCode,
+			StatusMsg:  strings.ServiceOK,
+			Existed:    false,
+		}
+		logger.WithFields(logrus.Fields{
+			"user": request.UserId,
+		}).Infof("User do not exist")
+		return
+	}
+
+	resp = &user.UserExistResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		Existed:    true,
+		IsPrivate:  userModel.Private,
+	}
+	return
+}
+
+// fillUserCounts 通过下游服务填充用户的关注、粉丝、作品、获赞与点赞数量以及 actorId 是否关注该用户，出现错误时返回 true
+func fillUserCounts(ctx context.Context, logger *logrus.Entry, u *user.User, actorId uint32) bool {
 	var wg sync.WaitGroup
 	wg.Add(6)
 	isErr := false
 
 	go func() {
 		defer wg.Done()
-		rResp, err := relationClient.CountFollowList(ctx, &relation.CountFollowListRequest{UserId: request.UserId})
+		rResp, err := relationClient.CountFollowList(ctx, &relation.CountFollowListRequest{UserId: u.Id})
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"err":    err,
-				"userId": request.UserId,
+				"userId": u.Id,
 			}).Errorf("Error when user service get follow list")
 			isErr = true
 			return
@@ -109,23 +315,23 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 			if err != nil {
 				logger.WithFields(logrus.Fields{
 					"errMsg": rResp.StatusMsg,
-					"userId": request.UserId,
+					"userId": u.Id,
 				}).Errorf("Error when user service get follow list")
 				isErr = true
 				return
 			}
 		}
 
-		resp.User.FollowCount = &rResp.Count
+		u.FollowCount = &rResp.Count
 	}()
 
 	go func() {
 		defer wg.Done()
-		rResp, err := relationClient.CountFollowerList(ctx, &relation.CountFollowerListRequest{UserId: request.UserId})
+		rResp, err := relationClient.CountFollowerList(ctx, &relation.CountFollowerListRequest{UserId: u.Id})
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"err":    err,
-				"userId": request.UserId,
+				"userId": u.Id,
 			}).Errorf("Error when user service get follower list")
 			isErr = true
 			return
@@ -135,26 +341,26 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 			if err != nil {
 				logger.WithFields(logrus.Fields{
 					"errMsg": rResp.StatusMsg,
-					"userId": request.UserId,
+					"userId": u.Id,
 				}).Errorf("Error when user service get follower list")
 				isErr = true
 				return
 			}
 		}
 
-		resp.User.FollowerCount = &rResp.Count
+		u.FollowerCount = &rResp.Count
 	}()
 
 	go func() {
 		defer wg.Done()
 		rResp, err := relationClient.IsFollow(ctx, &relation.IsFollowRequest{
-			ActorId: request.ActorId,
-			UserId:  request.UserId,
+			ActorId: actorId,
+			UserId:  u.Id,
 		})
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"err":    err,
-				"userId": request.UserId,
+				"userId": u.Id,
 			}).Errorf("Error when user service get is follow")
 			isErr = true
 			return
@@ -164,23 +370,23 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 			if err != nil {
 				logger.WithFields(logrus.Fields{
 					"errMsg": rResp.StatusMsg,
-					"userId": request.UserId,
+					"userId": u.Id,
 				}).Errorf("Error when user service get is follow")
 				isErr = true
 				return
 			}
 		}
 
-		resp.User.IsFollow = rResp.Result
+		u.IsFollow = rResp.Result
 	}()
 
 	go func() {
 		defer wg.Done()
-		rResp, err := publishClient.CountVideo(ctx, &publish.CountVideoRequest{UserId: request.UserId})
+		rResp, err := publishClient.CountVideo(ctx, &publish.CountVideoRequest{UserId: u.Id})
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"err":    err,
-				"userId": request.UserId,
+				"userId": u.Id,
 			}).Errorf("Error when user service get published count")
 			isErr = true
 			return
@@ -190,26 +396,26 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 			if err != nil {
 				logger.WithFields(logrus.Fields{
 					"errMsg": rResp.StatusMsg,
-					"userId": request.UserId,
+					"userId": u.Id,
 				}).Errorf("Error when user service get published count")
 				isErr = true
 				return
 			}
 		}
 
-		resp.User.WorkCount = &rResp.Count
+		u.WorkCount = &rResp.Count
 	}()
 
 	go func() {
 		defer wg.Done()
 		rResp, err := favoriteClient.CountUserTotalFavorited(ctx, &favorite.CountUserTotalFavoritedRequest{
-			ActorId: request.ActorId,
-			UserId:  request.UserId,
+			ActorId: actorId,
+			UserId:  u.Id,
 		})
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"err":    err,
-				"userId": request.UserId,
+				"userId": u.Id,
 			}).Errorf("Error when user service get toal favorited")
 			isErr = true
 			return
@@ -219,23 +425,23 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 			if err != nil {
 				logger.WithFields(logrus.Fields{
 					"errMsg": rResp.StatusMsg,
-					"userId": request.UserId,
+					"userId": u.Id,
 				}).Errorf("Error when user service get toal favorited")
 				isErr = true
 				return
 			}
 		}
 
-		resp.User.TotalFavorited = &rResp.Count
+		u.TotalFavorited = &rResp.Count
 	}()
 
 	go func() {
 		defer wg.Done()
-		rResp, err := favoriteClient.CountUserFavorite(ctx, &favorite.CountUserFavoriteRequest{UserId: request.UserId})
+		rResp, err := favoriteClient.CountUserFavorite(ctx, &favorite.CountUserFavoriteRequest{UserId: u.Id})
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"err":    err,
-				"userId": request.UserId,
+				"userId": u.Id,
 			}).Errorf("Error when user service get favorite")
 			isErr = true
 			return
@@ -245,68 +451,100 @@ func (a UserServiceImpl) GetUserInfo(ctx context.Context, request *user.UserRequ
 			if err != nil {
 				logger.WithFields(logrus.Fields{
 					"errMsg": rResp.StatusMsg,
-					"userId": request.UserId,
+					"userId": u.Id,
 				}).Errorf("Error when user service get favorite")
 				isErr = true
 				return
 			}
 		}
 
-		resp.User.FavoriteCount = &rResp.Count
+		u.FavoriteCount = &rResp.Count
 	}()
 
 	wg.Wait()
 
-	if isErr {
-		resp = &user.UserResponse{
-			StatusCode: strings.AuthServiceInnerErrorCode,
-			StatusMsg:  strings.AuthServiceInnerError,
-		}
+	return isErr
+}
+
+// ResolveUserNames 通过用户名查询用户 id，用于解析评论与消息中的 @
+func (a UserServiceImpl) ResolveUserNames(ctx context.Context, request *user.UserNamesRequest) (resp *user.UserNamesResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "ResolveUserNames")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("UserService.ResolveUserNames").WithContext(ctx)
+
+	names := request.UserNames
+	if len(names) > maxResolveUserNames {
+		names = names[:maxResolveUserNames]
+	}
+
+	resp = &user.UserNamesResponse{
+		StatusCode: strings.ServiceOKCode,
+		StatusMsg:  strings.ServiceOK,
+		UserIds:    make(map[string]uint32, len(names)),
+	}
+	if len(names) == 0 {
 		return
 	}
 
+	var users []models.User
+	if err = userDB.WithContext(ctx).Select("id", "user_name").Where("user_name IN ?", names).Find(&users).Error; err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":   err,
+			"names": names,
+		}).Errorf("Error when resolving user names")
+		logging.SetSpanError(span, err)
+		resp = &user.UserNamesResponse{
+			StatusCode: strings.UserServiceInnerErrorCode,
+			StatusMsg:  strings.UserServiceInnerError,
+		}
+		return
+	}
+	for _, u := range users {
+		resp.UserIds[u.UserName] = u.ID
+	}
 	return
 }
 
-func (a UserServiceImpl) GetUserExistInformation(ctx context.Context, request *user.UserExistRequest) (resp *user.UserExistResponse, err error) {
-	ctx, span := tracing.Tracer.Start(ctx, "GetUserExisted")
+// SetPrivacy 切换账号的私密状态，切换为公开后已有的关注请求仍需要处理
+func (a UserServiceImpl) SetPrivacy(ctx context.Context, request *user.PrivacyRequest) (resp *user.PrivacyResponse, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "SetPrivacy")
 	defer span.End()
 	logging.SetSpanWithHostname(span)
-	logger := logging.LogService("UserService.GetUserExisted").WithContext(ctx)
-
-	var userModel models.User
-	userModel.ID = request.UserId
-	ok, err := cached.ScanGet(ctx, "UserInfo", &userModel)
-
+	logger := logging.LogService("UserService.SetPrivacy").WithContext(ctx)
+
+	var rowsAffected int64
+	err = userInfoCache.Update(ctx, request.ActorId, func(ctx context.Context) error {
+		result := userDB.WithContext(ctx).Model(&models.User{}).
+			Where("id = ?", request.ActorId).
+			Update("private", request.Private)
+		rowsAffected = result.RowsAffected
+		return result.Error
+	})
 	if err != nil {
 		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Errorf("Error when selecting user info")
+			"err":     err,
+			"ActorId": request.ActorId,
+			"private": request.Private,
+		}).Errorf("Error when updating the privacy of the user")
 		logging.SetSpanError(span, err)
-		resp = &user.UserExistResponse{
+		resp = &user.PrivacyResponse{
 			StatusCode: strings.UserServiceInnerErrorCode,
 			StatusMsg:  strings.UserServiceInnerError,
-			Existed:    false,
 		}
 		return
 	}
-
-	if !ok {
-		resp = &user.UserExistResponse{
-			StatusCode: strings.ServiceOKCode,
-			StatusMsg:  strings.ServiceOK,
-			Existed:    false,
+	if rowsAffected == 0 {
+		resp = &user.PrivacyResponse{
+			StatusCode: strings.UserNotExistedCode,
+			StatusMsg:  strings.UserNotExisted,
 		}
-		logger.WithFields(logrus.Fields{
-			"user": request.UserId,
-		}).Infof("User do not exist")
 		return
 	}
 
-	resp = &user.UserExistResponse{
+	resp = &user.PrivacyResponse{
 		StatusCode: strings.ServiceOKCode,
 		StatusMsg:  strings.ServiceOK,
-		Existed:    true,
 	}
 	return
 }
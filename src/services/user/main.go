@@ -6,8 +6,8 @@ import (
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/models"
 	"GuGoTik/src/rpc/user"
-	"GuGoTik/src/storage/database"
 	"GuGoTik/src/utils/consul"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/prom"
 	"context"
@@ -19,6 +19,7 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
+	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 	"net"
 	"net/http"
@@ -27,6 +28,8 @@ import (
 )
 
 func main() {
+	container := deps.MustNew()
+
 	tp, err := tracing.SetTraceProvider(config.UserRpcServerName)
 
 	if err != nil {
@@ -73,10 +76,10 @@ func main() {
 	log.Infof("Rpc %s is running at %s now", config.UserRpcServerName, config.UserRpcServerPort)
 
 	var srv UserServiceImpl
+	srv.New(container)
 	user.RegisterUserServiceServer(s, srv)
 	grpc_health_v1.RegisterHealthServer(s, health.NewServer())
-	srv.New()
-	createMagicUser()
+	createMagicUser(container.DB)
 	srvMetrics.InitializeMetrics(s)
 
 	g := &run.Group{}
@@ -116,17 +119,17 @@ func main() {
 	}
 }
 
-func createMagicUser() {
+func createMagicUser(db *gorm.DB) {
 	// Create magic user: show video summary and keywords, and act as ChatGPT
 	magicUser := models.User{
 		UserName:        "ChatGPT",
 		Password:        "chatgpt",
-		Role:            2,
+		Role:            models.UserRoleMagic,
 		Avatar:          "https://maples31-blog.oss-cn-beijing.aliyuncs.com/img/ChatGPT_logo.svg.png",
 		BackgroundImage: "https://maples31-blog.oss-cn-beijing.aliyuncs.com/img/ChatGPT.jpg",
 		Signature:       "GuGoTik 小助手",
 	}
-	result := database.Client.Clauses(clause.OnConflict{
+	result := db.Clauses(clause.OnConflict{
 		Columns:   []clause.Column{{Name: "user_name"}},
 		DoUpdates: clause.AssignmentColumns([]string{"password", "role", "avatar", "background_image", "signature"}),
 	}).Create(&magicUser)
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/utils/logging"
+	"context"
+)
+
+// findProcessedDuplicate 查找与 raw 内容相同且已经处理过的视频，优先返回已经完成转写的视频，不存在时返回 nil
+func findProcessedDuplicate(ctx context.Context, raw *models.RawVideo) (*models.Video, error) {
+	ctx, span := tracing.Tracer.Start(ctx, "FindProcessedDuplicate")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+
+	if raw.ContentHash == "" {
+		return nil, nil
+	}
+
+	var videos []models.Video
+	result := database.Client.WithContext(ctx).
+		Where("content_hash = ? AND id <> ? AND file_name <> ''", raw.ContentHash, raw.VideoId).
+		Order("transcript <> '' DESC, created_at").
+		Limit(1).
+		Find(&videos)
+	if result.Error != nil {
+		logging.SetSpanError(span, result.Error)
+		return nil, result.Error
+	}
+
+	if len(videos) == 0 {
+		return nil, nil
+	}
+	return &videos[0], nil
+}
@@ -7,6 +7,7 @@ import (
 	"GuGoTik/src/models"
 	"GuGoTik/src/storage/database"
 	"GuGoTik/src/storage/file"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/pathgen"
 	"GuGoTik/src/utils/rabbitmq"
@@ -36,7 +37,9 @@ func exitOnError(err error) {
 }
 
 func main() {
-	conn, err := amqp.Dial(rabbitmq.BuildMQConnAddr())
+	container := deps.MustNew()
+
+	conn, err := container.DialMQ()
 	exitOnError(err)
 
 	defer func(conn *amqp.Connection) {
@@ -129,7 +132,7 @@ func main() {
 	logger = logging.LogService("VideoSummary")
 	logger.Infof(strings.VideoSummary + " is running now")
 
-	ConnectServiceClient()
+	ConnectServiceClient(container)
 	defer CloseMQConn()
 
 	wg := sync.WaitGroup{}
@@ -157,42 +160,69 @@ func Consume(channel *amqp.Channel) {
 			continue
 		}
 
-		// 截取封面
-		err := extractVideoCover(ctx, &raw)
+		// 查找相同内容且已经处理过的视频，复用它的处理结果
+		duplicate, err := findProcessedDuplicate(ctx, &raw)
 		if err != nil {
 			logger.WithFields(logrus.Fields{
 				"err": err,
-			}).Errorf("Error when extracting video cover.")
+			}).Errorf("Error when finding processed duplicate video.")
 			logging.SetSpanError(span, err)
 		}
-		// 获取视频水印
-		watermarkPNGName, err := textWatermark(ctx, &raw)
-		if err != nil {
+
+		coverName := raw.CoverName
+		if duplicate != nil && duplicate.CoverName != "" {
+			coverName = duplicate.CoverName
 			logger.WithFields(logrus.Fields{
-				"err": err,
-			}).Errorf("Error when generate watermark png.")
-			logging.SetSpanError(span, err)
+				"duplicate_video_id": duplicate.ID,
+			}).Debug("Reuse the cover of duplicate video")
+		} else {
+			// 截取封面
+			err = extractVideoCover(ctx, &raw)
+			if err != nil {
+				logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Errorf("Error when extracting video cover.")
+				logging.SetSpanError(span, err)
+			}
 		}
-		// 添加水印逻辑
-		err = addWatermarkToVideo(ctx, &raw, watermarkPNGName)
-		if err != nil {
+
+		finalFileName := pathgen.GenerateFinalVideoName(raw.ActorId, raw.Title, raw.VideoId)
+		// 水印与作者相关，只有同一作者的重复视频才能复用成品视频
+		if duplicate != nil && duplicate.UserId == raw.ActorId {
+			finalFileName = duplicate.FileName
 			logger.WithFields(logrus.Fields{
-				"err": err,
-			}).Errorf("Error when adding watermark to video.")
-			logging.SetSpanError(span, err)
+				"duplicate_video_id": duplicate.ID,
+			}).Debug("Reuse the watermarked file of duplicate video")
+		} else {
+			// 获取视频水印
+			watermarkPNGName, err := textWatermark(ctx, &raw)
+			if err != nil {
+				logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Errorf("Error when generate watermark png.")
+				logging.SetSpanError(span, err)
+			}
+			// 添加水印逻辑
+			err = addWatermarkToVideo(ctx, &raw, watermarkPNGName)
+			if err != nil {
+				logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Errorf("Error when adding watermark to video.")
+				logging.SetSpanError(span, err)
+			}
 		}
 		// 保存到数据库
-		finalFileName := pathgen.GenerateFinalVideoName(raw.ActorId, raw.Title, raw.VideoId)
 		video := &models.Video{
-			ID:        raw.VideoId,
-			UserId:    raw.ActorId,
-			Title:     raw.Title,
-			FileName:  finalFileName,
-			CoverName: raw.CoverName,
+			ID:          raw.VideoId,
+			UserId:      raw.ActorId,
+			Title:       raw.Title,
+			FileName:    finalFileName,
+			CoverName:   coverName,
+			ContentHash: raw.ContentHash,
 		}
 		result := database.Client.Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "id"}},
-			DoUpdates: clause.AssignmentColumns([]string{"user_id", "title", "file_name", "cover_name"}),
+			DoUpdates: clause.AssignmentColumns([]string{"user_id", "title", "file_name", "cover_name", "content_hash"}),
 		}).Create(&video)
 		if result.Error != nil {
 			logger.WithFields(logrus.Fields{
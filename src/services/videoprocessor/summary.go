@@ -9,7 +9,7 @@ import (
 	"GuGoTik/src/rpc/user"
 	"GuGoTik/src/storage/database"
 	"GuGoTik/src/storage/file"
-	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/deps"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/utils/pathgen"
 	"GuGoTik/src/utils/rabbitmq"
@@ -56,15 +56,15 @@ func init() {
 	openaiClient = openai.NewClientWithConfig(cfg)
 }
 
-func ConnectServiceClient() {
-	userRpcConn := grpc2.Connect(config.UserRpcServerName)
+func ConnectServiceClient(container *deps.Container) {
+	userRpcConn := container.Dial(config.UserRpcServerName)
 	userClient = user.NewUserServiceClient(userRpcConn)
-	commentRpcConn := grpc2.Connect(config.CommentRpcServerName)
+	commentRpcConn := container.Dial(config.CommentRpcServerName)
 	commentClient = comment.NewCommentServiceClient(commentRpcConn)
 
 	var err error
 
-	conn, err = amqp.Dial(rabbitmq.BuildMQConnAddr())
+	conn, err = container.DialMQ()
 	exitOnError(err)
 
 	channel, err = conn.Channel()
@@ -214,10 +214,28 @@ func SummaryConsume(channel *amqp.Channel) {
 			"RawVideo": raw.VideoId,
 		}).Debugf("Receive message of video %d", raw.VideoId)
 
+		// 查找相同内容且已经完成转写的视频，复用它的转写、总结与关键词，避免重复调用 ChatGPT
+		duplicate, err := findProcessedDuplicate(ctx, &raw)
+		if err != nil {
+			logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Errorf("Error when finding processed duplicate video.")
+			logging.SetSpanError(span, err)
+		}
+		if duplicate != nil && duplicate.Transcript == "" {
+			duplicate = nil
+		}
+
 		// Video -> Audio
 		audioFileName := pathgen.GenerateAudioName(raw.FileName)
 		isAudioFileExist, _ := file.IsFileExist(ctx, audioFileName)
-		if !isAudioFileExist {
+		if duplicate != nil {
+			audioFileName = duplicate.AudioFileName
+			logger.WithFields(logrus.Fields{
+				"VideoId":          raw.VideoId,
+				"DuplicateVideoId": duplicate.ID,
+			}).Debugf("Video %d reuses the result of duplicate video %d", raw.VideoId, duplicate.ID)
+		} else if !isAudioFileExist {
 			audioFileName, err = video2Audio(ctx, raw.FileName)
 			if err != nil {
 				logger.WithFields(logrus.Fields{
@@ -263,7 +281,9 @@ func SummaryConsume(channel *amqp.Channel) {
 					"VideoId": raw.VideoId,
 				}).Errorf("Faild to get transcript of video %d from database", raw.VideoId)
 		}
-		if !transcriptExist {
+		if !transcriptExist && duplicate != nil {
+			transcript = duplicate.Transcript
+		} else if !transcriptExist {
 			transcript, err = speech2Text(ctx, audioFileName)
 			if err != nil {
 				logger.WithFields(logrus.Fields{
@@ -298,7 +318,11 @@ func SummaryConsume(channel *amqp.Channel) {
 					"VideoId": raw.VideoId,
 				}).Errorf("Faild to get summary of video %d from database", raw.VideoId)
 		}
-		if !summaryExist {
+		summaryPending := false
+		if !summaryExist && duplicate != nil && duplicate.Summary != "" {
+			summary = duplicate.Summary
+		} else if !summaryExist {
+			summaryPending = true
 			go text2Summary(ctx, transcript, &summaryChannel, &summaryErrChannel)
 		} else {
 			logger.WithFields(logrus.Fields{
@@ -317,7 +341,11 @@ func SummaryConsume(channel *amqp.Channel) {
 					"VideoId": raw.VideoId,
 				}).Errorf("Faild to get keywords of video %d from database", raw.VideoId)
 		}
-		if !keywordsExist {
+		keywordsPending := false
+		if !keywordsExist && duplicate != nil && duplicate.Keywords != "" {
+			keywords = duplicate.Keywords
+		} else if !keywordsExist {
+			keywordsPending = true
 			go text2Keywords(ctx, transcript, &keywordsChannel, &keywordsErrChannel)
 		} else {
 			logger.WithFields(logrus.Fields{
@@ -327,7 +355,7 @@ func SummaryConsume(channel *amqp.Channel) {
 
 		summaryOrKeywordsErr := false
 
-		if !summaryExist {
+		if summaryPending {
 			select {
 			case summary = <-summaryChannel:
 			case err = <-summaryErrChannel:
@@ -341,7 +369,7 @@ func SummaryConsume(channel *amqp.Channel) {
 			}
 		}
 
-		if !keywordsExist {
+		if keywordsPending {
 			select {
 			case keywords = <-keywordsChannel:
 			case err = <-keywordsErrChannel:
@@ -0,0 +1,186 @@
+package cached
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/storage/redis"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/prom"
+	"context"
+	"encoding/json"
+	"github.com/google/uuid"
+	"github.com/patrickmn/go-cache"
+	redis2 "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// 向订阅连接发送 Ping 的间隔
+	invalidationPingInterval = 10 * time.Second
+	// 超过该时间没有收到任何消息（包括 Pong）视为广播通道不可用
+	invalidationReceiveTimeout = 3 * invalidationPingInterval
+	// 广播通道不可用时重试订阅的间隔
+	invalidationRetryInterval = time.Second
+)
+
+// 当前进程的标识，忽略自己发出的失效消息
+var instanceId = uuid.New().String()
+
+// 只有在失效广播通道可用时才启用本地缓存，否则其他副本的写入无法通知到本进程
+var localEnabled atomic.Bool
+
+var startListenerOnce sync.Once
+
+// invalidationMessage 通过 Redis Pub/Sub 广播的缓存失效消息
+type invalidationMessage struct {
+	Origin string `json:"origin"` // 发出消息的进程
+	Cache  string `json:"cache"`  // 本地缓存名称
+	Key    string `json:"key"`    // 带有前缀的 key
+}
+
+// localCache 包装 go-cache，在广播通道不可用时不读写本地缓存
+type localCache struct {
+	name   string
+	policy Policy
+	items  *cache.Cache
+}
+
+func (l *localCache) Get(key string) (interface{}, bool) {
+	if !localEnabled.Load() {
+		return nil, false
+	}
+	value, ok := l.items.Get(key)
+	if ok {
+		observe(l.name, layerLocal, resultHit)
+	} else {
+		observe(l.name, layerLocal, resultMiss)
+	}
+	return value, ok
+}
+
+func (l *localCache) Set(key string, value interface{}, d time.Duration) {
+	if !localEnabled.Load() {
+		return
+	}
+	if l.policy.MaxEntries > 0 && l.items.ItemCount() >= l.policy.MaxEntries {
+		if _, ok := l.items.Get(key); !ok {
+			// 先清理已过期的条目，仍然没有空间时放弃写入本地缓存，读取会 Fallback 到 Redis
+			l.items.DeleteExpired()
+			if l.items.ItemCount() >= l.policy.MaxEntries {
+				prom.CacheLocalRejected.WithLabelValues(l.name).Inc()
+				return
+			}
+		}
+	}
+	l.items.Set(key, value, d)
+}
+
+// Delete 只删除本进程的本地缓存
+func (l *localCache) Delete(key string) {
+	l.items.Delete(key)
+}
+
+// Invalidate 删除本进程的本地缓存，并通知其他副本删除
+func (l *localCache) Invalidate(ctx context.Context, key string) {
+	l.items.Delete(key)
+	publishInvalidation(ctx, l.name, key)
+}
+
+func publishInvalidation(ctx context.Context, name string, key string) {
+	data, err := json.Marshal(invalidationMessage{
+		Origin: instanceId,
+		Cache:  name,
+		Key:    key,
+	})
+	if err != nil {
+		return
+	}
+
+	if err := redis.Client.Publish(ctx, config.CacheInvalidationRedisChannel, data).Err(); err != nil {
+		logging.LogService("Cached.PublishInvalidation").WithContext(ctx).WithFields(logrus.Fields{
+			"err":   err,
+			"cache": name,
+			"key":   key,
+		}).Errorf("Failed to broadcast cache invalidation")
+	}
+}
+
+// flushLocalCaches 清空所有本地缓存，广播通道恢复前可能错过了失效消息
+func flushLocalCaches() {
+	m.Lock()
+	defer m.Unlock()
+	for _, c := range cacheMaps {
+		c.items.Flush()
+	}
+}
+
+func setLocalEnabled(enabled bool) {
+	if localEnabled.Swap(enabled) == enabled {
+		return
+	}
+	flushLocalCaches()
+	logger := logging.LogService("Cached.InvalidationListener")
+	if enabled {
+		logger.Infof("Cache invalidation channel is ready, local memory cache enabled")
+	} else {
+		logger.Warnf("Cache invalidation channel is down, local memory cache disabled")
+	}
+}
+
+// startInvalidationListener 订阅缓存失效广播，在第一次使用本地缓存时启动
+func startInvalidationListener() {
+	startListenerOnce.Do(func() {
+		go listenInvalidation()
+	})
+}
+
+func listenInvalidation() {
+	logger := logging.LogService("Cached.InvalidationListener")
+	ctx := context.Background()
+	pubSub := redis.Client.Subscribe(ctx, config.CacheInvalidationRedisChannel)
+
+	go func() {
+		ticker := time.NewTicker(invalidationPingInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			_ = pubSub.Ping(ctx)
+		}
+	}()
+
+	for {
+		msg, err := pubSub.ReceiveTimeout(ctx, invalidationReceiveTimeout)
+		if err != nil {
+			if localEnabled.Load() {
+				logger.WithFields(logrus.Fields{
+					"err": err,
+				}).Errorf("Receiving cache invalidation happens error")
+			}
+			setLocalEnabled(false)
+			time.Sleep(invalidationRetryInterval)
+			continue
+		}
+
+		switch msg := msg.(type) {
+		case *redis2.Subscription:
+			// 每次（重新）订阅成功都会收到该消息
+			if msg.Kind == "subscribe" {
+				setLocalEnabled(true)
+			}
+		case *redis2.Message:
+			var message invalidationMessage
+			if err := json.Unmarshal([]byte(msg.Payload), &message); err != nil {
+				logger.WithFields(logrus.Fields{
+					"err":     err,
+					"payload": msg.Payload,
+				}).Warnf("Failed to decode cache invalidation")
+				continue
+			}
+			if message.Origin == instanceId {
+				continue
+			}
+			getOrCreateCache(message.Cache).Delete(message.Key)
+		}
+	}
+}
@@ -0,0 +1,377 @@
+package cached
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/storage/redis"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/patrickmn/go-cache"
+	redis2 "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"time"
+)
+
+// Codec 负责缓存值与 Redis 中保存的字符串之间的相互转换
+type Codec[V any] interface {
+	Marshal(value V) (string, error)
+	Unmarshal(data string) (V, error)
+}
+
+// JSONCodec 使用 JSON 编码缓存值
+type JSONCodec[V any] struct{}
+
+func (JSONCodec[V]) Marshal(value V) (string, error) {
+	data, err := json.Marshal(value)
+	return string(data), err
+}
+
+func (JSONCodec[V]) Unmarshal(data string) (value V, err error) {
+	err = json.Unmarshal([]byte(data), &value)
+	return
+}
+
+// StringCodec 直接保存字符串
+type StringCodec struct{}
+
+func (StringCodec) Marshal(value string) (string, error) {
+	return value, nil
+}
+
+func (StringCodec) Unmarshal(data string) (string, error) {
+	return data, nil
+}
+
+// Loader 在 Memory 与 Redis 均未命中时从数据源（一般为 DB）加载数据，found 为 false 表示数据不存在
+type Loader[K comparable, V any] func(ctx context.Context, key K) (value V, found bool, err error)
+
+// BatchLoader 批量从数据源加载数据，返回的 map 中不包含不存在的 key
+type BatchLoader[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// Cache 类型安全的 Memory-Redis-Loader 多级缓存
+type Cache[K comparable, V any] struct {
+	name        string
+	codec       Codec[V]
+	loader      Loader[K, V]
+	batchLoader BatchLoader[K, V]
+	local       *localCache
+}
+
+// New 创建一个多级缓存，name 作为 key 的命名空间，loader 可以为 nil，此时只读取 Memory-Redis
+func New[K comparable, V any](name string, codec Codec[V], loader Loader[K, V]) *Cache[K, V] {
+	return &Cache[K, V]{
+		name:   name,
+		codec:  codec,
+		loader: loader,
+		local:  getOrCreateCache(name),
+	}
+}
+
+// WithBatchLoader 设置 GetMany 使用的批量加载函数，没有设置时 GetMany 逐个调用 Loader
+func (c *Cache[K, V]) WithBatchLoader(batchLoader BatchLoader[K, V]) *Cache[K, V] {
+	c.batchLoader = batchLoader
+	return c
+}
+
+// key 生成带有前缀的 Redis key，本地缓存使用同样的 key
+func (c *Cache[K, V]) key(key K) string {
+	return fmt.Sprintf("%s%s-%v", config.EnvCfg.RedisPrefix, c.name, key)
+}
+
+// Get 采用 Memory-Redis-Loader 的模式读取数据，数据不存在时返回 false
+func (c *Cache[K, V]) Get(ctx context.Context, key K) (value V, found bool, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "Cached-MultiLevel-Get")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("Cached.MultiLevel.Get").WithContext(ctx)
+
+	if value, found, err = c.GetCached(ctx, key); err != nil || found {
+		return
+	}
+
+	if c.loader == nil {
+		return
+	}
+
+	// Redis 没有命中，Fallback 到 Loader，同一进程内相同 key 只会加载一次
+	type loaded struct {
+		value V
+		found bool
+	}
+	result, err, _ := loadGroup.Do(c.key(key), func() (interface{}, error) {
+		start := time.Now()
+		value, found, err := c.loader(ctx, key)
+		observeLoad(c.name, start, found, err)
+		return loaded{value: value, found: found}, err
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":   err,
+			"cache": c.name,
+			"key":   key,
+		}).Errorf("Loader error when load value")
+		logging.SetSpanError(span, err)
+		return
+	}
+	value, found = result.(loaded).value, result.(loaded).found
+
+	if !found {
+		logger.WithFields(logrus.Fields{
+			"cache": c.name,
+			"key":   key,
+		}).Warnf("Missed loader, seems wrong key")
+		return
+	}
+
+	// 回写失败不影响本次读取
+	if err := c.set(ctx, key, value, false); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":   err,
+			"cache": c.name,
+			"key":   key,
+		}).Errorf("Error when writing back the loaded value")
+	}
+	return value, true, nil
+}
+
+// GetCached 采用 Memory-Redis 的模式读取数据，不会调用 Loader
+func (c *Cache[K, V]) GetCached(ctx context.Context, key K) (value V, found bool, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "Cached-MultiLevel-GetCached")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("Cached.MultiLevel.GetCached").WithContext(ctx)
+
+	// 1. 在本地缓存查询数据
+	redisKey := c.key(key)
+	if cachedData, ok := c.local.Get(redisKey); ok {
+		return cachedData.(V), true, nil
+	}
+	logger.WithFields(logrus.Fields{
+		"key": redisKey,
+	}).Infof("Missed local memory cached")
+
+	// 2. 缓存没有命中，Fallback 到 Redis
+	data, err := redis.Client.Get(ctx, redisKey).Result()
+	switch {
+	case err == redis2.Nil:
+		observe(c.name, layerRedis, resultMiss)
+		logger.WithFields(logrus.Fields{
+			"key": redisKey,
+		}).Infof("Missed Redis cached")
+		return value, false, nil
+	case err != nil:
+		observe(c.name, layerRedis, resultError)
+		logger.WithFields(logrus.Fields{
+			"err": err,
+			"key": redisKey,
+		}).Errorf("Redis error when find value")
+		logging.SetSpanError(span, err)
+		return value, false, err
+	}
+
+	if value, err = c.codec.Unmarshal(data); err != nil {
+		// 无法解析的数据视为未命中，删除后由 Loader 重新加载
+		logger.WithFields(logrus.Fields{
+			"err": err,
+			"key": redisKey,
+		}).Warnf("Failed to decode Redis value, drop it")
+		redis.Client.Del(ctx, redisKey)
+		observe(c.name, layerRedis, resultMiss)
+		return value, false, nil
+	}
+	observe(c.name, layerRedis, resultHit)
+
+	// Redis 存在数据，回写本地缓存
+	c.local.Set(redisKey, value, cache.DefaultExpiration)
+	return value, true, nil
+}
+
+// GetMany 批量读取数据：先查询本地缓存，未命中的 key 通过一次 Redis Pipeline 读取，仍未命中的 key 交给 BatchLoader 一次加载。
+// 返回的 map 中不包含不存在的 key
+func (c *Cache[K, V]) GetMany(ctx context.Context, keys []K) (map[K]V, error) {
+	ctx, span := tracing.Tracer.Start(ctx, "Cached-MultiLevel-GetMany")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("Cached.MultiLevel.GetMany").WithContext(ctx)
+
+	values := make(map[K]V, len(keys))
+
+	// 1. 在本地缓存查询数据
+	var missed []K
+	seen := make(map[K]struct{}, len(keys))
+	for _, key := range keys {
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if cachedData, ok := c.local.Get(c.key(key)); ok {
+			values[key] = cachedData.(V)
+			continue
+		}
+		missed = append(missed, key)
+	}
+	if len(missed) == 0 {
+		return values, nil
+	}
+
+	// 2. 本地缓存没有命中的 key 通过一次 Pipeline 从 Redis 读取
+	pipe := redis.Client.Pipeline()
+	cmds := make([]*redis2.StringCmd, len(missed))
+	for i, key := range missed {
+		cmds[i] = pipe.Get(ctx, c.key(key))
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis2.Nil {
+		// Redis 不可用时全部交给 Loader
+		observe(c.name, layerRedis, resultError)
+		logger.WithFields(logrus.Fields{
+			"err":   err,
+			"cache": c.name,
+		}).Errorf("Redis error when find values")
+	}
+
+	var unloaded []K
+	for i, key := range missed {
+		data, err := cmds[i].Result()
+		if err != nil {
+			if err == redis2.Nil {
+				observe(c.name, layerRedis, resultMiss)
+			}
+			unloaded = append(unloaded, key)
+			continue
+		}
+		value, err := c.codec.Unmarshal(data)
+		if err != nil {
+			observe(c.name, layerRedis, resultMiss)
+			unloaded = append(unloaded, key)
+			continue
+		}
+		observe(c.name, layerRedis, resultHit)
+		values[key] = value
+		c.local.Set(c.key(key), value, cache.DefaultExpiration)
+	}
+	if len(unloaded) == 0 {
+		return values, nil
+	}
+
+	// 3. Redis 没有命中的 key 交给 Loader 加载
+	loaded, err := c.loadMany(ctx, unloaded)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":   err,
+			"cache": c.name,
+			"keys":  unloaded,
+		}).Errorf("Loader error when load values")
+		logging.SetSpanError(span, err)
+		return nil, err
+	}
+
+	// 回写失败不影响本次读取
+	pipe = redis.Client.Pipeline()
+	for key, value := range loaded {
+		values[key] = value
+		data, err := c.codec.Marshal(value)
+		if err != nil {
+			continue
+		}
+		c.local.Set(c.key(key), value, cache.DefaultExpiration)
+		pipe.Set(ctx, c.key(key), data, c.local.policy.redisTTL())
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err":   err,
+			"cache": c.name,
+		}).Errorf("Error when writing back the loaded values")
+	}
+	return values, nil
+}
+
+// loadMany 从数据源加载 keys，优先使用 BatchLoader
+func (c *Cache[K, V]) loadMany(ctx context.Context, keys []K) (map[K]V, error) {
+	values := make(map[K]V, len(keys))
+	if c.batchLoader != nil {
+		start := time.Now()
+		loaded, err := c.batchLoader(ctx, keys)
+		observeLoad(c.name, start, true, err)
+		return loaded, err
+	}
+	if c.loader == nil {
+		return values, nil
+	}
+
+	for _, key := range keys {
+		start := time.Now()
+		value, found, err := c.loader(ctx, key)
+		observeLoad(c.name, start, found, err)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+// Set 同时写入 Memory 与 Redis，并通知其他副本删除旧的本地缓存
+func (c *Cache[K, V]) Set(ctx context.Context, key K, value V) error {
+	return c.set(ctx, key, value, true)
+}
+
+func (c *Cache[K, V]) set(ctx context.Context, key K, value V, broadcast bool) error {
+	ctx, span := tracing.Tracer.Start(ctx, "Cached-MultiLevel-Set")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+
+	data, err := c.codec.Marshal(value)
+	if err != nil {
+		logging.SetSpanError(span, err)
+		return err
+	}
+
+	redisKey := c.key(key)
+	c.local.Set(redisKey, value, cache.DefaultExpiration)
+	if err := redis.Client.Set(ctx, redisKey, data, c.local.policy.redisTTL()).Err(); err != nil {
+		logging.SetSpanError(span, err)
+		return err
+	}
+	if broadcast {
+		publishInvalidation(ctx, c.name, redisKey)
+	}
+	return nil
+}
+
+// SetLocal 只写入本地缓存
+func (c *Cache[K, V]) SetLocal(key K, value V) {
+	c.local.Set(c.key(key), value, cache.DefaultExpiration)
+}
+
+// Delete 删除所有副本的 Memory 与 Redis 中的数据，下次读取时会 Fallback 到 Loader
+func (c *Cache[K, V]) Delete(ctx context.Context, key K) error {
+	ctx, span := tracing.Tracer.Start(ctx, "Cached-MultiLevel-Delete")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+
+	redisKey := c.key(key)
+	err := redis.Client.Del(ctx, redisKey).Err()
+	if err != nil {
+		logging.SetSpanError(span, err)
+	}
+	// 先删除 Redis 再广播，避免其他副本在收到消息后又从 Redis 读到旧数据
+	c.local.Invalidate(ctx, redisKey)
+	return err
+}
+
+// Update 先执行写入数据源的 write，成功后再删除缓存，避免缓存中留下旧数据
+func (c *Cache[K, V]) Update(ctx context.Context, key K, write func(ctx context.Context) error) error {
+	ctx, span := tracing.Tracer.Start(ctx, "Cached-MultiLevel-Update")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+
+	if err := write(ctx); err != nil {
+		logging.SetSpanError(span, err)
+		return err
+	}
+	return c.Delete(ctx, key)
+}
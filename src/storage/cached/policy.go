@@ -0,0 +1,169 @@
+package cached
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/prom"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultNamespace 没有匹配到任何策略的 key 归入该命名空间
+const defaultNamespace = "default"
+
+// Policy 一个 key 命名空间的缓存策略
+type Policy struct {
+	LocalTTL   time.Duration // 本地缓存的过期时间
+	RedisTTL   time.Duration // Redis 缓存的过期时间
+	Jitter     time.Duration // Redis 过期时间附加的随机范围，避免大量 key 同时过期
+	MaxEntries int           // 本地缓存最多保存的条目数，为 0 时不限制
+}
+
+// redisTTL 写入 Redis 时使用的过期时间
+func (p Policy) redisTTL() time.Duration {
+	if p.Jitter <= 0 {
+		return p.RedisTTL
+	}
+	return p.RedisTTL + time.Duration(rand.Int63n(int64(p.Jitter)))
+}
+
+// 各命名空间的默认策略，可以通过 CACHE_POLICIES 覆盖，命名空间按照 key 的最长前缀匹配
+var policies = map[string]Policy{
+	defaultNamespace:     {LocalTTL: 5 * time.Minute, RedisTTL: 120 * time.Hour, Jitter: 6 * time.Hour, MaxEntries: 100000},
+	"UserInfo":           {LocalTTL: 5 * time.Minute, RedisTTL: 24 * time.Hour, Jitter: 2 * time.Hour, MaxEntries: 50000},
+	"UserId":             {LocalTTL: 30 * time.Minute, RedisTTL: 120 * time.Hour, Jitter: 6 * time.Hour, MaxEntries: 50000},
+	"UserLog":            {LocalTTL: time.Minute, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 10000},
+	"U2T":                {LocalTTL: 5 * time.Minute, RedisTTL: 120 * time.Hour, Jitter: 6 * time.Hour, MaxEntries: 50000},
+	"T2U":                {LocalTTL: 5 * time.Minute, RedisTTL: 120 * time.Hour, Jitter: 6 * time.Hour, MaxEntries: 50000},
+	"VideoCount":         {LocalTTL: time.Minute, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 50000},
+	"CommentCount":       {LocalTTL: time.Minute, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 50000},
+	"VideoExistedCached": {LocalTTL: 10 * time.Minute, RedisTTL: 120 * time.Hour, Jitter: 6 * time.Hour, MaxEntries: 100000},
+	"IsFollowedCache":    {LocalTTL: time.Minute, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 200000},
+	"follow_count_":      {LocalTTL: time.Minute, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 50000},
+	"follower_count_":    {LocalTTL: time.Minute, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 50000},
+	"UserReaction":       {LocalTTL: time.Minute, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 200000},
+	"VideoReactions":     {LocalTTL: 30 * time.Second, RedisTTL: 24 * time.Hour, Jitter: time.Hour, MaxEntries: 50000},
+}
+
+// 按长度降序排列的命名空间，用于最长前缀匹配
+var namespaces []string
+
+func init() {
+	if err := applyPolicyOverrides(config.EnvCfg.CachePolicies); err != nil {
+		logging.LogService("Cached.Policy").WithFields(logrus.Fields{
+			"err":      err,
+			"policies": config.EnvCfg.CachePolicies,
+		}).Errorf("Failed to parse cache policies, some of them are ignored")
+	}
+
+	for namespace := range policies {
+		if namespace != defaultNamespace {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Slice(namespaces, func(i, j int) bool {
+		return len(namespaces[i]) > len(namespaces[j])
+	})
+}
+
+// applyPolicyOverrides 解析形如 `T2U=local:1m,redis:24h,jitter:1h,max:10000;UserInfo=redis:12h` 的配置，
+// 没有写出的字段沿用原有策略
+func applyPolicyOverrides(s string) error {
+	var errs []string
+	for _, item := range strings.Split(s, ";") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		namespace, fields, ok := strings.Cut(item, "=")
+		if !ok || namespace == "" {
+			errs = append(errs, fmt.Sprintf("invalid policy %q", item))
+			continue
+		}
+
+		policy, ok := policies[namespace]
+		if !ok {
+			policy = policies[defaultNamespace]
+		}
+		valid := true
+		for _, field := range strings.Split(fields, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(field), ":")
+			var err error
+			switch name {
+			case "local":
+				policy.LocalTTL, err = time.ParseDuration(value)
+			case "redis":
+				policy.RedisTTL, err = time.ParseDuration(value)
+			case "jitter":
+				policy.Jitter, err = time.ParseDuration(value)
+			case "max":
+				policy.MaxEntries, err = strconv.Atoi(value)
+			default:
+				err = fmt.Errorf("unknown field")
+			}
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s: %v", namespace, field, err))
+				valid = false
+			}
+		}
+		if valid {
+			policies[namespace] = policy
+		}
+	}
+
+	if len(errs) != 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// namespaceOf 返回 key（不含 RedisPrefix）所属的命名空间
+func namespaceOf(key string) string {
+	for _, namespace := range namespaces {
+		if strings.HasPrefix(key, namespace) {
+			return namespace
+		}
+	}
+	return defaultNamespace
+}
+
+// policyOf 返回命名空间的缓存策略
+func policyOf(namespace string) Policy {
+	if policy, ok := policies[namespace]; ok {
+		return policy
+	}
+	return policies[defaultNamespace]
+}
+
+// observe 记录一次缓存查询的结果
+func observe(namespace string, layer string, result string) {
+	prom.CacheRequests.WithLabelValues(namespace, layer, result).Inc()
+}
+
+// observeLoad 记录一次数据源加载的耗时与结果
+func observeLoad(namespace string, start time.Time, found bool, err error) {
+	prom.CacheLoadDuration.WithLabelValues(namespace).Observe(time.Since(start).Seconds())
+	switch {
+	case err != nil:
+		observe(namespace, layerLoader, resultError)
+	case found:
+		observe(namespace, layerLoader, resultHit)
+	default:
+		observe(namespace, layerLoader, resultMiss)
+	}
+}
+
+const (
+	layerLocal  = "local"
+	layerRedis  = "redis"
+	layerLoader = "loader"
+
+	resultHit   = "hit"
+	resultMiss  = "miss"
+	resultError = "error"
+)
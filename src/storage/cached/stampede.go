@@ -0,0 +1,272 @@
+package cached
+
+import (
+	"GuGoTik/src/storage/redis"
+	"GuGoTik/src/utils/logging"
+	"context"
+	"errors"
+	"github.com/google/uuid"
+	"github.com/patrickmn/go-cache"
+	redis2 "github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/singleflight"
+	"time"
+)
+
+const (
+	// Redis 加载锁的有效期，持有锁的进程超过该时间未完成加载，其他进程会自行加载
+	loadLockTTL = 3 * time.Second
+	// 等待其他进程加载时轮询 Redis 的间隔
+	loadLockWaitInterval = 50 * time.Millisecond
+	// 数据不存在时的默认缓存时间
+	defaultNegativeTTL = time.Minute
+	// 表示数据不存在的占位值
+	negativeValue = "\x00GUGOTIK_NOT_FOUND"
+)
+
+// ErrNotFound 由 GetWithFunc 的加载函数返回，表示数据不存在，该结果会以较短的时间缓存
+var ErrNotFound = errors.New("cached value not found")
+
+// 同一进程内相同 key 的加载只会执行一次
+var loadGroup singleflight.Group
+
+// 只有持有锁的进程才能删除锁
+var unlockScript = redis2.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// Option GetWithFunc 的可选配置
+type Option func(o *options)
+
+type options struct {
+	namespace   string        // key 所属的命名空间
+	fresh       time.Duration // 数据保持新鲜的时间，为 0 时不启用 stale-while-revalidate
+	stale       time.Duration // 数据过期后仍可使用的时间
+	negativeTTL time.Duration // 数据不存在时的缓存时间
+}
+
+func newOptions(namespace string, opts []Option) *options {
+	o := &options{
+		namespace:   namespace,
+		negativeTTL: defaultNegativeTTL,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// redisTTL 数据写入 Redis 时使用的过期时间
+func (o *options) redisTTL() time.Duration {
+	if o.fresh > 0 {
+		return o.fresh + o.stale
+	}
+	return policyOf(o.namespace).redisTTL()
+}
+
+// WithStaleWhileRevalidate 数据写入 fresh 时间后变为过期数据，在之后的 stale 时间内读取会直接返回过期数据，
+// 同时由一个请求在后台重新加载
+func WithStaleWhileRevalidate(fresh time.Duration, stale time.Duration) Option {
+	return func(o *options) {
+		o.fresh = fresh
+		o.stale = stale
+	}
+}
+
+// WithNegativeTTL 设置加载函数返回 ErrNotFound 时的缓存时间
+func WithNegativeTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.negativeTTL = ttl
+	}
+}
+
+// 缓存查询结果的状态
+type lookupState int
+
+const (
+	stateMiss     lookupState = iota // 没有命中
+	stateFresh                       // 命中新鲜数据
+	stateStale                       // 命中过期数据，需要后台刷新
+	stateNegative                    // 命中数据不存在的占位值
+)
+
+// lookup 从本地缓存与 Redis 读取 key，key 需要包含前缀
+func lookup(ctx context.Context, key string, o *options) (string, lookupState, error) {
+	logger := logging.LogService("Cached.Lookup").WithContext(ctx)
+	c := getOrCreateCache(o.namespace)
+	if cachedData, found := c.Get(key); found {
+		if cachedData.(string) == negativeValue {
+			return "", stateNegative, nil
+		}
+		return cachedData.(string), stateFresh, nil
+	}
+
+	pipe := redis.Client.Pipeline()
+	getCmd := pipe.Get(ctx, key)
+	var ttlCmd *redis2.DurationCmd
+	if o.fresh > 0 {
+		ttlCmd = pipe.PTTL(ctx, key)
+	}
+	if _, err := pipe.Exec(ctx); err != nil && err != redis2.Nil {
+		observe(o.namespace, layerRedis, resultError)
+		logger.WithFields(logrus.Fields{
+			"err": err,
+			"key": key,
+		}).Errorf("Redis error when find string")
+		return "", stateMiss, err
+	}
+
+	value, err := getCmd.Result()
+	if err == redis2.Nil {
+		observe(o.namespace, layerRedis, resultMiss)
+		return "", stateMiss, nil
+	}
+	if err != nil {
+		observe(o.namespace, layerRedis, resultError)
+		return "", stateMiss, err
+	}
+	observe(o.namespace, layerRedis, resultHit)
+
+	if value == negativeValue {
+		c.Set(key, value, o.negativeTTL)
+		return "", stateNegative, nil
+	}
+
+	if ttlCmd != nil {
+		if ttl, err := ttlCmd.Result(); err == nil && ttl >= 0 && ttl < o.stale {
+			// 过期数据不回写本地缓存，保证刷新完成后可以尽快读到新数据
+			return value, stateStale, nil
+		}
+	}
+
+	c.Set(key, value, cache.DefaultExpiration)
+	return value, stateFresh, nil
+}
+
+// store 写入本地缓存与 Redis，key 需要包含前缀，broadcast 为 true 时通知其他副本删除旧的本地缓存
+func store(ctx context.Context, namespace string, key string, value string, localTTL time.Duration, redisTTL time.Duration, broadcast bool) {
+	c := getOrCreateCache(namespace)
+	c.Set(key, value, localTTL)
+	redis.Client.Set(ctx, key, value, redisTTL)
+	if broadcast {
+		publishInvalidation(ctx, namespace, key)
+	}
+}
+
+// loadFunc 调用加载函数并记录耗时
+func loadFunc(ctx context.Context, rawKey string, f func(ctx context.Context, key string) (string, error), o *options) (string, error) {
+	start := time.Now()
+	value, err := f(ctx, rawKey)
+	if errors.Is(err, ErrNotFound) {
+		observeLoad(o.namespace, start, false, nil)
+	} else {
+		observeLoad(o.namespace, start, true, err)
+	}
+	return value, err
+}
+
+// tryLock 尝试获取 key 的加载锁，返回的 token 用于释放锁
+func tryLock(ctx context.Context, key string) (string, bool, error) {
+	token := uuid.New().String()
+	ok, err := redis.Client.SetNX(ctx, key+"-LoadLock", token, loadLockTTL).Result()
+	return token, ok, err
+}
+
+func unlock(ctx context.Context, key string, token string) {
+	if err := unlockScript.Run(ctx, redis.Client, []string{key + "-LoadLock"}, token).Err(); err != nil && err != redis2.Nil {
+		logging.LogService("Cached.Unlock").WithContext(ctx).WithFields(logrus.Fields{
+			"err": err,
+			"key": key,
+		}).Warnf("Failed to release load lock")
+	}
+}
+
+// load 在持有 Redis 加载锁的情况下调用 f 加载数据并写入缓存，没有抢到锁时等待持有锁的进程写入结果。
+// rawKey 为调用方传入的 key，key 为带有前缀的 key
+func load(ctx context.Context, rawKey string, key string, f func(ctx context.Context, key string) (string, error), o *options) (string, error) {
+	logger := logging.LogService("Cached.Load").WithContext(ctx)
+
+	token, locked, err := tryLock(ctx, key)
+	if err != nil {
+		// Redis 不可用时直接加载
+		logger.WithFields(logrus.Fields{
+			"err": err,
+			"key": key,
+		}).Warnf("Failed to acquire load lock, load directly")
+	}
+
+	if err == nil && !locked {
+		deadline := time.Now().Add(loadLockTTL)
+		for time.Now().Before(deadline) {
+			select {
+			case <-ctx.Done():
+				return "", ctx.Err()
+			case <-time.After(loadLockWaitInterval):
+			}
+
+			value, state, err := lookup(ctx, key, o)
+			if err != nil {
+				break
+			}
+			switch state {
+			case stateFresh, stateStale:
+				return value, nil
+			case stateNegative:
+				return "", ErrNotFound
+			}
+		}
+		logger.WithFields(logrus.Fields{
+			"key": key,
+		}).Warnf("Waiting for load lock timeout, load directly")
+	}
+
+	if locked {
+		defer unlock(ctx, key, token)
+	}
+
+	value, err := loadFunc(ctx, rawKey, f, o)
+	if errors.Is(err, ErrNotFound) {
+		store(ctx, o.namespace, key, negativeValue, o.negativeTTL, o.negativeTTL, false)
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+
+	store(ctx, o.namespace, key, value, cache.DefaultExpiration, o.redisTTL(), false)
+	return value, nil
+}
+
+// revalidate 在后台刷新过期数据，同一时刻集群中只有一个请求执行刷新
+func revalidate(ctx context.Context, rawKey string, key string, f func(ctx context.Context, key string) (string, error), o *options) {
+	// 刷新不应随原请求结束而取消，只保留链路信息
+	ctx = trace.ContextWithSpanContext(context.Background(), trace.SpanContextFromContext(ctx))
+	go func() {
+		_, _, _ = loadGroup.Do("revalidate-"+key, func() (interface{}, error) {
+			logger := logging.LogService("Cached.Revalidate").WithContext(ctx)
+			token, locked, err := tryLock(ctx, key)
+			if err != nil || !locked {
+				return nil, err
+			}
+			defer unlock(ctx, key, token)
+
+			value, err := loadFunc(ctx, rawKey, f, o)
+			switch {
+			case errors.Is(err, ErrNotFound):
+				store(ctx, o.namespace, key, negativeValue, o.negativeTTL, o.negativeTTL, true)
+			case err != nil:
+				logger.WithFields(logrus.Fields{
+					"err": err,
+					"key": key,
+				}).Warnf("Failed to revalidate stale value")
+			default:
+				store(ctx, o.namespace, key, value, cache.DefaultExpiration, o.redisTTL(), true)
+			}
+			return nil, err
+		})
+	}()
+}
@@ -3,6 +3,8 @@ package database
 import (
 	"GuGoTik/src/constant/config"
 	"GuGoTik/src/utils/logging"
+	"context"
+	"database/sql"
 	"fmt"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -13,11 +15,11 @@ import (
 	"time"
 )
 
+// Client 由 deps.Container 在服务启动时设置，导入本包不会连接数据库
 var Client *gorm.DB
 
-func init() {
-	var err error
-
+// Open 根据环境变量配置连接 PostgreSQL，配置了只读副本时同时注册读写分离
+func Open() (client *gorm.DB, err error) {
 	gormLogrus := logging.GetGormLogger()
 
 	var cfg gorm.Config
@@ -39,7 +41,7 @@ func init() {
 		}
 	}
 
-	if Client, err = gorm.Open(
+	if client, err = gorm.Open(
 		postgres.Open(
 			fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s",
 				config.EnvCfg.PostgreSQLHost,
@@ -49,38 +51,18 @@ func init() {
 				config.EnvCfg.PostgreSQLPort)),
 		&cfg,
 	); err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	if config.EnvCfg.PostgreSQLReplicaState == "enable" {
-		var replicas []gorm.Dialector
-		for _, addr := range strings.Split(config.EnvCfg.PostgreSQLReplicaAddress, ",") {
-			pair := strings.Split(addr, ":")
-			if len(pair) != 2 {
-				continue
-			}
-
-			replicas = append(replicas, postgres.Open(
-				fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s",
-					pair[0],
-					config.EnvCfg.PostgreSQLReplicaUsername,
-					config.EnvCfg.PostgreSQLReplicaPassword,
-					config.EnvCfg.PostgreSQLDataBase,
-					pair[1])))
-		}
-
-		err := Client.Use(dbresolver.Register(dbresolver.Config{
-			Replicas: replicas,
-			Policy:   dbresolver.RandomPolicy{},
-		}))
-		if err != nil {
-			panic(err)
+		if err := useReplicas(client); err != nil {
+			return nil, err
 		}
 	}
 
-	sqlDB, err := Client.DB()
+	sqlDB, err := client.DB()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	sqlDB.SetMaxIdleConns(100)
@@ -88,7 +70,57 @@ func init() {
 	sqlDB.SetConnMaxLifetime(24 * time.Hour)
 	sqlDB.SetConnMaxIdleTime(time.Hour)
 
-	if err := Client.Use(tracing.NewPlugin()); err != nil {
-		panic(err)
+	if err := client.Use(tracing.NewPlugin()); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// useReplicas 注册只读副本，读请求只会路由到复制延迟正常的副本，需要读自己的写入时路由到主库
+func useReplicas(client *gorm.DB) error {
+	maxLag, err := time.ParseDuration(config.EnvCfg.PostgreSQLReplicaMaxLag)
+	if err != nil {
+		return fmt.Errorf("invalid POSTGRESQL_REPLICA_MAX_LAG: %w", err)
+	}
+	interval, err := time.ParseDuration(config.EnvCfg.PostgreSQLReplicaCheck)
+	if err != nil {
+		return fmt.Errorf("invalid POSTGRESQL_REPLICA_CHECK_INTERVAL: %w", err)
 	}
+
+	replicas := newReplicaSet(maxLag)
+	var dialectors []gorm.Dialector
+	for _, addr := range strings.Split(config.EnvCfg.PostgreSQLReplicaAddress, ",") {
+		pair := strings.Split(addr, ":")
+		if len(pair) != 2 {
+			continue
+		}
+
+		db, err := sql.Open("pgx", fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s",
+			pair[0],
+			config.EnvCfg.PostgreSQLReplicaUsername,
+			config.EnvCfg.PostgreSQLReplicaPassword,
+			config.EnvCfg.PostgreSQLDataBase,
+			pair[1]))
+		if err != nil {
+			return err
+		}
+		db.SetMaxIdleConns(100)
+		db.SetMaxOpenConns(200)
+		db.SetConnMaxLifetime(24 * time.Hour)
+		db.SetConnMaxIdleTime(time.Hour)
+		dialectors = append(dialectors, replicas.add(addr, db))
+	}
+
+	if err := client.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   replicas,
+	})); err != nil {
+		return err
+	}
+	if err := replicas.register(client); err != nil {
+		return err
+	}
+
+	go replicas.monitor(context.Background(), interval)
+	return nil
 }
@@ -0,0 +1,299 @@
+package migrate
+
+import (
+	"GuGoTik/src/constant/config"
+	"bytes"
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+// 迁移文件名，例如 0001_baseline.up.sql 与 0001_baseline.down.sql
+var fileNamePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// ErrSchemaOutdated 数据库中的 Schema 版本低于当前程序需要的版本
+var ErrSchemaOutdated = errors.New("database schema is outdated, please run the migrate command first")
+
+// Migration 一个版本的迁移，Up 与 Down 为渲染后的 SQL
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status 迁移的执行状态，AppliedAt 为 nil 表示尚未执行
+type Status struct {
+	Version   uint
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Table 返回带有 Schema 与前缀的表名，与 GORM 的命名规则一致
+func Table(name string) string {
+	if config.EnvCfg.PostgreSQLSchema == "" {
+		return config.EnvCfg.PostgreSQLPrefix + name
+	}
+	return config.EnvCfg.PostgreSQLSchema + "." + config.EnvCfg.PostgreSQLPrefix + name
+}
+
+// Index 返回 GORM 为 index 标签生成的索引名，使得迁移可以兼容由 AutoMigrate 创建的旧数据库
+func Index(table string, column string) string {
+	return strings.ReplaceAll("idx_"+Table(table)+"_"+column, ".", "_")
+}
+
+var funcs = template.FuncMap{
+	"table": Table,
+	"index": Index,
+}
+
+// Load 读取并渲染所有迁移文件，按照版本号升序排列
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, "sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make(map[uint]*Migration)
+	for _, entry := range entries {
+		matches := fileNamePattern.FindStringSubmatch(entry.Name())
+		if matches == nil {
+			return nil, fmt.Errorf("invalid migration file name: %s", entry.Name())
+		}
+		version, err := strconv.ParseUint(matches[1], 10, 32)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := migrations[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: matches[2]}
+			migrations[uint(version)] = migration
+		}
+		if migration.Name != matches[2] {
+			return nil, fmt.Errorf("migration %d has different names: %s, %s", version, migration.Name, matches[2])
+		}
+
+		content, err := render(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		if matches[3] == "up" {
+			migration.Up = content
+		} else {
+			migration.Down = content
+		}
+	}
+
+	result := make([]Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d must have both up and down files", migration.Version)
+		}
+		result = append(result, *migration)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Version < result[j].Version
+	})
+	return result, nil
+}
+
+func render(name string) (string, error) {
+	content, err := files.ReadFile(path.Join("sql", name))
+	if err != nil {
+		return "", err
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Parse(string(content))
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// Migrator 在 PostgreSQL Advisory Lock 的保护下执行迁移，同一时刻只有一个进程可以修改 Schema
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New 创建 Migrator
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// Latest 当前程序需要的 Schema 版本
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// withLock 在同一个连接上获取 Advisory Lock 后执行 f，Advisory Lock 属于连接，因此所有操作都需要使用该连接
+func (m *Migrator) withLock(ctx context.Context, f func(conn *sql.Conn) error) error {
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	lockKey := "GuGoTik-Migrate-" + Table("schema_migrations")
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock(hashtext($1))", lockKey); err != nil {
+		return err
+	}
+	defer func() {
+		_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock(hashtext($1))", lockKey)
+	}()
+
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	version    bigint PRIMARY KEY,
+	name       text NOT NULL,
+	applied_at timestamptz NOT NULL DEFAULT now()
+)`, Table("schema_migrations"))); err != nil {
+		return err
+	}
+	return f(conn)
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// applied 查询已经执行的迁移
+func applied(ctx context.Context, q queryer) (map[uint]time.Time, error) {
+	rows, err := q.QueryContext(ctx, fmt.Sprintf("SELECT version, applied_at FROM %s", Table("schema_migrations")))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[uint]time.Time)
+	for rows.Next() {
+		var version uint
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		versions[version] = appliedAt
+	}
+	return versions, rows.Err()
+}
+
+// run 在一个事务中执行迁移 SQL 并更新迁移记录
+func run(ctx context.Context, conn *sql.Conn, query string, record string, args ...any) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, query); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, record, args...); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// Up 按版本顺序执行所有尚未执行的迁移，返回本次执行的迁移
+func (m *Migrator) Up(ctx context.Context) (done []Migration, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if _, ok := versions[migration.Version]; ok {
+				continue
+			}
+			if err := run(ctx, conn, migration.Up,
+				fmt.Sprintf("INSERT INTO %s (version, name) VALUES ($1, $2)", Table("schema_migrations")),
+				migration.Version, migration.Name); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return
+}
+
+// Down 按版本倒序回滚最近执行的 steps 个迁移，返回本次回滚的迁移
+func (m *Migrator) Down(ctx context.Context, steps int) (done []Migration, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && len(done) < steps; i-- {
+			migration := m.migrations[i]
+			if _, ok := versions[migration.Version]; !ok {
+				continue
+			}
+			if err := run(ctx, conn, migration.Down,
+				fmt.Sprintf("DELETE FROM %s WHERE version = $1", Table("schema_migrations")),
+				migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return
+}
+
+// Status 返回所有迁移的执行状态
+func (m *Migrator) Status(ctx context.Context) (status []Status, err error) {
+	err = m.withLock(ctx, func(conn *sql.Conn) error {
+		versions, err := applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			s := Status{Version: migration.Version, Name: migration.Name}
+			if appliedAt, ok := versions[migration.Version]; ok {
+				s.AppliedAt = &appliedAt
+			}
+			status = append(status, s)
+		}
+		return nil
+	})
+	return
+}
+
+// Verify 检查当前程序需要的所有迁移均已执行，不会获取锁也不会修改数据库。
+// 数据库版本高于程序版本时视为正常，以便滚动升级时旧版本的副本可以继续运行
+func (m *Migrator) Verify(ctx context.Context) error {
+	versions, err := applied(ctx, m.db)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSchemaOutdated, err)
+	}
+	for _, migration := range m.migrations {
+		if _, ok := versions[migration.Version]; !ok {
+			return fmt.Errorf("%w: migration %d_%s is not applied", ErrSchemaOutdated, migration.Version, migration.Name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS {{table "actions"}};
+DROP TABLE IF EXISTS {{table "messages"}};
+DROP TABLE IF EXISTS {{table "relations"}};
+DROP TABLE IF EXISTS {{table "comments"}};
+DROP TABLE IF EXISTS {{table "content_objects"}};
+DROP TABLE IF EXISTS {{table "raw_videos"}};
+DROP TABLE IF EXISTS {{table "videos"}};
+DROP TABLE IF EXISTS {{table "users"}};
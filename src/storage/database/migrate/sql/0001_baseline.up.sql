@@ -0,0 +1,125 @@
+-- 与原先 AutoMigrate 创建的表结构保持一致，已经由 AutoMigrate 创建过的数据库可以直接执行
+
+CREATE TABLE IF NOT EXISTS {{table "users"}} (
+    id               bigserial PRIMARY KEY,
+    user_name        varchar(32) NOT NULL UNIQUE,
+    password         text        NOT NULL,
+    role             bigint DEFAULT 1,
+    avatar           text,
+    background_image text,
+    signature        text,
+    created_at       timestamptz,
+    updated_at       timestamptz,
+    deleted_at       timestamptz
+);
+CREATE INDEX IF NOT EXISTS {{index "users" "user_name"}} ON {{table "users"}} (user_name);
+CREATE INDEX IF NOT EXISTS {{index "users" "deleted_at"}} ON {{table "users"}} (deleted_at);
+
+CREATE TABLE IF NOT EXISTS {{table "videos"}} (
+    id              bigserial PRIMARY KEY,
+    user_id         bigint NOT NULL,
+    title           text   NOT NULL,
+    file_name       text   NOT NULL,
+    cover_name      text   NOT NULL,
+    audio_file_name text,
+    transcript      text,
+    summary         text,
+    keywords        text,
+    content_hash    varchar(64),
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    deleted_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS {{index "videos" "content_hash"}} ON {{table "videos"}} (content_hash);
+CREATE INDEX IF NOT EXISTS {{index "videos" "deleted_at"}} ON {{table "videos"}} (deleted_at);
+
+CREATE TABLE IF NOT EXISTS {{table "raw_videos"}} (
+    actor_id     bigint,
+    video_id     bigint NOT NULL,
+    title        text,
+    file_name    text,
+    cover_name   text,
+    content_hash varchar(64),
+    id           bigserial,
+    created_at   timestamptz,
+    updated_at   timestamptz,
+    deleted_at   timestamptz,
+    PRIMARY KEY (video_id, id)
+);
+CREATE INDEX IF NOT EXISTS {{index "raw_videos" "deleted_at"}} ON {{table "raw_videos"}} (deleted_at);
+
+CREATE TABLE IF NOT EXISTS {{table "content_objects"}} (
+    checksum   varchar(64) NOT NULL PRIMARY KEY,
+    file_name  text        NOT NULL,
+    size       bigint,
+    ref_count  bigint      NOT NULL DEFAULT 0,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+
+CREATE TABLE IF NOT EXISTS {{table "comments"}} (
+    id                          bigserial PRIMARY KEY,
+    video_id                    bigint NOT NULL,
+    user_id                     bigint NOT NULL,
+    content                     text,
+    rate                        bigint,
+    reason                      text,
+    moderation_flagged          boolean,
+    moderation_hate             boolean,
+    moderation_hate_threatening boolean,
+    moderation_self_harm        boolean,
+    moderation_sexual           boolean,
+    moderation_sexual_minors    boolean,
+    moderation_violence         boolean,
+    moderation_violence_graphic boolean,
+    created_at                  timestamptz,
+    updated_at                  timestamptz,
+    deleted_at                  timestamptz
+);
+CREATE INDEX IF NOT EXISTS comment_video ON {{table "comments"}} (video_id, rate);
+CREATE INDEX IF NOT EXISTS {{index "comments" "deleted_at"}} ON {{table "comments"}} (deleted_at);
+
+CREATE TABLE IF NOT EXISTS {{table "relations"}} (
+    id         bigserial PRIMARY KEY,
+    actor_id   bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    created_at timestamptz,
+    updated_at timestamptz,
+    deleted_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS actor_list ON {{table "relations"}} (actor_id);
+CREATE INDEX IF NOT EXISTS user_list ON {{table "relations"}} (user_id);
+CREATE INDEX IF NOT EXISTS {{index "relations" "deleted_at"}} ON {{table "relations"}} (deleted_at);
+
+CREATE TABLE IF NOT EXISTS {{table "messages"}} (
+    id              bigserial PRIMARY KEY,
+    to_user_id      bigint NOT NULL,
+    from_user_id    bigint NOT NULL,
+    conversation_id text   NOT NULL,
+    content         text   NOT NULL,
+    created_at      timestamptz,
+    updated_at      timestamptz,
+    deleted_at      timestamptz
+);
+CREATE INDEX IF NOT EXISTS {{index "messages" "deleted_at"}} ON {{table "messages"}} (deleted_at);
+
+CREATE TABLE IF NOT EXISTS {{table "actions"}} (
+    id             bigserial PRIMARY KEY,
+    type           bigint,
+    name           text,
+    sub_name       text,
+    service_name   text,
+    attached       text,
+    actor_id       bigint,
+    video_id       bigint,
+    affect_user_id bigint,
+    affect_action  bigint,
+    affected_data  text,
+    event_id       text,
+    trace_id       text,
+    span_id        text,
+    created_at     timestamptz,
+    updated_at     timestamptz,
+    deleted_at     timestamptz
+);
+CREATE INDEX IF NOT EXISTS {{index "actions" "deleted_at"}} ON {{table "actions"}} (deleted_at);
@@ -0,0 +1,19 @@
+-- 事务性 Outbox：业务变更与待投递的消息在同一个事务中写入，由 OutboxRelay 投递到 RabbitMQ
+
+CREATE TABLE IF NOT EXISTS {{table "outbox_messages"}} (
+    id              bigserial PRIMARY KEY,
+    exchange        text        NOT NULL,
+    routing_key     text        NOT NULL,
+    body            bytea       NOT NULL,
+    headers         text,
+    status          text        NOT NULL DEFAULT 'pending',
+    attempts        bigint      NOT NULL DEFAULT 0,
+    next_attempt_at timestamptz NOT NULL DEFAULT now(),
+    last_error      text,
+    sent_at         timestamptz,
+    created_at      timestamptz,
+    updated_at      timestamptz
+);
+-- Relay 只扫描待投递的消息，部分索引可以让已经投递的消息不影响扫描的速度
+CREATE INDEX IF NOT EXISTS {{index "outbox_messages" "pending"}} ON {{table "outbox_messages"}} (next_attempt_at, id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS {{index "outbox_messages" "status"}} ON {{table "outbox_messages"}} (status, updated_at);
@@ -0,0 +1,12 @@
+-- 点赞记录，Redis 中的 user_like_/video_like_/user_liked_ 都可以由该表重建
+
+CREATE TABLE IF NOT EXISTS {{table "favorites"}} (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint NOT NULL,
+    video_id   bigint NOT NULL,
+    author_id  bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS favorite_user_video ON {{table "favorites"}} (user_id, video_id);
+CREATE INDEX IF NOT EXISTS favorite_video ON {{table "favorites"}} (video_id);
+CREATE INDEX IF NOT EXISTS favorite_author ON {{table "favorites"}} (author_id);
@@ -0,0 +1 @@
+ALTER TABLE {{table "favorites"}} DROP COLUMN IF EXISTS reaction;
@@ -0,0 +1,3 @@
+-- 每条点赞记录带有一个回应类型，原有的点赞都是普通点赞
+
+ALTER TABLE {{table "favorites"}} ADD COLUMN IF NOT EXISTS reaction smallint NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{table "collection_items"}};
+DROP TABLE IF EXISTS {{table "collections"}};
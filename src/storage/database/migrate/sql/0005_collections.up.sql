@@ -0,0 +1,21 @@
+-- 收藏夹，与点赞相互独立，可以设置为私密
+
+CREATE TABLE IF NOT EXISTS {{table "collections"}} (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint      NOT NULL,
+    name       varchar(64) NOT NULL,
+    private    boolean     NOT NULL DEFAULT false,
+    created_at timestamptz,
+    updated_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS collection_user_name ON {{table "collections"}} (user_id, name);
+
+CREATE TABLE IF NOT EXISTS {{table "collection_items"}} (
+    id            bigserial PRIMARY KEY,
+    collection_id bigint NOT NULL,
+    video_id      bigint NOT NULL,
+    position      bigint NOT NULL,
+    created_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS collection_item_video ON {{table "collection_items"}} (collection_id, video_id);
+CREATE INDEX IF NOT EXISTS collection_item_position ON {{table "collection_items"}} (collection_id, position);
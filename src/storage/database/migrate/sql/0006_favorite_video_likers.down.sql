@@ -0,0 +1,2 @@
+CREATE INDEX IF NOT EXISTS favorite_video ON {{table "favorites"}} (video_id);
+DROP INDEX IF EXISTS favorite_video_id;
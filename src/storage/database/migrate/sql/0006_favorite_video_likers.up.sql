@@ -0,0 +1,4 @@
+-- 视频作者按点赞时间倒序分页查看点赞的用户，(video_id, id) 可以同时用于按视频统计点赞数
+
+CREATE INDEX IF NOT EXISTS favorite_video_id ON {{table "favorites"}} (video_id, id);
+DROP INDEX IF EXISTS favorite_video;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS comment_parent;
+DROP INDEX IF EXISTS comment_root;
+ALTER TABLE {{table "comments"}} DROP COLUMN IF EXISTS tombstoned;
+ALTER TABLE {{table "comments"}} DROP COLUMN IF EXISTS root_id;
+ALTER TABLE {{table "comments"}} DROP COLUMN IF EXISTS parent_id;
@@ -0,0 +1,7 @@
+-- 评论支持楼中楼回复，root_id 指向顶层评论，回复按 (root_id, id) 分页
+
+ALTER TABLE {{table "comments"}} ADD COLUMN IF NOT EXISTS parent_id bigint NOT NULL DEFAULT 0;
+ALTER TABLE {{table "comments"}} ADD COLUMN IF NOT EXISTS root_id bigint NOT NULL DEFAULT 0;
+ALTER TABLE {{table "comments"}} ADD COLUMN IF NOT EXISTS tombstoned boolean NOT NULL DEFAULT false;
+CREATE INDEX IF NOT EXISTS comment_root ON {{table "comments"}} (root_id, id);
+CREATE INDEX IF NOT EXISTS comment_parent ON {{table "comments"}} (parent_id);
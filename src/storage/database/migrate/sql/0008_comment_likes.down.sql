@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{table "comment_likes"}};
+ALTER TABLE {{table "comments"}} DROP COLUMN IF EXISTS like_count;
@@ -0,0 +1,11 @@
+-- 评论点赞，comments.like_count 与 comment_likes 在同一个事务中修改
+
+ALTER TABLE {{table "comments"}} ADD COLUMN IF NOT EXISTS like_count bigint NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS {{table "comment_likes"}} (
+    id         bigserial PRIMARY KEY,
+    comment_id bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS comment_like_comment_user ON {{table "comment_likes"}} (comment_id, user_id);
@@ -0,0 +1 @@
+ALTER TABLE {{table "comments"}} DROP COLUMN IF EXISTS moderation_decision;
@@ -0,0 +1,5 @@
+-- 评论审核结论：0-展示，1-等待人工复核，2-拒绝。原先按评分过滤，评分为 4 的评论视为待复核，5 视为拒绝
+
+ALTER TABLE {{table "comments"}} ADD COLUMN IF NOT EXISTS moderation_decision smallint NOT NULL DEFAULT 0;
+UPDATE {{table "comments"}} SET moderation_decision = 1, moderation_flagged = true WHERE rate = 4;
+UPDATE {{table "comments"}} SET moderation_decision = 2, moderation_flagged = true WHERE rate >= 5;
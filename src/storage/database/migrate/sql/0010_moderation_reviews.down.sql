@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS action_type_id;
+DROP TABLE IF EXISTS {{table "user_bans"}};
+DROP TABLE IF EXISTS {{table "moderation_reviews"}};
@@ -0,0 +1,30 @@
+-- 人工复核队列与用户封禁记录，审核操作的历史记录在 actions 中按类型查询
+
+CREATE TABLE IF NOT EXISTS {{table "moderation_reviews"}} (
+    id            bigserial PRIMARY KEY,
+    item_type     bigint NOT NULL,
+    item_id       bigint NOT NULL,
+    author_id     bigint NOT NULL,
+    content       text,
+    reason        text,
+    status        bigint NOT NULL DEFAULT 0,
+    reviewer_id   bigint NOT NULL DEFAULT 0,
+    review_reason text,
+    reviewed_at   timestamptz,
+    created_at    timestamptz,
+    updated_at    timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS moderation_review_item ON {{table "moderation_reviews"}} (item_type, item_id);
+CREATE INDEX IF NOT EXISTS moderation_review_pending ON {{table "moderation_reviews"}} (status, item_type, id);
+
+CREATE TABLE IF NOT EXISTS {{table "user_bans"}} (
+    id           bigserial PRIMARY KEY,
+    user_id      bigint      NOT NULL,
+    moderator_id bigint      NOT NULL,
+    reason       text,
+    until        timestamptz NOT NULL,
+    created_at   timestamptz
+);
+CREATE INDEX IF NOT EXISTS user_ban_user ON {{table "user_bans"}} (user_id, until);
+
+CREATE INDEX IF NOT EXISTS action_type_id ON {{table "actions"}} (type, id);
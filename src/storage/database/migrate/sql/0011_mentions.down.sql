@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{table "notifications"}};
+DROP TABLE IF EXISTS {{table "mentions"}};
@@ -0,0 +1,30 @@
+-- 评论与消息中的 @，以及由通知事件写入的通知
+
+CREATE TABLE IF NOT EXISTS {{table "mentions"}} (
+    id         bigserial PRIMARY KEY,
+    item_type  bigint NOT NULL,
+    item_id    bigint NOT NULL,
+    author_id  bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    user_name  text   NOT NULL,
+    start      bigint NOT NULL,
+    length     bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS mention_item ON {{table "mentions"}} (item_type, item_id);
+CREATE INDEX IF NOT EXISTS mention_user ON {{table "mentions"}} (user_id, id);
+
+CREATE TABLE IF NOT EXISTS {{table "notifications"}} (
+    id         bigserial PRIMARY KEY,
+    user_id    bigint  NOT NULL,
+    actor_id   bigint  NOT NULL,
+    type       text    NOT NULL,
+    item_type  bigint  NOT NULL,
+    item_id    bigint  NOT NULL,
+    video_id   bigint  NOT NULL DEFAULT 0,
+    event_id   text    NOT NULL,
+    read       boolean NOT NULL DEFAULT false,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS notification_user ON {{table "notifications"}} (user_id, id);
+CREATE UNIQUE INDEX IF NOT EXISTS notification_event ON {{table "notifications"}} (event_id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS {{table "comment_pins"}};
+DROP TABLE IF EXISTS {{table "comment_revisions"}};
+ALTER TABLE {{table "comments"}} DROP COLUMN IF EXISTS edited_at;
@@ -0,0 +1,18 @@
+-- 评论编辑的修订历史与视频作者置顶的评论
+
+ALTER TABLE {{table "comments"}} ADD COLUMN IF NOT EXISTS edited_at timestamptz;
+
+CREATE TABLE IF NOT EXISTS {{table "comment_revisions"}} (
+    id         bigserial PRIMARY KEY,
+    comment_id bigint NOT NULL,
+    content    text,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS comment_revision_comment ON {{table "comment_revisions"}} (comment_id, id);
+
+CREATE TABLE IF NOT EXISTS {{table "comment_pins"}} (
+    video_id   bigint PRIMARY KEY,
+    comment_id bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS comment_pin_comment ON {{table "comment_pins"}} (comment_id);
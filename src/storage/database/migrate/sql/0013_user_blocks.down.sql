@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{table "user_mutes"}};
+DROP TABLE IF EXISTS {{table "user_blocks"}};
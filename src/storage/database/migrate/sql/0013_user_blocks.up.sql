@@ -0,0 +1,18 @@
+-- 用户之间的拉黑与屏蔽关系
+
+CREATE TABLE IF NOT EXISTS {{table "user_blocks"}} (
+    id         bigserial PRIMARY KEY,
+    actor_id   bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS user_block_pair ON {{table "user_blocks"}} (actor_id, user_id);
+CREATE INDEX IF NOT EXISTS user_block_user ON {{table "user_blocks"}} (user_id);
+
+CREATE TABLE IF NOT EXISTS {{table "user_mutes"}} (
+    id         bigserial PRIMARY KEY,
+    actor_id   bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS user_mute_pair ON {{table "user_mutes"}} (actor_id, user_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS {{table "follow_requests"}};
+ALTER TABLE {{table "users"}} DROP COLUMN IF EXISTS private;
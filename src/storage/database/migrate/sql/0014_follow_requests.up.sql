@@ -0,0 +1,12 @@
+-- 私密账号与等待同意的关注请求
+
+ALTER TABLE {{table "users"}} ADD COLUMN IF NOT EXISTS private boolean NOT NULL DEFAULT false;
+
+CREATE TABLE IF NOT EXISTS {{table "follow_requests"}} (
+    id         bigserial PRIMARY KEY,
+    actor_id   bigint NOT NULL,
+    user_id    bigint NOT NULL,
+    created_at timestamptz
+);
+CREATE UNIQUE INDEX IF NOT EXISTS follow_request_pair ON {{table "follow_requests"}} (actor_id, user_id);
+CREATE INDEX IF NOT EXISTS follow_request_user ON {{table "follow_requests"}} (user_id, id);
@@ -0,0 +1,160 @@
+package database
+
+import (
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/prom"
+	"context"
+	"database/sql"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// 副本与主库没有待回放的 WAL 时认为没有延迟，否则用最后一次回放事务的时间计算延迟，
+// 避免主库长时间没有写入时空闲的副本被误判为落后
+const replicaLagQuery = `SELECT CASE
+    WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+    ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// replica 只读副本以及监控到的复制延迟
+type replica struct {
+	addr    string
+	db      *sql.DB
+	healthy atomic.Bool
+}
+
+// replicaSet 监控所有只读副本的复制延迟，实现 dbresolver.Policy 只把读请求路由到延迟正常的副本
+type replicaSet struct {
+	replicas []*replica
+	byPool   map[gorm.ConnPool]*replica
+	maxLag   time.Duration
+}
+
+func newReplicaSet(maxLag time.Duration) *replicaSet {
+	return &replicaSet{
+		byPool: map[gorm.ConnPool]*replica{},
+		maxLag: maxLag,
+	}
+}
+
+// add 注册一个副本，返回交给 dbresolver 的 Dialector，两者共用同一个连接池以便在 Resolve 时找到对应的副本
+func (s *replicaSet) add(addr string, db *sql.DB) gorm.Dialector {
+	r := &replica{addr: addr, db: db}
+	r.healthy.Store(true)
+	s.replicas = append(s.replicas, r)
+	s.byPool[db] = r
+	return postgres.New(postgres.Config{Conn: db})
+}
+
+// Resolve 在延迟正常的副本中随机选择，全部被剔除时由 route 将请求路由到主库
+func (s *replicaSet) Resolve(pools []gorm.ConnPool) gorm.ConnPool {
+	healthy := make([]gorm.ConnPool, 0, len(pools))
+	for _, pool := range pools {
+		if r, ok := s.byPool[pool]; !ok || r.healthy.Load() {
+			healthy = append(healthy, pool)
+		}
+	}
+	if len(healthy) == 0 {
+		return pools[rand.Intn(len(pools))]
+	}
+	return healthy[rand.Intn(len(healthy))]
+}
+
+func (s *replicaSet) anyHealthy() bool {
+	for _, r := range s.replicas {
+		if r.healthy.Load() {
+			return true
+		}
+	}
+	return false
+}
+
+// route 在 dbresolver 选择连接之前执行，需要读自己的写入或者没有可用副本时强制读主库
+func (s *replicaSet) route(db *gorm.DB) {
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return
+	}
+
+	switch {
+	case usePrimary(db.Statement.Context):
+		dbresolver.Write.ModifyStatement(db.Statement)
+		prom.DBReadRouting.WithLabelValues("primary", "read_your_writes").Inc()
+	case !s.anyHealthy():
+		dbresolver.Write.ModifyStatement(db.Statement)
+		prom.DBReadRouting.WithLabelValues("primary", "replica_lag").Inc()
+	default:
+		prom.DBReadRouting.WithLabelValues("replica", "healthy").Inc()
+	}
+}
+
+func (s *replicaSet) register(client *gorm.DB) error {
+	if err := client.Callback().Query().Before("gorm:db_resolver").Register("gugotik:read_routing", s.route); err != nil {
+		return err
+	}
+	return client.Callback().Row().Before("gorm:db_resolver").Register("gugotik:read_routing", s.route)
+}
+
+// monitor 定期检查所有副本的复制延迟，直到 ctx 结束
+func (s *replicaSet) monitor(ctx context.Context, interval time.Duration) {
+	s.checkAll(ctx, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.checkAll(ctx, interval)
+		}
+	}
+}
+
+func (s *replicaSet) checkAll(ctx context.Context, timeout time.Duration) {
+	for _, r := range s.replicas {
+		s.check(ctx, r, timeout)
+	}
+}
+
+func (s *replicaSet) check(ctx context.Context, r *replica, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var seconds float64
+	err := r.db.QueryRowContext(ctx, replicaLagQuery).Scan(&seconds)
+	lag := time.Duration(seconds * float64(time.Second))
+	healthy := err == nil && lag <= s.maxLag
+
+	if err == nil {
+		prom.DBReplicaLag.WithLabelValues(r.addr).Set(seconds)
+	}
+	if healthy {
+		prom.DBReplicaHealthy.WithLabelValues(r.addr).Set(1)
+	} else {
+		prom.DBReplicaHealthy.WithLabelValues(r.addr).Set(0)
+	}
+
+	if healthy == r.healthy.Swap(healthy) {
+		return
+	}
+	logger := logging.LogService("Database.ReplicaMonitor").WithFields(logrus.Fields{
+		"replica": r.addr,
+		"lag":     lag.String(),
+		"maxLag":  s.maxLag.String(),
+		"err":     err,
+	})
+	if healthy {
+		logger.Infof("Replica caught up, serving reads again")
+	} else {
+		logger.Warnf("Replica is lagging or unreachable, ejected from reads")
+	}
+}
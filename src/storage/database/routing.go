@@ -0,0 +1,72 @@
+package database
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/storage/redis"
+	"context"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// primaryBaggageKey 通过 Baggage 标记请求需要读主库，标记会随 Trace 上下文经过 gRPC 与 RabbitMQ 传递到下游服务
+const primaryBaggageKey = "gugotik.read_primary"
+
+// WithPrimary 返回的 ctx 及由其发起的下游调用中，所有读请求都会路由到主库
+func WithPrimary(ctx context.Context) context.Context {
+	member, err := baggage.NewMember(primaryBaggageKey, "1")
+	if err != nil {
+		return ctx
+	}
+	bag, err := baggage.FromContext(ctx).SetMember(member)
+	if err != nil {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag)
+}
+
+// WithoutPrimary 删除 ctx 中读主库的标记，网关用于丢弃客户端通过请求头传入的 Baggage，标记只能由服务端设置
+func WithoutPrimary(ctx context.Context) context.Context {
+	bag := baggage.FromContext(ctx)
+	if bag.Member(primaryBaggageKey).Key() == "" {
+		return ctx
+	}
+	return baggage.ContextWithBaggage(ctx, bag.DeleteMember(primaryBaggageKey))
+}
+
+func usePrimary(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+	return baggage.FromContext(ctx).Member(primaryBaggageKey).Value() == "1"
+}
+
+func recentWriteKey(userId uint32) string {
+	return fmt.Sprintf("%sdb_recent_write_%d", config.EnvCfg.RedisPrefix, userId)
+}
+
+func primaryPinDuration() time.Duration {
+	duration, err := time.ParseDuration(config.EnvCfg.PostgreSQLPrimaryPin)
+	if err != nil {
+		return 5 * time.Second
+	}
+	return duration
+}
+
+// MarkWrite 记录用户刚刚写入过数据，之后一段时间内该用户的读请求都会路由到主库，保证用户能读到自己的写入
+func MarkWrite(ctx context.Context, userId uint32) error {
+	if redis.Client == nil {
+		return nil
+	}
+	return redis.Client.Set(ctx, recentWriteKey(userId), 1, primaryPinDuration()).Err()
+}
+
+// RecentlyWrote 用户是否在最近一段时间内写入过数据，Redis 不可用时按照没有写入处理
+func RecentlyWrote(ctx context.Context, userId uint32) bool {
+	if redis.Client == nil {
+		return false
+	}
+	existed, err := redis.Client.Exists(ctx, recentWriteKey(userId)).Result()
+	return err == nil && existed > 0
+}
@@ -2,34 +2,35 @@ package es
 
 import (
 	"GuGoTik/src/constant/config"
-	"log"
+	"fmt"
 
 	es "github.com/elastic/go-elasticsearch/v7"
 )
 
+// EsClient 由 deps.Container 在服务启动时设置，导入本包不会连接 Elasticsearch
 var EsClient *es.Client
 
-func init() {
+// New 根据环境变量配置连接 Elasticsearch，并创建消息索引
+func New() (*es.Client, error) {
 	cfg := es.Config{
 		Addresses: []string{
 			config.EnvCfg.ElasticsearchUrl,
 		},
 	}
-	var err error
-	EsClient, err = es.NewClient(cfg)
+	client, err := es.NewClient(cfg)
 	if err != nil {
-		log.Fatalf("elasticsearch.NewClient: %v", err)
+		return nil, fmt.Errorf("elasticsearch.NewClient: %w", err)
 	}
 
-	_, err = EsClient.Info()
+	_, err = client.Info()
 	if err != nil {
-		log.Fatalf("Error getting response: %s", err)
+		return nil, fmt.Errorf("error getting response: %w", err)
 	}
 
-	_, err = EsClient.API.Indices.Create("Message")
+	_, err = client.API.Indices.Create("Message")
 
 	if err != nil {
-		log.Fatalf("create index error: %s", err)
+		return nil, fmt.Errorf("create index error: %w", err)
 	}
-
+	return client, nil
 }
@@ -4,14 +4,21 @@ import (
 	"GuGoTik/src/constant/config"
 	"GuGoTik/src/extra/tracing"
 	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/pathgen"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"github.com/sirupsen/logrus"
 	"io"
 	"net/url"
 	"os"
 	"path"
+	"strings"
 )
 
+// 上传文件在目标目录中使用的临时文件后缀，写入完成后会被原子重命名为最终文件名
+const tempFileSuffix = ".uploading"
+
 type FSStorage struct {
 }
 
@@ -23,6 +30,12 @@ func (f FSStorage) GetLocalPath(ctx context.Context, fileName string) string {
 }
 
 func (f FSStorage) Upload(ctx context.Context, fileName string, content io.Reader) (output *PutObjectOutput, err error) {
+	return f.UploadWithChecksum(ctx, fileName, content, "")
+}
+
+// UploadWithChecksum 以流的方式将内容写入同目录下的临时文件，fsync 后原子重命名为目标文件，
+// 如果 checksum 不为空，那么写入内容的 SHA-256 必须与之相同，否则放弃本次写入
+func (f FSStorage) UploadWithChecksum(ctx context.Context, fileName string, content io.Reader, checksum string) (output *PutObjectOutput, err error) {
 	ctx, span := tracing.Tracer.Start(ctx, "FSStorage-Upload")
 	defer span.End()
 	logging.SetSpanWithHostname(span)
@@ -33,15 +46,91 @@ func (f FSStorage) Upload(ctx context.Context, fileName string, content io.Reade
 	})
 	logger.Debugf("Process start")
 
-	all, err := io.ReadAll(content)
+	filePath := path.Join(config.EnvCfg.FileSystemStartPath, fileName)
+
+	tmpPath, size, sum, err := writeTempFile(ctx, filePath, content)
 	if err != nil {
+		logging.SetSpanError(span, err)
+		return nil, err
+	}
+
+	if checksum != "" && !strings.EqualFold(checksum, sum) {
+		_ = os.Remove(tmpPath)
 		logger.WithFields(logrus.Fields{
-			"err": err,
-		}).Debug("Failed reading content")
+			"expected": checksum,
+			"actual":   sum,
+		}).Warnf("Checksum mismatch, discard the upload")
+		logging.SetSpanError(span, ErrChecksumMismatch)
+		return nil, ErrChecksumMismatch
+	}
+
+	if err = commitTempFile(ctx, tmpPath, filePath); err != nil {
+		logging.SetSpanError(span, err)
 		return nil, err
 	}
 
+	logger.WithFields(logrus.Fields{
+		"size":     size,
+		"checksum": sum,
+	}).Debug("Process done")
+
+	return &PutObjectOutput{
+		FileName: fileName,
+		Size:     size,
+		Checksum: sum,
+	}, nil
+}
+
+// UploadContentAddressed 边写入边计算 SHA-256，并以摘要作为文件名保存，相同内容只会保存一份
+func (f FSStorage) UploadContentAddressed(ctx context.Context, content io.Reader, ext string) (output *PutObjectOutput, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "FSStorage-UploadContentAddressed")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("FSStorage.UploadContentAddressed").WithContext(ctx)
+	logger.Debugf("Process start")
+
+	// 内容写完之前无法得知文件名，先以固定前缀写入临时文件
+	tmpPath, size, sum, err := writeTempFile(ctx, path.Join(config.EnvCfg.FileSystemStartPath, "content"), content)
+	if err != nil {
+		logging.SetSpanError(span, err)
+		return nil, err
+	}
+
+	fileName := pathgen.GenerateContentAddressedName(sum, ext)
 	filePath := path.Join(config.EnvCfg.FileSystemStartPath, fileName)
+	logger = logger.WithFields(logrus.Fields{
+		"file_name": fileName,
+		"size":      size,
+	})
+
+	if _, err = os.Stat(filePath); err == nil {
+		_ = os.Remove(tmpPath)
+		logger.Debug("Content already existed, skip writing")
+		return &PutObjectOutput{
+			FileName: fileName,
+			Size:     size,
+			Checksum: sum,
+			Existed:  true,
+		}, nil
+	}
+
+	if err = commitTempFile(ctx, tmpPath, filePath); err != nil {
+		logging.SetSpanError(span, err)
+		return nil, err
+	}
+
+	logger.Debug("Process done")
+	return &PutObjectOutput{
+		FileName: fileName,
+		Size:     size,
+		Checksum: sum,
+	}, nil
+}
+
+// writeTempFile 在 filePath 所在目录创建临时文件并写入内容，返回临时文件路径、大小与 SHA-256。
+// 临时文件与目标文件位于同一目录，保证 rename 不会跨文件系统
+func writeTempFile(ctx context.Context, filePath string, content io.Reader) (tmpPath string, size int64, sum string, err error) {
+	logger := logging.LogService("FSStorage.WriteTempFile").WithContext(ctx)
 
 	dir := path.Dir(filePath)
 	err = os.MkdirAll(dir, os.FileMode(0755))
@@ -50,19 +139,79 @@ func (f FSStorage) Upload(ctx context.Context, fileName string, content io.Reade
 		logger.WithFields(logrus.Fields{
 			"err": err,
 		}).Debug("Failed creating directory before writing file")
-		return nil, err
+		return
 	}
 
-	err = os.WriteFile(filePath, all, os.FileMode(0755))
+	tmp, err := os.CreateTemp(dir, "."+path.Base(filePath)+".*"+tempFileSuffix)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Debug("Failed creating temp file")
+		return
+	}
+	tmpPath = tmp.Name()
+
+	defer func() {
+		if err != nil {
+			_ = tmp.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
 
+	hash := sha256.New()
+	size, err = io.Copy(io.MultiWriter(tmp, hash), content)
 	if err != nil {
 		logger.WithFields(logrus.Fields{
 			"err": err,
 		}).Debug("Failed writing content to file")
-		return nil, err
+		return
+	}
+	sum = hex.EncodeToString(hash.Sum(nil))
+
+	if err = tmp.Sync(); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Debug("Failed syncing temp file")
+		return
+	}
+
+	if err = tmp.Chmod(os.FileMode(0644)); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Debug("Failed changing file mode")
+		return
+	}
+
+	if err = tmp.Close(); err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Debug("Failed closing temp file")
+		return
+	}
+	return
+}
+
+// commitTempFile 将临时文件原子重命名为目标文件，并同步目录项，确保 rename 在崩溃后依然可见
+func commitTempFile(ctx context.Context, tmpPath string, filePath string) error {
+	logger := logging.LogService("FSStorage.CommitTempFile").WithContext(ctx)
+
+	if err := os.Rename(tmpPath, filePath); err != nil {
+		_ = os.Remove(tmpPath)
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Debug("Failed renaming temp file")
+		return err
 	}
 
-	return &PutObjectOutput{}, nil
+	if d, err := os.Open(path.Dir(filePath)); err == nil {
+		if err = d.Sync(); err != nil {
+			logger.WithFields(logrus.Fields{
+				"err": err,
+			}).Debug("Failed syncing directory")
+		}
+		_ = d.Close()
+	}
+	return nil
 }
 
 func (f FSStorage) GetLink(ctx context.Context, fileName string) (string, error) {
@@ -87,3 +236,51 @@ func (f FSStorage) IsFileExist(ctx context.Context, fileName string) (bool, erro
 
 	return false, err
 }
+
+func (f FSStorage) Delete(ctx context.Context, fileName string) error {
+	_, span := tracing.Tracer.Start(ctx, "FSStorage-Delete")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	filePath := path.Join(config.EnvCfg.FileSystemStartPath, fileName)
+	err := os.Remove(filePath)
+	if err != nil && !os.IsNotExist(err) {
+		logging.SetSpanError(span, err)
+		return err
+	}
+	return nil
+}
+
+// Walk 只遍历 FS_PATH 的第一层，Upload 写入的文件都位于这一层，避免误扫描 FS_PATH 下的其他目录
+func (f FSStorage) Walk(ctx context.Context, fn func(object ObjectInfo) error) error {
+	_, span := tracing.Tracer.Start(ctx, "FSStorage-Walk")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	entries, err := os.ReadDir(config.EnvCfg.FileSystemStartPath)
+	if err != nil {
+		logging.SetSpanError(span, err)
+		return err
+	}
+
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// 文件可能在遍历期间被删除
+			if os.IsNotExist(err) {
+				continue
+			}
+			logging.SetSpanError(span, err)
+			return err
+		}
+		if err := fn(ObjectInfo{
+			Name:    entry.Name(),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
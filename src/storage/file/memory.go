@@ -0,0 +1,126 @@
+package file
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/utils/pathgen"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStorage 将文件保存在内存中的存储，用于测试
+type MemoryStorage struct {
+	mu      sync.Mutex
+	objects map[string]memoryObject
+}
+
+type memoryObject struct {
+	content []byte
+	modTime time.Time
+}
+
+// NewMemoryStorage 创建一个空的内存存储
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{objects: make(map[string]memoryObject)}
+}
+
+// Content 返回文件内容，用于在测试中检查写入结果
+func (m *MemoryStorage) Content(fileName string) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	object, ok := m.objects[fileName]
+	return object.content, ok
+}
+
+func (m *MemoryStorage) Upload(ctx context.Context, fileName string, content io.Reader) (*PutObjectOutput, error) {
+	return m.UploadWithChecksum(ctx, fileName, content, "")
+}
+
+func (m *MemoryStorage) UploadWithChecksum(_ context.Context, fileName string, content io.Reader, checksum string) (*PutObjectOutput, error) {
+	data, sum, err := readAll(content)
+	if err != nil {
+		return nil, err
+	}
+	if checksum != "" && checksum != sum {
+		return nil, ErrChecksumMismatch
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[fileName] = memoryObject{content: data, modTime: time.Now()}
+	return &PutObjectOutput{FileName: fileName, Size: int64(len(data)), Checksum: sum}, nil
+}
+
+func (m *MemoryStorage) UploadContentAddressed(_ context.Context, content io.Reader, ext string) (*PutObjectOutput, error) {
+	data, sum, err := readAll(content)
+	if err != nil {
+		return nil, err
+	}
+	fileName := pathgen.GenerateContentAddressedName(sum, ext)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, existed := m.objects[fileName]
+	if !existed {
+		m.objects[fileName] = memoryObject{content: data, modTime: time.Now()}
+	}
+	return &PutObjectOutput{FileName: fileName, Size: int64(len(data)), Checksum: sum, Existed: existed}, nil
+}
+
+func (m *MemoryStorage) GetLink(_ context.Context, fileName string) (string, error) {
+	return url.JoinPath(config.EnvCfg.FileSystemBaseUrl, fileName)
+}
+
+// GetLocalPath 内存存储没有本地路径，返回空字符串
+func (m *MemoryStorage) GetLocalPath(_ context.Context, _ string) string {
+	return ""
+}
+
+func (m *MemoryStorage) IsFileExist(_ context.Context, fileName string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.objects[fileName]
+	return ok, nil
+}
+
+func (m *MemoryStorage) Delete(_ context.Context, fileName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, fileName)
+	return nil
+}
+
+func (m *MemoryStorage) Walk(_ context.Context, fn func(object ObjectInfo) error) error {
+	m.mu.Lock()
+	objects := make([]ObjectInfo, 0, len(m.objects))
+	for name, object := range m.objects {
+		objects = append(objects, ObjectInfo{Name: name, Size: int64(len(object.content)), ModTime: object.modTime})
+	}
+	m.mu.Unlock()
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].Name < objects[j].Name
+	})
+	for _, object := range objects {
+		if err := fn(object); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readAll 读取全部内容并计算 SHA-256
+func readAll(content io.Reader) ([]byte, string, error) {
+	var buf bytes.Buffer
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(&buf, hash), content); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), hex.EncodeToString(hash.Sum(nil)), nil
+}
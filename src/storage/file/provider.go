@@ -3,43 +3,115 @@ package file
 import (
 	"GuGoTik/src/constant/config"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 )
 
-var client storageProvider
+var client Provider
 
-type storageProvider interface {
+var defaultOnce sync.Once
+
+// Provider 文件存储的实现，通过 Use 设置后由本包的函数使用
+type Provider interface {
 	Upload(ctx context.Context, fileName string, content io.Reader) (*PutObjectOutput, error)
+	UploadWithChecksum(ctx context.Context, fileName string, content io.Reader, checksum string) (*PutObjectOutput, error)
+	UploadContentAddressed(ctx context.Context, content io.Reader, ext string) (*PutObjectOutput, error)
 	GetLink(ctx context.Context, fileName string) (string, error)
 	GetLocalPath(ctx context.Context, fileName string) string
 	IsFileExist(ctx context.Context, fileName string) (bool, error)
+	Delete(ctx context.Context, fileName string) error
+	Walk(ctx context.Context, fn func(object ObjectInfo) error) error
+}
+
+// ObjectInfo 存储中单个文件的元信息
+type ObjectInfo struct {
+	Name    string    // 文件名，与 Upload 时使用的 fileName 相同
+	Size    int64     // 文件大小
+	ModTime time.Time // 最后修改时间
 }
 
-type PutObjectOutput struct{}
+type PutObjectOutput struct {
+	FileName string // 写入的文件名
+	Size     int64  // 实际写入的字节数
+	Checksum string // 写入内容的 SHA-256，十六进制编码
+	Existed  bool   // 按内容寻址上传时，相同内容的文件是否已经存在
+}
+
+// ErrChecksumMismatch 写入内容与期望的 SHA-256 不一致
+var ErrChecksumMismatch = errors.New("checksum of uploaded content mismatch")
 
-// 多态接口，根据绑定对象不同实现不同的方法，外部包直接使用file.xxx()调用
-func init() {
-	switch config.EnvCfg.StorageType { // Append more type here to provide more file action ability
+// New 根据存储类型创建文件存储
+func New(storageType string) (Provider, error) {
+	switch storageType { // Append more type here to provide more file action ability
 	case "fs":
-		client = FSStorage{}
+		return FSStorage{}, nil
 	}
+	return nil, fmt.Errorf("unsupported storage type: %s", storageType)
+}
+
+// Use 设置本包的函数使用的文件存储，一般由 deps.Container 在服务启动时调用
+func Use(provider Provider) {
+	defaultOnce.Do(func() {})
+	client = provider
+}
+
+// 多态接口，根据绑定对象不同实现不同的方法，外部包直接使用file.xxx()调用。
+// 没有调用 Use 时按照 STORAGE_TYPE 创建默认的文件存储
+func provider() Provider {
+	defaultOnce.Do(func() {
+		client, _ = New(config.EnvCfg.StorageType)
+	})
+	return client
 }
 
 func Upload(ctx context.Context, fileName string, content io.Reader) (*PutObjectOutput, error) {
-	return client.Upload(ctx, fileName, content)
+	return provider().Upload(ctx, fileName, content)
+}
+
+// UploadWithChecksum 上传文件并校验内容的 SHA-256，checksum 为空时不做校验
+func UploadWithChecksum(ctx context.Context, fileName string, content io.Reader, checksum string) (*PutObjectOutput, error) {
+	return provider().UploadWithChecksum(ctx, fileName, content, checksum)
+}
+
+// UploadContentAddressed 按内容寻址上传文件，文件名由内容的 SHA-256 与 ext 决定，相同内容不会重复保存
+func UploadContentAddressed(ctx context.Context, content io.Reader, ext string) (*PutObjectOutput, error) {
+	return provider().UploadContentAddressed(ctx, content, ext)
 }
 
 func GetLocalPath(ctx context.Context, fileName string) string {
-	return client.GetLocalPath(ctx, fileName)
+	return provider().GetLocalPath(ctx, fileName)
 }
 
 func GetLink(ctx context.Context, fileName string, userId uint32) (link string, err error) {
-	originLink, err := client.GetLink(ctx, fileName)
+	return GetUserLink(ctx, provider(), fileName, userId)
+}
+
+// GetUserLink 使用指定的 Provider 生成带有 user_id 的访问链接
+func GetUserLink(ctx context.Context, p Provider, fileName string, userId uint32) (link string, err error) {
+	originLink, err := p.GetLink(ctx, fileName)
 	link = fmt.Sprintf("%s?user_id=%d", originLink, userId)
 	return
 }
 
 func IsFileExist(ctx context.Context, fileName string) (bool, error) {
-	return client.IsFileExist(ctx, fileName)
+	return provider().IsFileExist(ctx, fileName)
+}
+
+// IsTempFile 判断文件是否为 Upload 过程中产生的临时文件
+func IsTempFile(fileName string) bool {
+	return strings.HasPrefix(fileName, ".") && strings.HasSuffix(fileName, tempFileSuffix)
+}
+
+// Delete 删除文件，文件不存在时不返回错误
+func Delete(ctx context.Context, fileName string) error {
+	return provider().Delete(ctx, fileName)
+}
+
+// Walk 遍历存储中的所有文件，fn 返回错误时停止遍历
+func Walk(ctx context.Context, fn func(object ObjectInfo) error) error {
+	return provider().Walk(ctx, fn)
 }
@@ -7,22 +7,25 @@ import (
 	"strings"
 )
 
+// Client 由 deps.Container 在服务启动时设置，导入本包不会连接 Redis
 var Client redis.UniversalClient
 
-func init() {
+// New 根据环境变量配置创建 Redis 客户端
+func New() (redis.UniversalClient, error) {
 	addrs := strings.Split(config.EnvCfg.RedisAddr, ";")
-	Client = redis.NewUniversalClient(&redis.UniversalOptions{
+	client := redis.NewUniversalClient(&redis.UniversalOptions{
 		Addrs:      addrs,
 		Password:   config.EnvCfg.RedisPassword,
 		DB:         config.EnvCfg.RedisDB,
 		MasterName: config.EnvCfg.RedisMaster,
 	})
 
-	if err := redisotel.InstrumentTracing(Client); err != nil {
-		panic(err)
+	if err := redisotel.InstrumentTracing(client); err != nil {
+		return nil, err
 	}
 
-	if err := redisotel.InstrumentMetrics(Client); err != nil {
-		panic(err)
+	if err := redisotel.InstrumentMetrics(client); err != nil {
+		return nil, err
 	}
+	return client, nil
 }
@@ -10,17 +10,11 @@ import (
 	"strconv"
 )
 
-var consulClient *capi.Client
-
-func init() {
+// newClient 根据环境变量配置创建 Consul 客户端
+func newClient() (*capi.Client, error) {
 	cfg := capi.DefaultConfig()
 	cfg.Address = config.EnvCfg.ConsulAddr
-	if c, err := capi.NewClient(cfg); err == nil {
-		consulClient = c
-		return
-	} else {
-		logging.Logger.Panicf("Connect Consul happens error: %v", err)
-	}
+	return capi.NewClient(cfg)
 }
 
 func RegisterConsul(name string, port string) error {
@@ -47,6 +41,11 @@ func RegisterConsul(name string, port string) error {
 			DeregisterCriticalServiceAfter: "30s",
 		},
 	}
+	consulClient, err := newClient()
+	if err != nil {
+		logging.Logger.Errorf("Connect Consul happens error: %v", err)
+		return err
+	}
 	if err := consulClient.Agent().ServiceRegister(reg); err != nil {
 		return err
 	}
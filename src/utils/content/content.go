@@ -0,0 +1,58 @@
+package content
+
+import (
+	"GuGoTik/src/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lock 在事务中锁定按内容寻址保存的文件，事务结束时自动释放。
+// 上传复用文件与回收文件都需要先获取锁，避免回收器删除了刚刚被复用的文件；
+// 文件可能还没有对应的 ContentObject 记录，因此使用以文件名为键的 advisory 锁而不是行锁
+func Lock(tx *gorm.DB, fileName string) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fileName).Error
+}
+
+// Reference 在事务中为内容增加一次 RawVideo 引用，内容记录不存在时创建
+func Reference(tx *gorm.DB, checksum string, fileName string, size int64) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "checksum"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count":  gorm.Expr("? + 1", clause.Column{Table: clause.CurrentTable, Name: "ref_count"}),
+			"file_name":  fileName,
+			"updated_at": time.Now(),
+		}),
+	}).Create(&models.ContentObject{
+		Checksum: checksum,
+		FileName: fileName,
+		Size:     size,
+		RefCount: 1,
+	}).Error
+}
+
+// ReleaseRawVideo 在事务中删除视频的 RawVideo 记录，并减少它引用的内容的引用计数。
+// RawVideo 不存在或已经被删除时返回 false
+func ReleaseRawVideo(tx *gorm.DB, videoId uint32) (released bool, err error) {
+	var raws []models.RawVideo
+	if err = tx.Select("video_id", "content_hash").Where("video_id = ?", videoId).Limit(1).Find(&raws).Error; err != nil {
+		return
+	}
+	if len(raws) == 0 {
+		return
+	}
+
+	result := tx.Where("video_id = ?", videoId).Delete(&models.RawVideo{})
+	if result.Error != nil || result.RowsAffected == 0 {
+		return false, result.Error
+	}
+	if raws[0].ContentHash == "" {
+		return true, nil
+	}
+
+	err = tx.Model(&models.ContentObject{}).
+		Where("checksum = ? AND ref_count > 0", raws[0].ContentHash).
+		UpdateColumn("ref_count", gorm.Expr("ref_count - 1")).Error
+	return err == nil, err
+}
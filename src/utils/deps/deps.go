@@ -0,0 +1,136 @@
+package deps
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/storage/database/migrate"
+	"GuGoTik/src/storage/es"
+	"GuGoTik/src/storage/file"
+	redis2 "GuGoTik/src/storage/redis"
+	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/rabbitmq"
+	"context"
+	"fmt"
+	elasticsearch "github.com/elastic/go-elasticsearch/v7"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"gorm.io/gorm"
+	"time"
+)
+
+// Container 一个服务运行所需的外部依赖，由 main 创建后传入 *ServiceImpl，测试时可以使用 NewFake 创建
+type Container struct {
+	DB      *gorm.DB
+	Redis   redis.UniversalClient
+	Storage file.Provider
+	ES      *elasticsearch.Client                     // 只有使用 WithES 创建时才会连接
+	DialMQ  func() (*amqp.Connection, error)          // 连接 RabbitMQ
+	Dial    func(serviceName string) *grpc.ClientConn // 连接其他 gRPC 服务
+}
+
+type options struct {
+	es           bool
+	verifySchema bool
+}
+
+// Option New 的可选配置
+type Option func(o *options)
+
+// WithES 同时连接 Elasticsearch
+func WithES() Option {
+	return func(o *options) {
+		o.es = true
+	}
+}
+
+// WithoutSchemaCheck 不检查数据库的 Schema 版本，用于 migrate 命令本身
+func WithoutSchemaCheck() Option {
+	return func(o *options) {
+		o.verifySchema = false
+	}
+}
+
+// New 根据环境变量配置连接所有依赖，并检查数据库已经执行了所有迁移，
+// 成功后将依赖设置为 database.Client 等包级变量的默认值
+func New(ctx context.Context, opts ...Option) (*Container, error) {
+	o := &options{verifySchema: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	db, err := database.Open()
+	if err != nil {
+		return nil, fmt.Errorf("connect database: %w", err)
+	}
+	if o.verifySchema {
+		if err := verifySchema(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+
+	redisClient, err := redis2.New()
+	if err != nil {
+		return nil, fmt.Errorf("connect redis: %w", err)
+	}
+
+	storage, err := file.New(config.EnvCfg.StorageType)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Container{
+		DB:      db,
+		Redis:   redisClient,
+		Storage: storage,
+		DialMQ: func() (*amqp.Connection, error) {
+			return amqp.Dial(rabbitmq.BuildMQConnAddr())
+		},
+		Dial: grpc2.Connect,
+	}
+
+	if o.es {
+		if c.ES, err = es.New(); err != nil {
+			return nil, fmt.Errorf("connect elasticsearch: %w", err)
+		}
+	}
+
+	c.Install()
+	return c, nil
+}
+
+// MustNew 与 New 相同，连接失败时 panic，用于服务的 main 函数
+func MustNew(opts ...Option) *Container {
+	c, err := New(context.Background(), opts...)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Install 将依赖设置为各个存储包的包级变量，供尚未通过 Container 获取依赖的代码使用
+func (c *Container) Install() {
+	database.Client = c.DB
+	redis2.Client = c.Redis
+	if c.Storage != nil {
+		file.Use(c.Storage)
+	}
+	if c.ES != nil {
+		es.EsClient = c.ES
+	}
+}
+
+func verifySchema(ctx context.Context, db *gorm.DB) error {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	migrator, err := migrate.New(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	return migrator.Verify(ctx)
+}
@@ -0,0 +1,82 @@
+package deps
+
+import (
+	"GuGoTik/src/storage/file"
+	"errors"
+	"fmt"
+	"github.com/alicebob/miniredis/v2"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+	"sync/atomic"
+)
+
+// ErrNotAvailable Fake 中没有提供的依赖
+var ErrNotAvailable = errors.New("dependency is not available in the fake container")
+
+// Fake 使用内存实现的依赖，不需要任何外部服务
+type Fake struct {
+	*Container
+	Miniredis   *miniredis.Miniredis // 可以用于控制时间、检查 Redis 中的数据
+	FileStorage *file.MemoryStorage
+}
+
+var fakeDBSeq atomic.Int64
+
+// NewFake 创建使用内存 SQLite、内存 Redis 与内存文件存储的依赖，并设置为包级变量的默认值。
+// 每个 Fake 的数据库相互独立且没有任何表，测试需要先对用到的模型执行 DB.AutoMigrate；
+// gRPC 连接不会连接到任何服务，测试可以按需替换 Container 中的字段后再次调用 Install
+func NewFake() (*Fake, error) {
+	db, err := gorm.Open(
+		sqlite.Open(fmt.Sprintf("file:fake%d?mode=memory&cache=shared", fakeDBSeq.Add(1))),
+		&gorm.Config{Logger: logger.Default.LogMode(logger.Silent)},
+	)
+	if err != nil {
+		return nil, err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	// 内存数据库在最后一个连接关闭时销毁，只保留一个连接
+	sqlDB.SetMaxOpenConns(1)
+
+	mr, err := miniredis.Run()
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, err
+	}
+
+	storage := file.NewMemoryStorage()
+	f := &Fake{
+		Container: &Container{
+			DB:      db,
+			Redis:   redis.NewClient(&redis.Options{Addr: mr.Addr()}),
+			Storage: storage,
+			DialMQ: func() (*amqp.Connection, error) {
+				return nil, ErrNotAvailable
+			},
+			Dial: func(serviceName string) *grpc.ClientConn {
+				conn, _ := grpc.Dial("passthrough:///"+serviceName, grpc.WithTransportCredentials(insecure.NewCredentials()))
+				return conn
+			},
+		},
+		Miniredis:   mr,
+		FileStorage: storage,
+	}
+	f.Install()
+	return f, nil
+}
+
+// Close 关闭内存数据库与内存 Redis
+func (f *Fake) Close() {
+	if sqlDB, err := f.DB.DB(); err == nil {
+		_ = sqlDB.Close()
+	}
+	_ = f.Redis.Close()
+	f.Miniredis.Close()
+}
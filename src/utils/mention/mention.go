@@ -0,0 +1,158 @@
+package mention
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/models"
+	"GuGoTik/src/utils/outbox"
+	"context"
+	"fmt"
+	"regexp"
+	strings2 "strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// MaxMentions 一条内容中最多解析的 @ 数量，超出的部分按普通文本处理
+const MaxMentions = 20
+
+// maxNameLength 与 users.user_name 的长度一致
+const maxNameLength = 32
+
+// pattern @ 前面不能是字母、数字或用户名中允许的符号，避免把邮箱地址解析为 @
+var pattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_.@-])(@[\p{L}\p{N}_.\-]+)`)
+
+// Candidate 内容中解析出的一次 @，Start 与 Length 以 Unicode 字符计，包含开头的 @
+type Candidate struct {
+	Name   string
+	Start  uint32
+	Length uint32
+}
+
+// Parse 按出现顺序解析 text 中的 @用户名，末尾的句点视为标点而不是用户名的一部分
+func Parse(text string) (candidates []Candidate) {
+	for _, loc := range pattern.FindAllStringSubmatchIndex(text, -1) {
+		if len(candidates) == MaxMentions {
+			break
+		}
+		token := strings2.TrimRight(text[loc[2]:loc[3]], ".")
+		name := token[1:]
+		if name == "" || utf8.RuneCountInString(name) > maxNameLength {
+			continue
+		}
+		candidates = append(candidates, Candidate{
+			Name:   name,
+			Start:  uint32(utf8.RuneCountInString(text[:loc[2]])),
+			Length: uint32(utf8.RuneCountInString(token)),
+		})
+	}
+	return
+}
+
+// Names 返回去重后的用户名，用于批量查询
+func Names(candidates []Candidate) []string {
+	seen := make(map[string]bool, len(candidates))
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if !seen[c.Name] {
+			seen[c.Name] = true
+			names = append(names, c.Name)
+		}
+	}
+	return names
+}
+
+// Bind 把解析结果与查询到的用户 id 对应起来，不存在的用户被忽略
+func Bind(candidates []Candidate, userIds map[string]uint32, itemType uint32, authorId uint32) (mentions []models.Mention) {
+	for _, c := range candidates {
+		userId, ok := userIds[c.Name]
+		if !ok {
+			continue
+		}
+		mentions = append(mentions, models.Mention{
+			ItemType: itemType,
+			AuthorId: authorId,
+			UserId:   userId,
+			UserName: c.Name,
+			Start:    c.Start,
+			Length:   c.Length,
+		})
+	}
+	return
+}
+
+// Exclude 去掉对 userIds 中用户的 @，用于忽略与作者存在拉黑关系的用户
+func Exclude(mentions []models.Mention, userIds []uint32) []models.Mention {
+	if len(userIds) == 0 {
+		return mentions
+	}
+	excluded := make(map[uint32]bool, len(userIds))
+	for _, id := range userIds {
+		excluded[id] = true
+	}
+	kept := mentions[:0]
+	for _, m := range mentions {
+		if !excluded[m.UserId] {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// Save 在 tx 所在的事务中写入 @ 记录
+func Save(ctx context.Context, tx *gorm.DB, itemId uint32, mentions []models.Mention) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+	for i := range mentions {
+		mentions[i].ItemId = itemId
+	}
+	return tx.WithContext(ctx).Create(&mentions).Error
+}
+
+// Remove 在 tx 所在的事务中删除内容原有的 @ 记录，用于内容被编辑时重新写入
+func Remove(ctx context.Context, tx *gorm.DB, itemType uint32, itemId uint32) error {
+	return tx.WithContext(ctx).Where("item_type = ? AND item_id = ?", itemType, itemId).Delete(&models.Mention{}).Error
+}
+
+// Notify 在 tx 所在的事务中给每个被 @ 的用户投递一次通知，作者 @ 自己时不通知
+func Notify(ctx context.Context, tx *gorm.DB, itemId uint32, videoId uint32, mentions []models.Mention) error {
+	notified := make(map[uint32]bool, len(mentions))
+	for _, m := range mentions {
+		if m.UserId == m.AuthorId || notified[m.UserId] {
+			continue
+		}
+		notified[m.UserId] = true
+		if err := outbox.Enqueue(ctx, tx, strings.NotificationExchange, strings.NotificationMentionEvent, models.NotificationEvent{
+			EventId:  fmt.Sprintf("mention-%d-%d-%d", m.ItemType, itemId, m.UserId),
+			Type:     models.NotificationMention,
+			ActorId:  m.AuthorId,
+			UserId:   m.UserId,
+			ItemType: m.ItemType,
+			ItemId:   itemId,
+			VideoId:  videoId,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Load 批量查询内容中的 @，按内容 id 分组并保持出现顺序
+func Load(ctx context.Context, db *gorm.DB, itemType uint32, itemIds []uint32) (map[uint32][]models.Mention, error) {
+	result := make(map[uint32][]models.Mention, len(itemIds))
+	if len(itemIds) == 0 {
+		return result, nil
+	}
+	var mentions []models.Mention
+	if err := db.WithContext(ctx).
+		Where("item_type = ? AND item_id IN ?", itemType, itemIds).
+		Order("item_id, start").
+		Find(&mentions).Error; err != nil {
+		return nil, err
+	}
+	for _, m := range mentions {
+		result[m.ItemId] = append(result[m.ItemId], m)
+	}
+	return result, nil
+}
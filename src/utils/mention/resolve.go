@@ -0,0 +1,40 @@
+package mention
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/models"
+	"GuGoTik/src/rpc/relation"
+	"GuGoTik/src/rpc/user"
+	"context"
+	"fmt"
+)
+
+// Resolve 解析内容中的 @ 并通过 UserService 查询用户 id，不存在或与作者存在拉黑关系的用户被忽略，
+// itemType 为 @ 所在内容的类型
+func Resolve(ctx context.Context, userClient user.UserServiceClient, relationClient relation.RelationServiceClient,
+	itemType uint32, authorId uint32, content string) ([]models.Mention, error) {
+	candidates := Parse(content)
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	resp, err := userClient.ResolveUserNames(ctx, &user.UserNamesRequest{UserNames: Names(candidates)})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != strings.ServiceOKCode {
+		return nil, fmt.Errorf("resolve user names failed: %s", resp.StatusMsg)
+	}
+	mentions := Bind(candidates, resp.UserIds, itemType, authorId)
+	if len(mentions) == 0 {
+		return nil, nil
+	}
+
+	hidden, err := relationClient.GetHiddenUsers(ctx, &relation.HiddenUsersRequest{ActorId: authorId})
+	if err != nil {
+		return nil, err
+	}
+	if hidden.StatusCode != strings.ServiceOKCode {
+		return nil, fmt.Errorf("query hidden users failed: %s", hidden.StatusMsg)
+	}
+	return Exclude(mentions, hidden.BlockedIds), nil
+}
@@ -0,0 +1,18 @@
+package moderation
+
+import (
+	"GuGoTik/src/constant/config"
+	"strings"
+)
+
+// NewLocalChain 按配置创建发布内容前同步执行的本地审核链，评论、视频标题与私信共用同一套规则
+func NewLocalChain() (*Chain, error) {
+	words, err := LoadWords(config.EnvCfg.ModerationWordsFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewChain(
+		NewWordStage(words),
+		NewSpamStage(strings.Split(config.EnvCfg.ModerationAllowDomains, ",")),
+	), nil
+}
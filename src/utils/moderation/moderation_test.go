@@ -0,0 +1,113 @@
+package moderation
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "lower", in: "PORN", want: "porn"},
+		{name: "full width", in: "ｐｏｒｎ", want: "porn"},
+		{name: "full width space", in: "ｐ　ｏ　ｒ　ｎ", want: "porn"},
+		{name: "single letters", in: "p o r n", want: "porn"},
+		{name: "punctuation between letters", in: "p.o-r_n", want: "porn"},
+		{name: "zero width", in: "po​rn", want: "porn"},
+		{name: "cyrillic", in: "рοrn", want: "porn"},
+		{name: "digits", in: "p0rn", want: "porn"},
+		{name: "vertical strokes", in: "k1|l", want: "kiii"},
+		{name: "symbol inside word", in: "a$$hole", want: "asshoie"},
+		{name: "symbol at word edges", in: "@porn!", want: "porn"},
+		{name: "symbol before han", in: "porn!傻", want: "porn傻"},
+		{name: "word boundary", in: "top ornament", want: "top ornament"},
+		{name: "separators collapse", in: "kill  ,  yourself!", want: "kiii yourseif"},
+		{name: "han", in: "约 炮", want: "约炮"},
+		{name: "han and latin", in: "傻 idiot 逼", want: "傻idiot逼"},
+		{name: "empty", in: " ,.!? ", want: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, string(Normalize(tc.in)))
+		})
+	}
+}
+
+func matchTexts(m *WordMatcher, text string) []string {
+	texts := make([]string, 0)
+	for _, word := range m.Match(text) {
+		texts = append(texts, word.Text)
+	}
+	sort.Strings(texts)
+	return texts
+}
+
+func TestWordMatcher(t *testing.T) {
+	m := NewWordMatcher([]Word{
+		{Text: "porn"},
+		{Text: "kill yourself"},
+		{Text: "自杀"},
+		{Text: "杀了你"},
+		{Text: "弄死你"},
+		{Text: "死你"},
+	})
+	for _, tc := range []struct {
+		name string
+		in   string
+		want []string
+	}{
+		{name: "plain", in: "free porn here", want: []string{"porn"}},
+		{name: "spacing", in: "p o r n", want: []string{"porn"}},
+		{name: "full width", in: "ｐｏｒｎ", want: []string{"porn"}},
+		{name: "homoglyph", in: "рοrn", want: []string{"porn"}},
+		{name: "leetspeak", in: "p0rn", want: []string{"porn"}},
+		{name: "hyphen splits word", in: "just KILL   your-self", want: []string{}},
+		{name: "phrase spacing", in: "kill, yourself", want: []string{"kill yourself"}},
+		{name: "trailing punctuation", in: "kill yourself!", want: []string{"kill yourself"}},
+		{name: "cross word", in: "top ornament", want: []string{}},
+		{name: "inside word", in: "topornament", want: []string{}},
+		{name: "next to han", in: "看porn吗", want: []string{"porn"}},
+		{name: "han spacing", in: "自 杀", want: []string{"自杀"}},
+		{name: "overlapping", in: "自杀了你", want: []string{"杀了你", "自杀"}},
+		{name: "failure link", in: "我弄死你", want: []string{"弄死你", "死你"}},
+		{name: "failure link after mismatch", in: "弄死了死你", want: []string{"死你"}},
+		{name: "repeated", in: "porn porn", want: []string{"porn"}},
+		{name: "clean", in: "今天天气不错", want: []string{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, matchTexts(m, tc.in))
+		})
+	}
+}
+
+func TestSpamStageLinks(t *testing.T) {
+	s := NewSpamStage([]string{"gugotik.com", " Example.ORG "})
+	for _, tc := range []struct {
+		name string
+		in   string
+		want Decision
+	}{
+		{name: "no link", in: "好看", want: Allow},
+		{name: "allowed domain", in: "看 https://gugotik.com/video/1", want: Allow},
+		{name: "allowed subdomain", in: "看 www.gugotik.com", want: Allow},
+		{name: "allowed case insensitive", in: "看 HTTP://CDN.EXAMPLE.ORG/a", want: Allow},
+		{name: "suffix is not subdomain", in: "看 https://evilgugotik.com", want: Hold},
+		{name: "allowed as subdomain of external", in: "看 gugotik.com.evil.cn", want: Hold},
+		{name: "external", in: "看 https://spam.xyz", want: Hold},
+		{name: "spaced external", in: "看 spam . xyz", want: Hold},
+		{name: "too many links", in: "a.xyz b.xyz c.xyz", want: Reject},
+		{name: "same link counted once", in: "a.xyz a.xyz a.xyz", want: Hold},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := s.Moderate(context.Background(), tc.in)
+			assert.Empty(t, err)
+			assert.Equal(t, tc.want, res.Decision)
+			assert.Equal(t, tc.want != Allow, res.Flagged)
+		})
+	}
+}
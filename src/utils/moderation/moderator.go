@@ -0,0 +1,157 @@
+package moderation
+
+import (
+	"context"
+	"strings"
+)
+
+// Decision 审核结论，数值越大越严格
+type Decision uint32
+
+const (
+	Allow  Decision = iota // 直接展示
+	Hold                   // 暂不展示，等待人工复核
+	Reject                 // 拒绝发布
+)
+
+func (d Decision) String() string {
+	switch d {
+	case Allow:
+		return "allow"
+	case Hold:
+		return "hold"
+	case Reject:
+		return "reject"
+	}
+	return "unknown"
+}
+
+// Categories 与 OpenAI Moderation 的分类一致，对应 models.Comment 中的 Moderation* 字段
+type Categories struct {
+	Hate            bool
+	HateThreatening bool
+	SelfHarm        bool
+	Sexual          bool
+	SexualMinors    bool
+	Violence        bool
+	ViolenceGraphic bool
+}
+
+// Set 按分类名称标记，不认识的分类（如 spam、politics）只体现在审核结论中
+func (c *Categories) Set(name string) {
+	switch name {
+	case "hate":
+		c.Hate = true
+	case "hate/threatening":
+		c.HateThreatening = true
+	case "self-harm":
+		c.SelfHarm = true
+	case "sexual":
+		c.Sexual = true
+	case "sexual/minors":
+		c.SexualMinors = true
+	case "violence":
+		c.Violence = true
+	case "violence/graphic":
+		c.ViolenceGraphic = true
+	}
+}
+
+func (c *Categories) merge(o Categories) {
+	c.Hate = c.Hate || o.Hate
+	c.HateThreatening = c.HateThreatening || o.HateThreatening
+	c.SelfHarm = c.SelfHarm || o.SelfHarm
+	c.Sexual = c.Sexual || o.Sexual
+	c.SexualMinors = c.SexualMinors || o.SexualMinors
+	c.Violence = c.Violence || o.Violence
+	c.ViolenceGraphic = c.ViolenceGraphic || o.ViolenceGraphic
+}
+
+// Result 一个审核阶段或整条审核链的结果
+type Result struct {
+	Decision   Decision
+	Rate       uint32 // 1-5，越大越不友好，0 表示该阶段没有给出评分
+	Reason     string
+	Flagged    bool // 需要人工关注，Decision 不为 Allow 时总是为 true
+	Categories Categories
+}
+
+// Moderator 审核阶段，返回错误时审核链跳过该阶段
+type Moderator interface {
+	Name() string
+	Moderate(ctx context.Context, content string) (Result, error)
+}
+
+// Chain 按顺序执行的审核链，结论取所有阶段中最严格的一个，遇到 Reject 时不再执行后面的阶段
+type Chain struct {
+	stages []Moderator
+	// OnError 阶段返回错误时调用，为空时忽略错误
+	OnError func(stage string, err error)
+}
+
+func NewChain(stages ...Moderator) *Chain {
+	return &Chain{stages: stages}
+}
+
+func (c *Chain) Name() string {
+	names := make([]string, 0, len(c.stages))
+	for _, stage := range c.stages {
+		names = append(names, stage.Name())
+	}
+	return strings.Join(names, ",")
+}
+
+func (c *Chain) Moderate(ctx context.Context, content string) (Result, error) {
+	merged := Result{Decision: Allow}
+	var reasons []string
+	for _, stage := range c.stages {
+		res, err := stage.Moderate(ctx, content)
+		if err != nil {
+			if c.OnError != nil {
+				c.OnError(stage.Name(), err)
+			}
+			continue
+		}
+		merged = Merge(merged, res)
+		if res.Reason != "" && (res.Decision != Allow || res.Flagged) {
+			reasons = append(reasons, res.Reason)
+		}
+		if merged.Decision == Reject {
+			break
+		}
+	}
+	merged.Reason = strings.Join(reasons, "；")
+	return merged, nil
+}
+
+// Merge 合并两个审核结果，评分与结论取较严格的一个，原因以 a 为准
+func Merge(a Result, b Result) Result {
+	if b.Rate == 0 {
+		b.Rate = defaultRate(b.Decision)
+	}
+	a.Decision = atLeast(a.Decision, b.Decision)
+	if b.Rate > a.Rate {
+		a.Rate = b.Rate
+	}
+	a.Flagged = a.Flagged || b.Flagged || a.Decision != Allow
+	a.Categories.merge(b.Categories)
+	return a
+}
+
+func atLeast(d Decision, min Decision) Decision {
+	if d < min {
+		return min
+	}
+	return d
+}
+
+// defaultRate 阶段没有评分时按结论给出，与原先按评分过滤评论的阈值保持一致
+func defaultRate(d Decision) uint32 {
+	switch d {
+	case Hold:
+		return 4
+	case Reject:
+		return 5
+	}
+	return 0
+}
@@ -0,0 +1,107 @@
+package moderation
+
+import "unicode"
+
+// homoglyphs 把外形相近的字符折叠为同一个拉丁字母，包括常见的西里尔/希腊字母与数字替换写法。
+// i、l、1 等竖线形状的字符互相替换的写法很常见，统一折叠为 i
+var homoglyphs = map[rune]rune{
+	'а': 'a', 'в': 'b', 'е': 'e', 'ё': 'e', 'к': 'k', 'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p',
+	'с': 'c', 'т': 't', 'у': 'y', 'х': 'x', 'і': 'i', 'ј': 'j', 'ѕ': 's', 'ԁ': 'd', 'ɡ': 'g',
+	'α': 'a', 'β': 'b', 'ε': 'e', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o', 'ρ': 'p', 'τ': 't',
+	'υ': 'u', 'χ': 'x',
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', 'l': 'i',
+}
+
+// symbolHomoglyphs 可以替换字母的符号，只在夹在字母或数字中间时折叠（如 "p@rn"），
+// 出现在词首或词尾时（如 "kill yourself!"、"@user"）仍然是分隔符
+var symbolHomoglyphs = map[rune]rune{
+	'@': 'a', '$': 's', '!': 'i', '|': 'i',
+}
+
+const (
+	runeSkip      rune = -1 // 零宽字符等不可见字符，直接丢弃
+	runeSeparator rune = -2 // 空白、标点与表情，视为分隔符
+)
+
+// Normalize 生成用于匹配的文本：全角转半角、转小写、折叠形近字符，去掉零宽字符，
+// 空白、标点与表情等分隔符折叠为一个空格，保留拉丁词之间的边界。
+// 两侧都是单个字符（如 "p o r n"）或有一侧是中日文字时去掉分隔符，使插入分隔符的写法仍然可以命中，
+// 而 "top ornament" 这样的正常词组不会拼接成新词
+func Normalize(s string) []rune {
+	var tokens [][]rune
+	var cur, symbols []rune
+	for _, r := range s {
+		r = normalizeRune(r)
+		if folded, ok := symbolHomoglyphs[r]; ok {
+			// 词首的符号直接丢弃，词中的符号等后面出现字母时再折叠
+			if len(cur) > 0 {
+				symbols = append(symbols, folded)
+			}
+			continue
+		}
+		switch {
+		case r == runeSkip:
+		case r == runeSeparator, len(symbols) > 0 && !isWordRune(r):
+			if len(cur) > 0 {
+				tokens = append(tokens, cur)
+				cur = nil
+			}
+			symbols = symbols[:0]
+			if r != runeSeparator {
+				cur = append(cur, r)
+			}
+		default:
+			cur = append(cur, symbols...)
+			cur = append(cur, r)
+			symbols = symbols[:0]
+		}
+	}
+	if len(cur) > 0 {
+		tokens = append(tokens, cur)
+	}
+
+	out := make([]rune, 0, len(s))
+	for i, token := range tokens {
+		if i > 0 {
+			prev := tokens[i-1]
+			single := len(prev) == 1 && len(token) == 1
+			if !single && isWordRune(prev[len(prev)-1]) && isWordRune(token[0]) {
+				out = append(out, ' ')
+			}
+		}
+		out = append(out, token...)
+	}
+	return out
+}
+
+// normalizeRune 返回折叠后的字符，可以替换字母的符号原样返回，需要丢弃时返回 runeSkip，分隔符返回 runeSeparator
+func normalizeRune(r rune) rune {
+	// 全角 ASCII 与全角空格
+	if r >= 0xFF01 && r <= 0xFF5E {
+		r -= 0xFEE0
+	} else if r == 0x3000 {
+		r = ' '
+	}
+	r = unicode.ToLower(r)
+	if folded, ok := homoglyphs[r]; ok {
+		return folded
+	}
+	if _, ok := symbolHomoglyphs[r]; ok {
+		return r
+	}
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return r
+	}
+	if unicode.Is(unicode.Cf, r) || unicode.Is(unicode.Mn, r) {
+		return runeSkip
+	}
+	return runeSeparator
+}
+
+// isWordRune 判断字符是否属于以空格分词的文字，中日文字不使用空格分词，词的两侧总是视为边界
+func isWordRune(r rune) bool {
+	if r == ' ' || unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
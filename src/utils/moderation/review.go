@@ -0,0 +1,51 @@
+package moderation
+
+import (
+	"GuGoTik/src/models"
+	"context"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SubmitReview 在 tx 所在的事务中把内容加入人工复核队列，已经处理过的内容再次被标记时回到待审核状态
+func SubmitReview(ctx context.Context, tx *gorm.DB, itemType uint32, itemId uint32, authorId uint32, content string, reason string) error {
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "item_type"}, {Name: "item_id"}},
+		DoUpdates: clause.Assignments(map[string]any{
+			"content":       content,
+			"reason":        reason,
+			"status":        models.ModerationReviewPending,
+			"reviewer_id":   0,
+			"review_reason": "",
+			"reviewed_at":   nil,
+			"updated_at":    time.Now(),
+		}),
+	}).Create(&models.ModerationReview{
+		ItemType: itemType,
+		ItemId:   itemId,
+		AuthorId: authorId,
+		Content:  content,
+		Reason:   reason,
+		Status:   models.ModerationReviewPending,
+	}).Error
+}
+
+// BannedUntil 查询用户当前生效的封禁，没有封禁时返回 false
+func BannedUntil(ctx context.Context, db *gorm.DB, userId uint32) (until time.Time, banned bool, err error) {
+	var ban models.UserBan
+	err = db.WithContext(ctx).
+		Select("until").
+		Where("user_id = ? AND until > ?", userId, time.Now()).
+		Order("until DESC").
+		Take(&ban).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return until, false, nil
+	}
+	if err != nil {
+		return
+	}
+	return ban.Until, true, nil
+}
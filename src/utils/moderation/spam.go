@@ -0,0 +1,136 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+var (
+	urlPattern     = regexp.MustCompile(`(?i)(?:https?://|www\.)[^\s，。！？]+|\b[a-z0-9-]+(?:\.[a-z0-9-]+)*\.(?:com|cn|net|org|io|xyz|top|cc|me|info|vip|club|site|link|shop)\b(?:/[^\s，。！？]*)?`)
+	contactPattern = regexp.MustCompile(`(?i)(?:vx|wx|v信|微信|威信|qq|扣扣|企鹅|tg|telegram)\s*(?:号)?\s*[:：]?\s*[a-z0-9_-]{5,}`)
+	phonePattern   = regexp.MustCompile(`(?:^|\D)1[3-9]\d{9}(?:\D|$)`)
+)
+
+const (
+	spamMaxLinks  = 2  // 外部链接超过该数量时直接拒绝
+	spamMaxRepeat = 15 // 同一个字符连续出现的最大次数，超过时视为刷屏
+)
+
+// SpamStage 基于正则的广告与刷屏审核阶段：外部链接、联系方式与大量重复字符
+type SpamStage struct {
+	allowDomains []string
+}
+
+// NewSpamStage allowDomains 中的域名及其子域名的链接不视为广告
+func NewSpamStage(allowDomains []string) *SpamStage {
+	s := &SpamStage{}
+	for _, domain := range allowDomains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain != "" {
+			s.allowDomains = append(s.allowDomains, domain)
+		}
+	}
+	return s
+}
+
+func (s *SpamStage) Name() string {
+	return "spam"
+}
+
+func (s *SpamStage) Moderate(_ context.Context, content string) (Result, error) {
+	res := Result{Decision: Allow}
+	var reasons []string
+
+	// 去掉空白后再匹配，避免用空格拆开链接与联系方式，直接匹配不到链接时才使用
+	compact := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, content)
+
+	links := s.externalLinks(content)
+	if len(links) == 0 {
+		links = s.externalLinks(compact)
+	}
+	if len(links) > 0 {
+		res.Decision = Hold
+		if len(links) > spamMaxLinks {
+			res.Decision = Reject
+		}
+		reasons = append(reasons, fmt.Sprintf("包含%d个外部链接", len(links)))
+	}
+
+	if contactPattern.MatchString(compact) || phonePattern.MatchString(compact) {
+		res.Decision = atLeast(res.Decision, Hold)
+		reasons = append(reasons, "包含联系方式")
+	}
+
+	if longestRepeat(content) > spamMaxRepeat {
+		res.Decision = atLeast(res.Decision, Hold)
+		reasons = append(reasons, "大量重复字符")
+	}
+
+	if len(reasons) > 0 {
+		res.Flagged = true
+		res.Reason = "疑似广告或刷屏：" + strings.Join(reasons, "、")
+	}
+	return res, nil
+}
+
+// externalLinks 文本中不在白名单中的链接，相同的链接只计算一次
+func (s *SpamStage) externalLinks(text string) map[string]bool {
+	links := map[string]bool{}
+	for _, link := range urlPattern.FindAllString(text, -1) {
+		if !s.allowed(link) {
+			links[strings.ToLower(link)] = true
+		}
+	}
+	return links
+}
+
+// allowed 判断链接的域名是否在白名单中
+func (s *SpamStage) allowed(link string) bool {
+	if len(s.allowDomains) == 0 {
+		return false
+	}
+	raw := link
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	for _, domain := range s.allowDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// longestRepeat 同一个非空白字符连续出现的最大次数
+func longestRepeat(content string) int {
+	longest, cur := 0, 0
+	var last rune = -1
+	for _, r := range content {
+		if unicode.IsSpace(r) {
+			continue
+		}
+		if r == last {
+			cur++
+		} else {
+			last, cur = r, 1
+		}
+		if cur > longest {
+			longest = cur
+		}
+	}
+	return longest
+}
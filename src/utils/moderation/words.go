@@ -0,0 +1,214 @@
+package moderation
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//go:embed words.txt
+var defaultWords string
+
+// Word 敏感词及命中后的处理方式
+type Word struct {
+	Text     string
+	Category string // 分类名称，见 Categories.Set
+	Decision Decision
+}
+
+// ParseWords 读取敏感词表，每行一个词，格式为 "词|分类|hold 或 reject"，
+// 分类与处理方式可以省略，默认为 other 与 hold；空行与 # 开头的行会被忽略
+func ParseWords(r io.Reader) ([]Word, error) {
+	var words []Word
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		parts := strings.Split(text, "|")
+		word := Word{Text: strings.TrimSpace(parts[0]), Category: "other", Decision: Hold}
+		if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+			word.Category = strings.TrimSpace(parts[1])
+		}
+		if len(parts) > 2 {
+			switch strings.TrimSpace(parts[2]) {
+			case "hold", "":
+			case "reject":
+				word.Decision = Reject
+			default:
+				return nil, fmt.Errorf("line %d: unknown decision %q", line, parts[2])
+			}
+		}
+		if len(Normalize(word.Text)) == 0 {
+			return nil, fmt.Errorf("line %d: empty word", line)
+		}
+		words = append(words, word)
+	}
+	return words, scanner.Err()
+}
+
+// LoadWords 从文件读取敏感词表，path 为空时使用内置的词表
+func LoadWords(path string) ([]Word, error) {
+	if path == "" {
+		return ParseWords(strings.NewReader(defaultWords))
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseWords(f)
+}
+
+type acNode struct {
+	next map[rune]int32
+	fail int32
+	out  []int32 // 在该节点结束的词，包括沿失败指针可以到达的词
+}
+
+// pattern 词经过 Normalize 后的长度，以及首尾是否需要落在词的边界上
+type pattern struct {
+	length     int
+	startBound bool
+	endBound   bool
+}
+
+// WordMatcher 基于 Aho-Corasick 自动机的敏感词匹配，一次扫描找出所有命中的词。
+// 词表与待匹配的文本都经过 Normalize，以拉丁字母开头或结尾的词只在词的边界上命中，
+// 避免正常词中间的片段（如 "topornament" 中的 porn）被命中；构建后只读，可以并发使用
+type WordMatcher struct {
+	nodes    []acNode
+	words    []Word
+	patterns []pattern
+}
+
+func NewWordMatcher(words []Word) *WordMatcher {
+	m := &WordMatcher{nodes: []acNode{{next: map[rune]int32{}}}, words: words, patterns: make([]pattern, len(words))}
+	for i, word := range words {
+		text := Normalize(word.Text)
+		if len(text) > 0 {
+			m.patterns[i] = pattern{
+				length:     len(text),
+				startBound: isWordRune(text[0]),
+				endBound:   isWordRune(text[len(text)-1]),
+			}
+		}
+		cur := int32(0)
+		for _, r := range text {
+			nxt, ok := m.nodes[cur].next[r]
+			if !ok {
+				nxt = int32(len(m.nodes))
+				m.nodes = append(m.nodes, acNode{next: map[rune]int32{}})
+				m.nodes[cur].next[r] = nxt
+			}
+			cur = nxt
+		}
+		m.nodes[cur].out = append(m.nodes[cur].out, int32(i))
+	}
+
+	// 按层构建失败指针
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[cur].next {
+			fail := m.nodes[cur].fail
+			for {
+				if target, ok := m.nodes[fail].next[r]; ok && target != child {
+					m.nodes[child].fail = target
+					break
+				}
+				if fail == 0 {
+					m.nodes[child].fail = 0
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			m.nodes[child].out = append(m.nodes[child].out, m.nodes[m.nodes[child].fail].out...)
+			queue = append(queue, child)
+		}
+	}
+	return m
+}
+
+// Match 返回文本中命中的敏感词，每个词只返回一次
+func (m *WordMatcher) Match(text string) []Word {
+	var hits []Word
+	seen := map[int32]bool{}
+	runes := Normalize(text)
+	cur := int32(0)
+	for pos, r := range runes {
+		for {
+			if nxt, ok := m.nodes[cur].next[r]; ok {
+				cur = nxt
+				break
+			}
+			if cur == 0 {
+				break
+			}
+			cur = m.nodes[cur].fail
+		}
+		for _, i := range m.nodes[cur].out {
+			if !seen[i] && m.bounded(runes, pos, i) {
+				seen[i] = true
+				hits = append(hits, m.words[i])
+			}
+		}
+	}
+	return hits
+}
+
+// bounded 判断在 end 处结束的词 i 是否满足边界要求
+func (m *WordMatcher) bounded(runes []rune, end int, i int32) bool {
+	p := m.patterns[i]
+	start := end - p.length + 1
+	if p.startBound && start > 0 && isWordRune(runes[start-1]) {
+		return false
+	}
+	if p.endBound && end+1 < len(runes) && isWordRune(runes[end+1]) {
+		return false
+	}
+	return true
+}
+
+// WordStage 敏感词审核阶段
+type WordStage struct {
+	matcher *WordMatcher
+}
+
+func NewWordStage(words []Word) *WordStage {
+	return &WordStage{matcher: NewWordMatcher(words)}
+}
+
+func (s *WordStage) Name() string {
+	return "words"
+}
+
+func (s *WordStage) Moderate(_ context.Context, content string) (Result, error) {
+	res := Result{Decision: Allow}
+	hits := s.matcher.Match(content)
+	if len(hits) == 0 {
+		return res, nil
+	}
+	texts := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		if hit.Decision > res.Decision {
+			res.Decision = hit.Decision
+		}
+		res.Categories.Set(hit.Category)
+		texts = append(texts, hit.Text)
+	}
+	res.Flagged = true
+	res.Reason = "包含敏感词：" + strings.Join(texts, "、")
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package outbox
+
+import (
+	"GuGoTik/src/models"
+	"GuGoTik/src/utils/rabbitmq"
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Enqueue 在 tx 所在的事务中写入一条待投递的消息，payload 会被编码为 JSON。
+// 事务提交之后才会被 Relay 看到，回滚时消息也一起丢弃，从而保证业务变更与消息同时生效。
+func Enqueue(ctx context.Context, tx *gorm.DB, exchange string, routingKey string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	// 保存当前的 Trace 上下文，Relay 投递时原样带上，消费者仍然可以串联到同一条链路
+	headers, err := json.Marshal(rabbitmq.InjectAMQPHeaders(ctx))
+	if err != nil {
+		return err
+	}
+
+	return tx.WithContext(ctx).Create(&models.OutboxMessage{
+		Exchange:      exchange,
+		RoutingKey:    routingKey,
+		Body:          body,
+		Headers:       string(headers),
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}).Error
+}
+
+// Filter 运维查询与重放 Outbox 消息时的过滤条件，零值字段表示不过滤
+type Filter struct {
+	Ids         []uint64 // 指定的消息 Id
+	Status      string   // 消息状态
+	MinAttempts int      // 至少已经尝试投递的次数，用于找出一直在重试的消息
+	Limit       int      // 最多返回的数量
+}
+
+func (f Filter) apply(db *gorm.DB) *gorm.DB {
+	if len(f.Ids) > 0 {
+		db = db.Where("id IN ?", f.Ids)
+	}
+	if f.Status != "" {
+		db = db.Where("status = ?", f.Status)
+	}
+	if f.MinAttempts > 0 {
+		db = db.Where("attempts >= ?", f.MinAttempts)
+	}
+	return db
+}
+
+// List 按 Id 顺序列出符合条件的消息
+func List(ctx context.Context, db *gorm.DB, filter Filter) (messages []models.OutboxMessage, err error) {
+	query := filter.apply(db.WithContext(ctx).Model(&models.OutboxMessage{})).Order("id")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	err = query.Find(&messages).Error
+	return
+}
+
+// Replay 将符合条件且尚未投递成功的消息重置为待投递状态，并清空重试次数，返回被重置的数量
+func Replay(ctx context.Context, db *gorm.DB, filter Filter) (int64, error) {
+	result := filter.apply(db.WithContext(ctx).Model(&models.OutboxMessage{})).
+		Where("status <> ?", models.OutboxSent).
+		Updates(map[string]any{
+			"status":          models.OutboxPending,
+			"attempts":        0,
+			"next_attempt_at": time.Now(),
+		})
+	return result.RowsAffected, result.Error
+}
+
+// Purge 删除在 before 之前已经投递成功的消息，返回删除的数量
+func Purge(ctx context.Context, db *gorm.DB, before time.Time) (int64, error) {
+	result := db.WithContext(ctx).
+		Where("status = ? AND sent_at < ?", models.OutboxSent, before).
+		Delete(&models.OutboxMessage{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,297 @@
+package outbox
+
+import (
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/models"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/utils/prom"
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// exchanges Relay 打开 Channel 时声明的 Exchange，需要与生产者原先声明的类型保持一致
+var exchanges = map[string]string{
+	strings.EventExchange:        "topic",
+	strings.AuditExchange:        "direct",
+	strings.NotificationExchange: "topic",
+}
+
+var errNacked = errors.New("message was nacked by the broker")
+
+// Relay 轮询 Outbox 表，以 Publisher Confirm 的方式将消息投递到 RabbitMQ。
+// 多个 Relay 可以同时运行，每个 Relay 通过 FOR UPDATE SKIP LOCKED 领取不同的消息。
+type Relay struct {
+	DB             *gorm.DB
+	DialMQ         func() (*amqp.Connection, error)
+	BatchSize      int           // 每一轮最多领取的消息数量
+	MaxAttempts    int           // 超过该次数后消息被标记为 dead，等待人工重放
+	BaseBackoff    time.Duration // 第一次重试的间隔，之后每次翻倍
+	MaxBackoff     time.Duration // 重试间隔的上限
+	ConfirmTimeout time.Duration // 等待 Broker 确认的超时时间
+	Retention      time.Duration // 已投递的消息保留的时长，为 0 时不清理
+
+	conn      *amqp.Connection
+	channel   *amqp.Channel
+	lastPurge time.Time
+}
+
+// Run 持续投递消息直到 ctx 结束
+func (r *Relay) Run(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		count, err := r.Dispatch(ctx)
+		r.purge(ctx)
+		r.observeBacklog(ctx)
+
+		// 领取满一批时说明还有积压，不等待直接进行下一轮
+		if err != nil || count < r.BatchSize {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		} else if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// Dispatch 领取一批到期的消息并投递，返回领取的消息数量
+func (r *Relay) Dispatch(ctx context.Context) (count int, err error) {
+	ctx, span := tracing.Tracer.Start(ctx, "OutboxRelay.Dispatch")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("OutboxRelay.Dispatch").WithContext(ctx)
+
+	// RabbitMQ 不可用时不领取消息，避免在故障期间消耗消息的重试次数
+	channel, err := r.ensureChannel()
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Cannot open the RabbitMQ channel")
+		logging.SetSpanError(span, err)
+		return
+	}
+
+	err = r.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var messages []models.OutboxMessage
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, time.Now()).
+			Order("id").
+			Limit(r.BatchSize).
+			Find(&messages).Error; err != nil {
+			return err
+		}
+		count = len(messages)
+
+		// 先全部发出再统一等待确认，一批消息只需要等待一次往返
+		confirms := make([]*amqp.DeferredConfirmation, len(messages))
+		errs := make([]error, len(messages))
+		for i := range messages {
+			confirms[i], errs[i] = publish(ctx, channel, &messages[i])
+		}
+
+		waitCtx, cancel := context.WithTimeout(ctx, r.ConfirmTimeout)
+		defer cancel()
+		for i := range messages {
+			if errs[i] == nil {
+				errs[i] = waitConfirm(waitCtx, confirms[i])
+			}
+			if err := r.settle(tx, &messages[i], errs[i]); err != nil {
+				return err
+			}
+			if errs[i] != nil {
+				logger.WithFields(logrus.Fields{
+					"err":      errs[i],
+					"id":       messages[i].ID,
+					"exchange": messages[i].Exchange,
+					"key":      messages[i].RoutingKey,
+					"attempts": messages[i].Attempts,
+					"status":   messages[i].Status,
+				}).Warnf("Failed to publish the outbox message")
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Failed to dispatch outbox messages")
+		logging.SetSpanError(span, err)
+	}
+	return
+}
+
+// Close 关闭 Relay 持有的 RabbitMQ 连接
+func (r *Relay) Close() {
+	if r.channel != nil {
+		_ = r.channel.Close()
+	}
+	if r.conn != nil {
+		_ = r.conn.Close()
+	}
+}
+
+func publish(ctx context.Context, channel *amqp.Channel, message *models.OutboxMessage) (*amqp.DeferredConfirmation, error) {
+	headers := amqp.Table{}
+	if message.Headers != "" {
+		if err := json.Unmarshal([]byte(message.Headers), &headers); err != nil {
+			return nil, err
+		}
+	}
+
+	return channel.PublishWithDeferredConfirmWithContext(ctx,
+		message.Exchange,
+		message.RoutingKey,
+		false,
+		false,
+		amqp.Publishing{
+			ContentType:  "text/plain",
+			DeliveryMode: amqp.Persistent,
+			MessageId:    strconv.FormatUint(message.ID, 10),
+			Body:         message.Body,
+			Headers:      headers,
+		})
+}
+
+func waitConfirm(ctx context.Context, confirm *amqp.DeferredConfirmation) error {
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errNacked
+	}
+	return nil
+}
+
+// settle 根据投递结果更新消息的状态，失败时按指数退避安排下一次重试
+func (r *Relay) settle(tx *gorm.DB, message *models.OutboxMessage, publishErr error) error {
+	now := time.Now()
+	message.Attempts++
+
+	updates := map[string]any{
+		"attempts": message.Attempts,
+	}
+	result := "sent"
+	if publishErr == nil {
+		message.Status = models.OutboxSent
+		updates["status"] = models.OutboxSent
+		updates["sent_at"] = now
+	} else {
+		result = "retry"
+		updates["last_error"] = publishErr.Error()
+		updates["next_attempt_at"] = now.Add(r.backoff(message.Attempts))
+		if message.Attempts >= r.MaxAttempts {
+			result = "dead"
+			message.Status = models.OutboxDead
+			updates["status"] = models.OutboxDead
+		}
+	}
+
+	if err := tx.Model(message).Updates(updates).Error; err != nil {
+		return err
+	}
+	prom.OutboxDispatched.WithLabelValues(result).Inc()
+	return nil
+}
+
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.BaseBackoff
+	for i := 1; i < attempts && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	return backoff
+}
+
+// ensureChannel 返回处于 Confirm 模式的 Channel，连接或 Channel 被关闭时重新建立
+func (r *Relay) ensureChannel() (*amqp.Channel, error) {
+	if r.channel != nil && !r.channel.IsClosed() {
+		return r.channel, nil
+	}
+
+	if r.conn == nil || r.conn.IsClosed() {
+		conn, err := r.DialMQ()
+		if err != nil {
+			return nil, err
+		}
+		r.conn = conn
+	}
+
+	channel, err := r.conn.Channel()
+	if err != nil {
+		return nil, err
+	}
+
+	for name, kind := range exchanges {
+		if err := channel.ExchangeDeclare(name, kind, true, false, false, false, nil); err != nil {
+			_ = channel.Close()
+			return nil, err
+		}
+	}
+
+	if err := channel.Confirm(false); err != nil {
+		_ = channel.Close()
+		return nil, err
+	}
+
+	r.channel = channel
+	return channel, nil
+}
+
+func (r *Relay) purge(ctx context.Context) {
+	if r.Retention <= 0 || time.Since(r.lastPurge) < time.Hour {
+		return
+	}
+	r.lastPurge = time.Now()
+
+	deleted, err := Purge(ctx, r.DB, time.Now().Add(-r.Retention))
+	logger := logging.LogService("OutboxRelay.Purge").WithContext(ctx)
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Failed to purge sent outbox messages")
+		return
+	}
+	if deleted > 0 {
+		logger.WithFields(logrus.Fields{
+			"deleted": deleted,
+		}).Infof("Purged sent outbox messages")
+	}
+}
+
+func (r *Relay) observeBacklog(ctx context.Context) {
+	var rows []struct {
+		Status string
+		Count  int64
+	}
+	err := r.DB.WithContext(ctx).Model(&models.OutboxMessage{}).
+		Select("status, count(*) AS count").
+		Where("status <> ?", models.OutboxSent).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return
+	}
+
+	prom.OutboxBacklog.WithLabelValues(models.OutboxPending).Set(0)
+	prom.OutboxBacklog.WithLabelValues(models.OutboxDead).Set(0)
+	for _, row := range rows {
+		prom.OutboxBacklog.WithLabelValues(row.Status).Set(float64(row.Count))
+	}
+}
@@ -35,3 +35,8 @@ func GenerateNameWatermark(actorId uint32, Name string) string {
 	hash := sha256.Sum256([]byte("Watermark" + strconv.FormatUint(uint64(actorId), 10) + Name))
 	return hex.EncodeToString(hash[:]) + ".png"
 }
+
+// GenerateContentAddressedName 按内容寻址的文件名，checksum 为文件内容的 SHA-256 十六进制编码
+func GenerateContentAddressedName(checksum string, ext string) string {
+	return checksum + ext
+}
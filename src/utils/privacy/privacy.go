@@ -0,0 +1,28 @@
+package privacy
+
+import (
+	"GuGoTik/src/models"
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// CanView 判断 actorId 能否查看 userId 的作品、喜欢、关注与粉丝列表，私密账号只对本人与粉丝可见。
+// isPrivate 为 userId 是否是私密账号，调用方通常已经通过 UserService 查询过，避免重复查询
+func CanView(ctx context.Context, db *gorm.DB, actorId uint32, userId uint32, isPrivate bool) (bool, error) {
+	if actorId == userId || !isPrivate {
+		return true, nil
+	}
+	if actorId == 0 {
+		return false, nil
+	}
+
+	var count int64
+	if err := db.WithContext(ctx).
+		Model(&models.Relation{}).
+		Where("actor_id = ? AND user_id = ?", actorId, userId).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
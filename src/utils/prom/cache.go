@@ -0,0 +1,32 @@
+package prom
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// CacheRequests 各命名空间在每一级缓存的命中情况，layer 为 local/redis/loader，result 为 hit/miss/error
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gugotik",
+		Subsystem: "cache",
+		Name:      "requests_total",
+		Help:      "Cache lookups by namespace, layer and result.",
+	}, []string{"namespace", "layer", "result"})
+	// CacheLoadDuration 缓存未命中时调用数据源加载的耗时
+	CacheLoadDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "gugotik",
+		Subsystem: "cache",
+		Name:      "load_duration_seconds",
+		Help:      "Time spent loading missed cache entries from the data source.",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.3, 0.6, 1, 3, 6},
+	}, []string{"namespace"})
+	// CacheLocalRejected 本地缓存达到容量上限而没有写入的次数
+	CacheLocalRejected = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gugotik",
+		Subsystem: "cache",
+		Name:      "local_rejected_total",
+		Help:      "Local memory cache writes skipped because the namespace is full.",
+	}, []string{"namespace"})
+)
+
+func init() {
+	Client.MustRegister(CacheRequests, CacheLoadDuration, CacheLocalRejected)
+}
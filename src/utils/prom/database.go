@@ -0,0 +1,31 @@
+package prom
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// DBReplicaLag 各只读副本的复制延迟
+	DBReplicaLag = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "gugotik",
+		Subsystem: "database",
+		Name:      "replica_lag_seconds",
+		Help:      "Replication lag of each PostgreSQL replica.",
+	}, []string{"replica"})
+	// DBReplicaHealthy 只读副本是否参与读请求的路由，1 表示参与，0 表示已被剔除
+	DBReplicaHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "gugotik",
+		Subsystem: "database",
+		Name:      "replica_healthy",
+		Help:      "Whether the PostgreSQL replica is serving reads.",
+	}, []string{"replica"})
+	// DBReadRouting 读请求的路由结果，target 为 primary/replica，reason 说明选择的原因
+	DBReadRouting = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gugotik",
+		Subsystem: "database",
+		Name:      "read_routing_total",
+		Help:      "Read queries routed to the primary or the replicas, by reason.",
+	}, []string{"target", "reason"})
+)
+
+func init() {
+	Client.MustRegister(DBReplicaLag, DBReplicaHealthy, DBReadRouting)
+}
@@ -0,0 +1,24 @@
+package prom
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var (
+	// OutboxDispatched Relay 投递 Outbox 消息的结果，result 为 sent/retry/dead
+	OutboxDispatched = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "gugotik",
+		Subsystem: "outbox",
+		Name:      "dispatched_total",
+		Help:      "Outbox messages handled by the relay, by result.",
+	}, []string{"result"})
+	// OutboxBacklog 等待投递的 Outbox 消息数量
+	OutboxBacklog = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "gugotik",
+		Subsystem: "outbox",
+		Name:      "messages",
+		Help:      "Outbox messages which are not sent yet, by status.",
+	}, []string{"status"})
+)
+
+func init() {
+	Client.MustRegister(OutboxDispatched, OutboxBacklog)
+}
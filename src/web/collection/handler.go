@@ -0,0 +1,239 @@
+package collection
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/rpc/collection"
+	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/web/models"
+	"GuGoTik/src/web/utils"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"net/http"
+)
+
+var Client collection.CollectionServiceClient
+
+func init() {
+	conn := grpc2.Connect(config.CollectionRpcServerName)
+	Client = collection.NewCollectionServiceClient(conn)
+}
+
+func CreateCollectionHandler(c *gin.Context) {
+	var req models.CreateCollectionReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "CreateCollectionHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.CreateCollection").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.CollectionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.CreateCollection(c.Request.Context(), &collection.CreateCollectionRequest{
+		ActorId: uint32(req.ActorId),
+		Name:    req.Name,
+		Private: req.Private,
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId": req.ActorId,
+			"Name":    req.Name,
+		}).Warnf("Error when trying to connect with CreateCollectionService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func UpdateCollectionHandler(c *gin.Context) {
+	var req models.UpdateCollectionReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "UpdateCollectionHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.UpdateCollection").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.CollectionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.UpdateCollection(c.Request.Context(), &collection.UpdateCollectionRequest{
+		ActorId:      uint32(req.ActorId),
+		CollectionId: uint32(req.CollectionId),
+		Name:         req.Name,
+		Private:      req.Private,
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":      req.ActorId,
+			"CollectionId": req.CollectionId,
+		}).Warnf("Error when trying to connect with UpdateCollectionService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func DeleteCollectionHandler(c *gin.Context) {
+	var req models.DeleteCollectionReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "DeleteCollectionHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.DeleteCollection").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.CollectionActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.DeleteCollection(c.Request.Context(), &collection.DeleteCollectionRequest{
+		ActorId:      uint32(req.ActorId),
+		CollectionId: uint32(req.CollectionId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":      req.ActorId,
+			"CollectionId": req.CollectionId,
+		}).Warnf("Error when trying to connect with DeleteCollectionService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func ListCollectionsHandler(c *gin.Context) {
+	var req models.ListCollectionsReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListCollectionsHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.ListCollections").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.ListCollectionsRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListCollections(c.Request.Context(), &collection.ListCollectionsRequest{
+		ActorId: uint32(req.ActorId),
+		UserId:  uint32(req.UserId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId": req.ActorId,
+			"UserId":  req.UserId,
+		}).Warnf("Error when trying to connect with ListCollectionsService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func AddVideoHandler(c *gin.Context) {
+	collectionVideoAction(c, "AddVideo", Client.AddVideo)
+}
+
+func RemoveVideoHandler(c *gin.Context) {
+	collectionVideoAction(c, "RemoveVideo", Client.RemoveVideo)
+}
+
+// collectionVideoAction 添加与移除视频的请求参数相同，只有调用的 RPC 不同
+func collectionVideoAction(c *gin.Context, name string, call func(ctx context.Context, in *collection.CollectionVideoRequest, opts ...grpc.CallOption) (*collection.CollectionActionResponse, error)) {
+	var req models.CollectionVideoReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), name+"Handler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay." + name).WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.CollectionActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := call(c.Request.Context(), &collection.CollectionVideoRequest{
+		ActorId:      uint32(req.ActorId),
+		CollectionId: uint32(req.CollectionId),
+		VideoId:      uint32(req.VideoId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":      req.ActorId,
+			"CollectionId": req.CollectionId,
+			"VideoId":      req.VideoId,
+		}).Warnf("Error when trying to connect with %sService", name)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func MoveVideoHandler(c *gin.Context) {
+	var req models.MoveCollectionVideoReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "MoveVideoHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.MoveVideo").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.CollectionActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.MoveVideo(c.Request.Context(), &collection.MoveCollectionVideoRequest{
+		ActorId:      uint32(req.ActorId),
+		CollectionId: uint32(req.CollectionId),
+		VideoId:      uint32(req.VideoId),
+		AfterVideoId: uint32(req.AfterVideoId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":      req.ActorId,
+			"CollectionId": req.CollectionId,
+			"VideoId":      req.VideoId,
+		}).Warnf("Error when trying to connect with MoveVideoService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func ListCollectionVideosHandler(c *gin.Context) {
+	var req models.ListCollectionVideosReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListCollectionVideosHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.ListCollectionVideos").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil || req.Limit < 0 {
+		c.JSON(http.StatusOK, models.ListCollectionVideosRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListCollectionVideos(c.Request.Context(), &collection.ListCollectionVideosRequest{
+		ActorId:      uint32(req.ActorId),
+		CollectionId: uint32(req.CollectionId),
+		Cursor:       req.Cursor,
+		Limit:        uint32(req.Limit),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":      req.ActorId,
+			"CollectionId": req.CollectionId,
+		}).Warnf("Error when trying to connect with ListCollectionVideosService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
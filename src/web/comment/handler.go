@@ -44,6 +44,7 @@ func ActionCommentHandler(c *gin.Context) {
 			VideoId:    uint32(req.VideoId),
 			ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD,
 			Action:     &comment.ActionCommentRequest_CommentText{CommentText: req.CommentText},
+			ParentId:   uint32(req.ParentId),
 		})
 	} else if req.ActionType == 2 {
 		res, err = Client.ActionComment(c.Request.Context(), &comment.ActionCommentRequest{
@@ -52,6 +53,14 @@ func ActionCommentHandler(c *gin.Context) {
 			ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_DELETE,
 			Action:     &comment.ActionCommentRequest_CommentId{CommentId: uint32(req.CommentId)},
 		})
+	} else if req.ActionType == 3 {
+		res, err = Client.ActionComment(c.Request.Context(), &comment.ActionCommentRequest{
+			ActorId:       uint32(req.ActorId),
+			VideoId:       uint32(req.VideoId),
+			ActionType:    comment.ActionCommentType_ACTION_COMMENT_TYPE_EDIT,
+			Action:        &comment.ActionCommentRequest_CommentText{CommentText: req.CommentText},
+			EditCommentId: uint32(req.CommentId),
+		})
 	} else {
 		c.JSON(http.StatusOK, models.ActionCommentRes{
 			StatusCode: strings.GateWayParamsErrorCode,
@@ -91,9 +100,14 @@ func ListCommentHandler(c *gin.Context) {
 		return
 	}
 
+	sort := comment.CommentSort_COMMENT_SORT_NEW
+	if req.Sort == "hot" {
+		sort = comment.CommentSort_COMMENT_SORT_HOT
+	}
 	res, err := Client.ListComment(c.Request.Context(), &comment.ListCommentRequest{
 		ActorId: uint32(req.ActorId),
 		VideoId: uint32(req.VideoId),
+		Sort:    sort,
 	})
 	if err != nil {
 		logger.WithFields(logrus.Fields{
@@ -112,6 +126,111 @@ func ListCommentHandler(c *gin.Context) {
 	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
 }
 
+func ListRepliesHandler(c *gin.Context) {
+	var req models.ListRepliesReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListRepliesHandler")
+	defer span.End()
+	logger := logging.LogService("GateWay.ListReplies").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil || req.Limit < 0 {
+		c.JSON(http.StatusOK, models.ListRepliesRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListReplies(c.Request.Context(), &comment.ListRepliesRequest{
+		ActorId:   uint32(req.ActorId),
+		CommentId: uint32(req.CommentId),
+		Cursor:    req.Cursor,
+		Limit:     uint32(req.Limit),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"comment_id": req.CommentId,
+			"actor_id":   req.ActorId,
+		}).Warnf("Error when trying to connect with ListRepliesService")
+		c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"comment_id": req.CommentId,
+		"actor_id":   req.ActorId,
+	}).Infof("List replies success")
+
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func LikeCommentHandler(c *gin.Context) {
+	var req models.LikeCommentReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "LikeCommentHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.LikeComment").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.LikeCommentRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.LikeComment(c.Request.Context(), &comment.LikeCommentRequest{
+		ActorId:    uint32(req.ActorId),
+		CommentId:  uint32(req.CommentId),
+		ActionType: uint32(req.ActionType),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"comment_id": req.CommentId,
+			"actor_id":   req.ActorId,
+		}).Warnf("Error when trying to connect with LikeCommentService")
+		c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"comment_id": req.CommentId,
+		"actor_id":   req.ActorId,
+	}).Infof("Like comment success")
+
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func PinCommentHandler(c *gin.Context) {
+	var req models.PinCommentReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "PinCommentHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.PinComment").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.PinCommentRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.PinComment(c.Request.Context(), &comment.PinCommentRequest{
+		ActorId:   uint32(req.ActorId),
+		VideoId:   uint32(req.VideoId),
+		CommentId: uint32(req.CommentId),
+		Unpin:     req.Unpin,
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"video_id":   req.VideoId,
+			"comment_id": req.CommentId,
+			"actor_id":   req.ActorId,
+		}).Warnf("Error when trying to connect with PinCommentService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
 func CountCommentHandler(c *gin.Context) {
 	var req models.CountCommentReq
 	_, span := tracing.Tracer.Start(c.Request.Context(), "CountCommentHandler")
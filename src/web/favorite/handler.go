@@ -103,3 +103,111 @@ func ListFavoriteHandler(c *gin.Context) {
 
 	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
 }
+
+func ReactHandler(c *gin.Context) {
+	var req models.ReactReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ReactHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.React").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.ReactRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+	if _, ok := favorite.ReactionType_name[int32(req.Reaction)]; !ok {
+		c.JSON(http.StatusOK, models.ReactRes{
+			StatusCode: strings.FavoriteReactionInvalidCode,
+			StatusMsg:  strings.FavoriteReactionInvalid,
+		})
+		return
+	}
+
+	res, err := Client.React(c.Request.Context(), &favorite.ReactRequest{
+		ActorId:  uint32(req.ActorId),
+		VideoId:  uint32(req.VideoId),
+		Reaction: favorite.ReactionType(req.Reaction),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":  req.ActorId,
+			"VideoId":  req.VideoId,
+			"Reaction": req.Reaction,
+		}).Warnf("Error when trying to connect with ReactService")
+		c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+		return
+	}
+
+	logger.WithFields(logrus.Fields{
+		"ActorId":  req.ActorId,
+		"VideoId":  req.VideoId,
+		"Reaction": req.Reaction,
+	}).Infof("React success")
+
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func CountReactionsHandler(c *gin.Context) {
+	var req models.CountReactionsReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "CountReactionsHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.CountReactions").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.CountReactionsRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.CountReactions(c.Request.Context(), &favorite.CountReactionsRequest{
+		ActorId: uint32(req.ActorId),
+		VideoId: uint32(req.VideoId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId": req.ActorId,
+			"VideoId": req.VideoId,
+		}).Warnf("Error when trying to connect with CountReactionsService")
+		c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+		return
+	}
+
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func ListVideoLikersHandler(c *gin.Context) {
+	var req models.ListVideoLikersReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListVideoLikersHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.ListVideoLikers").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil || req.Limit < 0 {
+		c.JSON(http.StatusOK, models.ListVideoLikersRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListVideoLikers(c.Request.Context(), &favorite.ListVideoLikersRequest{
+		ActorId: uint32(req.ActorId),
+		VideoId: uint32(req.VideoId),
+		Cursor:  req.Cursor,
+		Limit:   uint32(req.Limit),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId": req.ActorId,
+			"VideoId": req.VideoId,
+		}).Warnf("Error when trying to connect with ListVideoLikersService")
+	}
+
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
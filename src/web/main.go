@@ -4,14 +4,17 @@ import (
 	"GuGoTik/src/constant/config"
 	"GuGoTik/src/extra/profiling"
 	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/storage/redis"
 	"GuGoTik/src/utils/logging"
 	"GuGoTik/src/web/about"
 	"GuGoTik/src/web/auth"
+	collection2 "GuGoTik/src/web/collection"
 	comment2 "GuGoTik/src/web/comment"
 	favorite2 "GuGoTik/src/web/favorite"
 	feed2 "GuGoTik/src/web/feed"
 	message2 "GuGoTik/src/web/message"
 	"GuGoTik/src/web/middleware"
+	moderation2 "GuGoTik/src/web/moderation"
 	publish2 "GuGoTik/src/web/publish"
 	relation2 "GuGoTik/src/web/relation"
 	user2 "GuGoTik/src/web/user"
@@ -41,6 +44,15 @@ func main() {
 		}
 	}()
 
+	// 网关只使用 Redis 记录用户最近的写入，用于读自己的写入
+	redisClient, err := redis.New()
+	if err != nil {
+		logging.Logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Panicf("Error to create the redis client")
+	}
+	redis.Client = redisClient
+
 	g := gin.Default()
 	// Configure Prometheus
 	p := ginprometheus.NewPrometheus("GuGoTik-WebGateway")
@@ -50,6 +62,7 @@ func main() {
 	// Configure Tracing
 	g.Use(otelgin.Middleware(config.WebServiceName))
 	g.Use(middleware.TokenAuthMiddleware())
+	g.Use(middleware.ReadYourWritesMiddleware())
 	g.Use(middleware.RateLimiterMiddleWare(time.Second, 1000, 1000))
 
 	// Configure Pyroscope
@@ -63,6 +76,7 @@ func main() {
 	user := rootPath.Group("/user")
 	{
 		user.GET("/", user2.UserHandler)
+		user.POST("/privacy/", user2.PrivacyHandler)
 		user.POST("/login/", auth.LoginHandle)
 		user.POST("/register/", auth.RegisterHandle)
 	}
@@ -74,6 +88,9 @@ func main() {
 	{
 		comment.POST("/action/", comment2.ActionCommentHandler)
 		comment.GET("/list/", comment2.ListCommentHandler)
+		comment.GET("/replies/", comment2.ListRepliesHandler)
+		comment.POST("/like/", comment2.LikeCommentHandler)
+		comment.POST("/pin/", comment2.PinCommentHandler)
 		comment.GET("/count/", comment2.CountCommentHandler)
 	}
 	relation := rootPath.Group("/relation")
@@ -88,6 +105,13 @@ func main() {
 		relation.GET("/follow/count/", relation2.CountFollowHandler)
 		relation.GET("/follower/count/", relation2.CountFollowerHandler)
 		relation.GET("/isFollow/", relation2.IsFollowHandler)
+		relation.POST("/block/", relation2.BlockHandler)
+		relation.POST("/unblock/", relation2.UnblockHandler)
+		relation.GET("/block/list/", relation2.ListBlockedHandler)
+		relation.POST("/mute/", relation2.MuteHandler)
+		relation.GET("/request/list/", relation2.ListFollowRequestsHandler)
+		relation.POST("/request/accept/", relation2.AcceptFollowRequestHandler)
+		relation.POST("/request/reject/", relation2.RejectFollowRequestHandler)
 	}
 
 	publish := rootPath.Group("/publish")
@@ -105,6 +129,28 @@ func main() {
 	{
 		favorite.POST("/action/", favorite2.ActionFavoriteHandler)
 		favorite.GET("/list/", favorite2.ListFavoriteHandler)
+		favorite.POST("/reaction/", favorite2.ReactHandler)
+		favorite.GET("/reaction/count/", favorite2.CountReactionsHandler)
+		favorite.GET("/likers/", favorite2.ListVideoLikersHandler)
+	}
+	collection := rootPath.Group("/collection")
+	{
+		collection.POST("/create/", collection2.CreateCollectionHandler)
+		collection.POST("/update/", collection2.UpdateCollectionHandler)
+		collection.POST("/delete/", collection2.DeleteCollectionHandler)
+		collection.GET("/list/", collection2.ListCollectionsHandler)
+		collection.POST("/video/add/", collection2.AddVideoHandler)
+		collection.POST("/video/remove/", collection2.RemoveVideoHandler)
+		collection.POST("/video/move/", collection2.MoveVideoHandler)
+		collection.GET("/video/list/", collection2.ListCollectionVideosHandler)
+	}
+	moderation := rootPath.Group("/moderation")
+	{
+		moderation.GET("/pending/", moderation2.ListPendingHandler)
+		moderation.POST("/approve/", moderation2.ApproveHandler)
+		moderation.POST("/reject/", moderation2.RejectHandler)
+		moderation.POST("/ban/", moderation2.BanUserHandler)
+		moderation.GET("/history/", moderation2.ListHistoryHandler)
 	}
 	// Run Server
 	if err := g.Run(config.WebServiceAddr); err != nil {
@@ -26,6 +26,7 @@ func TokenAuthMiddleware() gin.HandlerFunc {
 		if c.Request.URL.Path == "/douyin/user/login/" ||
 			c.Request.URL.Path == "/douyin/user/register/" ||
 			c.Request.URL.Path == "/douyin/comment/list/" ||
+			c.Request.URL.Path == "/douyin/comment/replies/" ||
 			c.Request.URL.Path == "/douyin/publish/list/" ||
 			c.Request.URL.Path == "/douyin/favorite/list/" {
 			c.Request.URL.RawQuery += "&actor_id=" + config.EnvCfg.AnonymityUser
@@ -46,7 +47,9 @@ func TokenAuthMiddleware() gin.HandlerFunc {
 
 		if token == "" && (c.Request.URL.Path == "/douyin/feed/" ||
 			c.Request.URL.Path == "/douyin/relation/follow/list/" ||
-			c.Request.URL.Path == "/douyin/relation/follower/list/") {
+			c.Request.URL.Path == "/douyin/relation/follower/list/" ||
+			c.Request.URL.Path == "/douyin/collection/list/" ||
+			c.Request.URL.Path == "/douyin/collection/video/list/") {
 			c.Request.URL.RawQuery += "&actor_id=" + config.EnvCfg.AnonymityUser
 			span.SetAttributes(attribute.String("mark_url", c.Request.URL.String()))
 			logger.WithFields(logrus.Fields{
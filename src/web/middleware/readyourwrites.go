@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/storage/database"
+	"GuGoTik/src/utils/logging"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+	"strconv"
+)
+
+// ReadYourWritesMiddleware 用户写入数据之后的一段时间内，该用户的请求都会读主库，避免从延迟的副本读不到自己刚刚的写入
+func ReadYourWritesMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// 读主库的标记只能由服务端设置，丢弃客户端随请求头传入的标记
+		ctx := database.WithoutPrimary(c.Request.Context())
+		c.Request = c.Request.WithContext(ctx)
+
+		// 鉴权中间件在 Query 的末尾追加了 actor_id，取最后一个值
+		actorIds := c.Request.URL.Query()["actor_id"]
+		if len(actorIds) == 0 || actorIds[len(actorIds)-1] == config.EnvCfg.AnonymityUser {
+			c.Next()
+			return
+		}
+		actorId, err := strconv.ParseUint(actorIds[len(actorIds)-1], 10, 32)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		if database.RecentlyWrote(ctx, uint32(actorId)) {
+			c.Request = c.Request.WithContext(database.WithPrimary(ctx))
+		}
+
+		c.Next()
+
+		if c.Request.Method == http.MethodPost && c.Writer.Status() == http.StatusOK {
+			if err := database.MarkWrite(ctx, uint32(actorId)); err != nil {
+				logging.LogService("GateWay.ReadYourWritesMiddleWare").WithContext(ctx).WithFields(logrus.Fields{
+					"err":     err,
+					"actorId": actorId,
+				}).Warnf("Failed to mark the recent write")
+			}
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"GuGoTik/src/rpc/collection"
+	"GuGoTik/src/rpc/feed"
+)
+
+type CreateCollectionReq struct {
+	Token   string `form:"token" binding:"required"`
+	ActorId int    `form:"actor_id"`
+	Name    string `form:"name" binding:"required"`
+	Private bool   `form:"private"`
+}
+
+type UpdateCollectionReq struct {
+	Token        string  `form:"token" binding:"required"`
+	ActorId      int     `form:"actor_id"`
+	CollectionId int     `form:"collection_id" binding:"required"`
+	Name         *string `form:"name"`    // 不传时不修改
+	Private      *bool   `form:"private"` // 不传时不修改
+}
+
+type CollectionRes struct {
+	StatusCode int                    `json:"status_code"`
+	StatusMsg  string                 `json:"status_msg"`
+	Collection *collection.Collection `json:"collection"`
+}
+
+type DeleteCollectionReq struct {
+	Token        string `form:"token" binding:"required"`
+	ActorId      int    `form:"actor_id"`
+	CollectionId int    `form:"collection_id" binding:"required"`
+}
+
+type CollectionVideoReq struct {
+	Token        string `form:"token" binding:"required"`
+	ActorId      int    `form:"actor_id"`
+	CollectionId int    `form:"collection_id" binding:"required"`
+	VideoId      int    `form:"video_id" binding:"required"`
+}
+
+type MoveCollectionVideoReq struct {
+	Token        string `form:"token" binding:"required"`
+	ActorId      int    `form:"actor_id"`
+	CollectionId int    `form:"collection_id" binding:"required"`
+	VideoId      int    `form:"video_id" binding:"required"`
+	AfterVideoId int    `form:"after_video_id"` // 为 0 时移动到最前面
+}
+
+type CollectionActionRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
+
+type ListCollectionsReq struct {
+	Token   string `form:"token"`
+	ActorId int    `form:"actor_id"`
+	UserId  int    `form:"user_id" binding:"required"`
+}
+
+type ListCollectionsRes struct {
+	StatusCode     int                      `json:"status_code"`
+	StatusMsg      string                   `json:"status_msg"`
+	CollectionList []*collection.Collection `json:"collection_list"`
+}
+
+type ListCollectionVideosReq struct {
+	Token        string `form:"token"`
+	ActorId      int    `form:"actor_id"`
+	CollectionId int    `form:"collection_id" binding:"required"`
+	Cursor       *int64 `form:"cursor"`
+	Limit        int    `form:"limit"`
+}
+
+type ListCollectionVideosRes struct {
+	StatusCode int           `json:"status_code"`
+	StatusMsg  string        `json:"status_msg"`
+	VideoList  []*feed.Video `json:"video_list"`
+	NextCursor *int64        `json:"next_cursor"`
+}
@@ -6,9 +6,10 @@ type ActionCommentReq struct {
 	Token       string `form:"token" binding:"required"`
 	ActorId     int    `form:"actor_id"`
 	VideoId     int    `form:"video_id" binding:"-"`
-	ActionType  int    `form:"action_type" binding:"required"` // 1-发布评论，2-删除评论
-	CommentText string `form:"comment_text"`                   // 用户填写的评论内容，在action_type=1的时候使用
-	CommentId   int    `form:"comment_id"`                     // 要删除的评论id，在action_type=2的时候使用
+	ActionType  int    `form:"action_type" binding:"required"` // 1-发布评论，2-删除评论，3-编辑评论
+	CommentText string `form:"comment_text"`                   // 用户填写的评论内容，在action_type=1或3的时候使用
+	CommentId   int    `form:"comment_id"`                     // 要删除或编辑的评论id，在action_type=2或3的时候使用
+	ParentId    int    `form:"parent_id"`                      // 回复的评论id，在action_type=1的时候使用，为 0 时发布顶层评论
 }
 
 type ActionCommentRes struct {
@@ -21,6 +22,7 @@ type ListCommentReq struct {
 	Token   string `form:"token"`
 	ActorId int    `form:"actor_id"`
 	VideoId int    `form:"video_id" binding:"-"`
+	Sort    string `form:"sort" binding:"omitempty,oneof=new hot"` // new-按时间倒序（默认），hot-按热度倒序
 }
 
 type ListCommentRes struct {
@@ -29,6 +31,47 @@ type ListCommentRes struct {
 	CommentList []*comment.Comment `json:"comment_list"`
 }
 
+type ListRepliesReq struct {
+	Token     string  `form:"token"`
+	ActorId   int     `form:"actor_id"`
+	CommentId int     `form:"comment_id" binding:"required"`
+	Cursor    *uint32 `form:"cursor"`
+	Limit     int     `form:"limit"`
+}
+
+type ListRepliesRes struct {
+	StatusCode  int                `json:"status_code"`
+	StatusMsg   string             `json:"status_msg"`
+	CommentList []*comment.Comment `json:"comment_list"`
+	NextCursor  *uint32            `json:"next_cursor"`
+}
+
+type LikeCommentReq struct {
+	Token      string `form:"token" binding:"required"`
+	ActorId    int    `form:"actor_id"`
+	CommentId  int    `form:"comment_id" binding:"required"`
+	ActionType int    `form:"action_type" binding:"required,oneof=1 2"` // 1-点赞，2-取消点赞
+}
+
+type LikeCommentRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+	LikeCount  int    `json:"like_count"`
+}
+
+type PinCommentReq struct {
+	Token     string `form:"token" binding:"required"`
+	ActorId   int    `form:"actor_id"`
+	VideoId   int    `form:"video_id" binding:"required"`
+	CommentId int    `form:"comment_id" binding:"required_without=Unpin"` // 要置顶的顶层评论id
+	Unpin     bool   `form:"unpin"`                                       // 为 true 时取消置顶
+}
+
+type PinCommentRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
+
 type CountCommentReq struct {
 	Token   string `form:"token"`
 	ActorId int    `form:"actor_id"`
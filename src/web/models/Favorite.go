@@ -1,7 +1,9 @@
 package models
 
 import (
+	"GuGoTik/src/rpc/favorite"
 	"GuGoTik/src/rpc/feed"
+	"GuGoTik/src/rpc/user"
 )
 
 type ActionFavoriteReq struct {
@@ -27,3 +29,43 @@ type ListFavoriteRes struct {
 	StatusMsg  string        `json:"status_msg"`
 	VideoList  []*feed.Video `json:"video_list"`
 }
+
+type ReactReq struct {
+	Token    string `form:"token" binding:"required"`
+	ActorId  int    `form:"actor_id" binding:"required"`
+	VideoId  int    `form:"video_id" binding:"required"`
+	Reaction int    `form:"reaction"` // 0 表示撤销回应
+}
+
+type ReactRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
+
+type CountReactionsReq struct {
+	Token   string `form:"token"`
+	ActorId int    `form:"actor_id"`
+	VideoId int    `form:"video_id" binding:"required"`
+}
+
+type CountReactionsRes struct {
+	StatusCode    int                       `json:"status_code"`
+	StatusMsg     string                    `json:"status_msg"`
+	Counts        []*favorite.ReactionCount `json:"counts"`
+	ActorReaction uint32                    `json:"actor_reaction"`
+}
+
+type ListVideoLikersReq struct {
+	Token   string  `form:"token" binding:"required"`
+	ActorId int     `form:"actor_id"`
+	VideoId int     `form:"video_id" binding:"required"`
+	Cursor  *uint32 `form:"cursor"`
+	Limit   int     `form:"limit"`
+}
+
+type ListVideoLikersRes struct {
+	StatusCode int          `json:"status_code"`
+	StatusMsg  string       `json:"status_msg"`
+	UserList   []*user.User `json:"user_list"`
+	NextCursor *uint32      `json:"next_cursor"`
+}
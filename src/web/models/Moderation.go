@@ -0,0 +1,59 @@
+package models
+
+import "GuGoTik/src/rpc/moderation"
+
+type ListPendingReq struct {
+	Token   string  `form:"token" binding:"required"`
+	ActorId int     `form:"actor_id"`
+	Type    string  `form:"type" binding:"omitempty,oneof=comment video message"` // 不传时返回全部类型
+	Cursor  *uint32 `form:"cursor"`
+	Limit   int     `form:"limit"`
+}
+
+type ListPendingRes struct {
+	StatusCode int                      `json:"status_code"`
+	StatusMsg  string                   `json:"status_msg"`
+	Items      []*moderation.ReviewItem `json:"items"`
+	NextCursor *uint32                  `json:"next_cursor"`
+}
+
+type ApproveReq struct {
+	Token    string `form:"token" binding:"required"`
+	ActorId  int    `form:"actor_id"`
+	ReviewId int    `form:"review_id" binding:"required"`
+}
+
+type RejectReq struct {
+	Token    string `form:"token" binding:"required"`
+	ActorId  int    `form:"actor_id"`
+	ReviewId int    `form:"review_id" binding:"required"`
+	Reason   string `form:"reason" binding:"required"`
+}
+
+type BanUserReq struct {
+	Token         string `form:"token" binding:"required"`
+	ActorId       int    `form:"actor_id"`
+	UserId        int    `form:"user_id" binding:"required"`
+	DurationHours int    `form:"duration_hours"` // 为 0 时永久封禁
+	Reason        string `form:"reason" binding:"required"`
+}
+
+type ModerationActionRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
+
+type ListHistoryReq struct {
+	Token   string  `form:"token" binding:"required"`
+	ActorId int     `form:"actor_id"`
+	UserId  *uint32 `form:"user_id"`
+	Cursor  *uint64 `form:"cursor"`
+	Limit   int     `form:"limit"`
+}
+
+type ListHistoryRes struct {
+	StatusCode int                            `json:"status_code"`
+	StatusMsg  string                         `json:"status_msg"`
+	Actions    []*moderation.ModerationAction `json:"actions"`
+	NextCursor *uint64                        `json:"next_cursor"`
+}
@@ -88,3 +88,59 @@ type IsFollowReq struct {
 type IsFollowRes struct {
 	Result bool `json:"result"`
 }
+
+type BlockReq struct {
+	Token   string `form:"token" binding:"required"`
+	ActorId int    `form:"actor_id"`   // 用户id
+	UserId  int    `form:"to_user_id"` // 对方用户id
+}
+
+type BlockRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
+
+type ListBlockedReq struct {
+	Token   string `form:"token" binding:"required"`
+	ActorId int    `form:"actor_id"`
+}
+
+type ListBlockedRes struct {
+	StatusCode int          `json:"status_code"`
+	StatusMsg  string       `json:"status_msg"`
+	UserList   []*user.User `json:"user_list"`
+}
+
+type MuteReq struct {
+	Token   string `form:"token" binding:"required"`
+	ActorId int    `form:"actor_id"`   // 用户id
+	UserId  int    `form:"to_user_id"` // 对方用户id
+	Unmute  bool   `form:"unmute"`     // 为 true 时取消屏蔽
+}
+
+type MuteRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
+
+type FollowRequestListReq struct {
+	Token   string `form:"token" binding:"required"`
+	ActorId int    `form:"actor_id"`
+}
+
+type FollowRequestListRes struct {
+	StatusCode int          `json:"status_code"`
+	StatusMsg  string       `json:"status_msg"`
+	UserList   []*user.User `json:"user_list"`
+}
+
+type FollowRequestActionReq struct {
+	Token   string `form:"token" binding:"required"`
+	ActorId int    `form:"actor_id"`   // 用户id
+	UserId  int    `form:"to_user_id"` // 发出关注请求的用户id
+}
+
+type FollowRequestActionRes struct {
+	StatusCode int    `json:"status_code"`
+	StatusMsg  string `json:"status_msg"`
+}
@@ -9,3 +9,13 @@ type UserRes struct {
 	StatusCode int32  `json:"status_code"` // 状态码，0-成功，其他值-失败
 	StatusMsg  string `json:"status_msg"`  // 返回状态描述
 }
+
+type PrivacyReq struct {
+	ActorId uint32 `form:"actor_id" binding:"required"`
+	Private bool   `form:"private"` // true-私密账号，false-公开账号
+}
+
+type PrivacyRes struct {
+	StatusCode int32  `json:"status_code"` // 状态码，0-成功，其他值-失败
+	StatusMsg  string `json:"status_msg"`  // 返回状态描述
+}
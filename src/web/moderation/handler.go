@@ -0,0 +1,175 @@
+package moderation
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/extra/tracing"
+	"GuGoTik/src/rpc/moderation"
+	grpc2 "GuGoTik/src/utils/grpc"
+	"GuGoTik/src/utils/logging"
+	"GuGoTik/src/web/models"
+	"GuGoTik/src/web/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+	"net/http"
+)
+
+var Client moderation.ModerationServiceClient
+
+func init() {
+	conn := grpc2.Connect(config.ModerationRpcServerName)
+	Client = moderation.NewModerationServiceClient(conn)
+}
+
+var itemTypes = map[string]moderation.ItemType{
+	"":        moderation.ItemType_ITEM_TYPE_UNSPECIFIED,
+	"comment": moderation.ItemType_ITEM_TYPE_COMMENT,
+	"video":   moderation.ItemType_ITEM_TYPE_VIDEO,
+	"message": moderation.ItemType_ITEM_TYPE_MESSAGE,
+}
+
+func ListPendingHandler(c *gin.Context) {
+	var req models.ListPendingReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListPendingHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.ListPending").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil || req.Limit < 0 {
+		c.JSON(http.StatusOK, models.ListPendingRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListPending(c.Request.Context(), &moderation.ListPendingRequest{
+		ActorId: uint32(req.ActorId),
+		Type:    itemTypes[req.Type],
+		Cursor:  req.Cursor,
+		Limit:   uint32(req.Limit),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId": req.ActorId,
+			"Type":    req.Type,
+		}).Warnf("Error when trying to connect with ListPendingService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func ApproveHandler(c *gin.Context) {
+	var req models.ApproveReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ApproveHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.Approve").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.ModerationActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.Approve(c.Request.Context(), &moderation.ApproveRequest{
+		ActorId:  uint32(req.ActorId),
+		ReviewId: uint32(req.ReviewId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":  req.ActorId,
+			"ReviewId": req.ReviewId,
+		}).Warnf("Error when trying to connect with ApproveService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func RejectHandler(c *gin.Context) {
+	var req models.RejectReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "RejectHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.Reject").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.ModerationActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.Reject(c.Request.Context(), &moderation.RejectRequest{
+		ActorId:  uint32(req.ActorId),
+		ReviewId: uint32(req.ReviewId),
+		Reason:   req.Reason,
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId":  req.ActorId,
+			"ReviewId": req.ReviewId,
+		}).Warnf("Error when trying to connect with RejectService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func BanUserHandler(c *gin.Context) {
+	var req models.BanUserReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "BanUserHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.BanUser").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil || req.DurationHours < 0 {
+		c.JSON(http.StatusOK, models.ModerationActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.BanUser(c.Request.Context(), &moderation.BanUserRequest{
+		ActorId:       uint32(req.ActorId),
+		UserId:        uint32(req.UserId),
+		DurationHours: uint32(req.DurationHours),
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId": req.ActorId,
+			"UserId":  req.UserId,
+		}).Warnf("Error when trying to connect with BanUserService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func ListHistoryHandler(c *gin.Context) {
+	var req models.ListHistoryReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListHistoryHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.ListHistory").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil || req.Limit < 0 {
+		c.JSON(http.StatusOK, models.ListHistoryRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListHistory(c.Request.Context(), &moderation.ListHistoryRequest{
+		ActorId: uint32(req.ActorId),
+		UserId:  req.UserId,
+		Cursor:  req.Cursor,
+		Limit:   uint32(req.Limit),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"ActorId": req.ActorId,
+		}).Warnf("Error when trying to connect with ListHistoryService")
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
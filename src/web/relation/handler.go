@@ -354,3 +354,197 @@ func IsFollowHandler(c *gin.Context) {
 	}).Infof("IsFollow success")
 	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
 }
+
+func BlockHandler(c *gin.Context) {
+	var req models.BlockReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "BlockHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.Block").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.BlockRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.Block(c.Request.Context(), &relation.BlockRequest{
+		ActorId: uint32(req.ActorId),
+		UserId:  uint32(req.UserId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"actor_id": req.ActorId,
+			"user_id":  req.UserId,
+		}).Warnf("BlockService returned an error response: %v", err)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func UnblockHandler(c *gin.Context) {
+	var req models.BlockReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "UnblockHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.Unblock").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.BlockRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.Unblock(c.Request.Context(), &relation.BlockRequest{
+		ActorId: uint32(req.ActorId),
+		UserId:  uint32(req.UserId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"actor_id": req.ActorId,
+			"user_id":  req.UserId,
+		}).Warnf("UnblockService returned an error response: %v", err)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func ListBlockedHandler(c *gin.Context) {
+	var req models.ListBlockedReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListBlockedHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.ListBlocked").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.ListBlockedRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListBlocked(c.Request.Context(), &relation.ListBlockedRequest{
+		ActorId: uint32(req.ActorId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"actor_id": req.ActorId,
+		}).Warnf("ListBlockedService returned an error response: %v", err)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func MuteHandler(c *gin.Context) {
+	var req models.MuteReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "MuteHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.Mute").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.MuteRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.Mute(c.Request.Context(), &relation.MuteRequest{
+		ActorId: uint32(req.ActorId),
+		UserId:  uint32(req.UserId),
+		Unmute:  req.Unmute,
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"actor_id": req.ActorId,
+			"user_id":  req.UserId,
+			"unmute":   req.Unmute,
+		}).Warnf("MuteService returned an error response: %v", err)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func ListFollowRequestsHandler(c *gin.Context) {
+	var req models.FollowRequestListReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "ListFollowRequestsHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.ListFollowRequests").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.FollowRequestListRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.ListFollowRequests(c.Request.Context(), &relation.FollowRequestListRequest{
+		ActorId: uint32(req.ActorId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"actor_id": req.ActorId,
+		}).Warnf("ListFollowRequestsService returned an error response: %v", err)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func AcceptFollowRequestHandler(c *gin.Context) {
+	var req models.FollowRequestActionReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "AcceptFollowRequestHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.AcceptFollowRequest").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.FollowRequestActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.AcceptFollowRequest(c.Request.Context(), &relation.RelationActionRequest{
+		ActorId: uint32(req.ActorId),
+		UserId:  uint32(req.UserId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"actor_id": req.ActorId,
+			"user_id":  req.UserId,
+		}).Warnf("AcceptFollowRequestService returned an error response: %v", err)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
+
+func RejectFollowRequestHandler(c *gin.Context) {
+	var req models.FollowRequestActionReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "RejectFollowRequestHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.RejectFollowRequest").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.FollowRequestActionRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		return
+	}
+
+	res, err := Client.RejectFollowRequest(c.Request.Context(), &relation.RelationActionRequest{
+		ActorId: uint32(req.ActorId),
+		UserId:  uint32(req.UserId),
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"actor_id": req.ActorId,
+			"user_id":  req.UserId,
+		}).Warnf("RejectFollowRequestService returned an error response: %v", err)
+	}
+	c.Render(http.StatusOK, utils.CustomJSON{Data: res, Context: c})
+}
@@ -54,3 +54,33 @@ func UserHandler(c *gin.Context) {
 
 	c.Render(http.StatusOK, utils.CustomJSON{Data: resp, Context: c})
 }
+
+func PrivacyHandler(c *gin.Context) {
+	var req models.PrivacyReq
+	_, span := tracing.Tracer.Start(c.Request.Context(), "PrivacyHandler")
+	defer span.End()
+	logging.SetSpanWithHostname(span)
+	logger := logging.LogService("GateWay.Privacy").WithContext(c.Request.Context())
+
+	if err := c.ShouldBindQuery(&req); err != nil {
+		c.JSON(http.StatusOK, models.PrivacyRes{
+			StatusCode: strings.GateWayParamsErrorCode,
+			StatusMsg:  strings.GateWayParamsError,
+		})
+		logging.SetSpanError(span, err)
+		return
+	}
+
+	resp, err := userClient.SetPrivacy(c.Request.Context(), &user.PrivacyRequest{
+		ActorId: req.ActorId,
+		Private: req.Private,
+	})
+	if err != nil {
+		logger.WithFields(logrus.Fields{
+			"err": err,
+		}).Errorf("Error when gateway set privacy through User Service")
+		logging.SetSpanError(span, err)
+	}
+
+	c.Render(http.StatusOK, utils.CustomJSON{Data: resp, Context: c})
+}
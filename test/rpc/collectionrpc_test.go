@@ -0,0 +1,87 @@
+package rpc
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/rpc/collection"
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"testing"
+)
+
+var collectionClient collection.CollectionServiceClient
+
+func setupCollection() {
+	conn, _ := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.CollectionRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	collectionClient = collection.NewCollectionServiceClient(conn)
+}
+
+func TestCollection(t *testing.T) {
+	setupCollection()
+	ctx := context.Background()
+
+	created, err := collectionClient.CreateCollection(ctx, &collection.CreateCollectionRequest{
+		ActorId: 1,
+		Name:    "test-" + uuid.NewString()[:8],
+		Private: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), created.StatusCode)
+	id := created.Collection.Id
+	defer func() {
+		_, _ = collectionClient.DeleteCollection(ctx, &collection.DeleteCollectionRequest{ActorId: 1, CollectionId: id})
+	}()
+
+	for _, videoId := range []uint32{1, 2, 3} {
+		res, err := collectionClient.AddVideo(ctx, &collection.CollectionVideoRequest{ActorId: 1, CollectionId: id, VideoId: videoId})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), res.StatusCode)
+	}
+	res, err := collectionClient.AddVideo(ctx, &collection.CollectionVideoRequest{ActorId: 1, CollectionId: id, VideoId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(strings.CollectionVideoExistedCode), res.StatusCode)
+
+	// 新收藏的视频在最前面，当前顺序为 3 2 1，将 3 移动到 1 之后
+	res, err = collectionClient.MoveVideo(ctx, &collection.MoveCollectionVideoRequest{ActorId: 1, CollectionId: id, VideoId: 3, AfterVideoId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+
+	page, err := collectionClient.ListCollectionVideos(ctx, &collection.ListCollectionVideosRequest{ActorId: 1, CollectionId: id, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), page.StatusCode)
+	assert.NotNil(t, page.NextCursor)
+	next, err := collectionClient.ListCollectionVideos(ctx, &collection.ListCollectionVideosRequest{ActorId: 1, CollectionId: id, Cursor: page.NextCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Nil(t, next.NextCursor)
+	var order []uint32
+	for _, video := range append(page.VideoList, next.VideoList...) {
+		order = append(order, video.Id)
+	}
+	assert.Equal(t, []uint32{2, 1, 3}, order)
+
+	// 私密收藏夹对其他用户不可见
+	hidden, err := collectionClient.ListCollectionVideos(ctx, &collection.ListCollectionVideosRequest{ActorId: 2, CollectionId: id})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(strings.CollectionNotFoundCode), hidden.StatusCode)
+
+	list, err := collectionClient.ListCollections(ctx, &collection.ListCollectionsRequest{ActorId: 1, UserId: 1})
+	assert.NoError(t, err)
+	var found *collection.Collection
+	for _, c := range list.CollectionList {
+		if c.Id == id {
+			found = c
+		}
+	}
+	assert.NotNil(t, found)
+	assert.Equal(t, uint32(3), found.VideoCount)
+
+	res, err = collectionClient.RemoveVideo(ctx, &collection.CollectionVideoRequest{ActorId: 1, CollectionId: id, VideoId: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+}
@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
 	"GuGoTik/src/rpc/comment"
 	"context"
 	"fmt"
@@ -34,6 +35,65 @@ func TestActionComment_Add(t *testing.T) {
 	assert.Equal(t, int32(0), res.StatusCode)
 }
 
+func TestActionComment_Rejected(t *testing.T) {
+	res, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:    1,
+		VideoId:    0,
+		ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD,
+		Action:     &comment.ActionCommentRequest_CommentText{CommentText: "我要 杀 了 你"},
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(strings.CommentRejectedCode), res.StatusCode)
+}
+
+func TestActionComment_Mention(t *testing.T) {
+	// 不存在的用户不会成为 @
+	res, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:    1,
+		VideoId:    1,
+		ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD,
+		Action:     &comment.ActionCommentRequest_CommentText{CommentText: "你好 @no_such_user_for_mention"},
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+	assert.Empty(t, res.Comment.Mentions)
+}
+
+func TestEditComment(t *testing.T) {
+	added, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:    1,
+		VideoId:    1,
+		ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD,
+		Action:     &comment.ActionCommentRequest_CommentText{CommentText: "编辑前的评论"},
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), added.StatusCode)
+	assert.Nil(t, added.Comment.EditedAt)
+
+	edited, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:       1,
+		VideoId:       1,
+		ActionType:    comment.ActionCommentType_ACTION_COMMENT_TYPE_EDIT,
+		Action:        &comment.ActionCommentRequest_CommentText{CommentText: "编辑后的评论"},
+		EditCommentId: added.Comment.Id,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), edited.StatusCode)
+	assert.Equal(t, "编辑后的评论", edited.Comment.Content)
+	assert.NotNil(t, edited.Comment.EditedAt)
+
+	// 其他用户不能编辑
+	res, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:       2,
+		VideoId:       1,
+		ActionType:    comment.ActionCommentType_ACTION_COMMENT_TYPE_EDIT,
+		Action:        &comment.ActionCommentRequest_CommentText{CommentText: "不是我的评论"},
+		EditCommentId: added.Comment.Id,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(strings.ActorIDNotMatchErrorCode), res.StatusCode)
+}
+
 func TestActionComment_Limiter(t *testing.T) {
 	wg := &sync.WaitGroup{}
 	for i := 0; i < 10; i++ {
@@ -77,6 +137,110 @@ func TestListComment(t *testing.T) {
 	assert.Equal(t, int32(0), res.StatusCode)
 }
 
+func TestReplyComment(t *testing.T) {
+	root, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:    1,
+		VideoId:    0,
+		ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD,
+		Action:     &comment.ActionCommentRequest_CommentText{CommentText: "楼主"},
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), root.StatusCode)
+
+	reply, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:    2,
+		VideoId:    0,
+		ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD,
+		Action:     &comment.ActionCommentRequest_CommentText{CommentText: "回复楼主"},
+		ParentId:   root.Comment.Id,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), reply.StatusCode)
+	assert.Equal(t, root.Comment.Id, reply.Comment.RootId)
+
+	replies, err := Client.ListReplies(context.Background(), &comment.ListRepliesRequest{
+		ActorId:   1,
+		CommentId: root.Comment.Id,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), replies.StatusCode)
+	assert.Equal(t, reply.Comment.Id, replies.CommentList[0].Id)
+
+	// 仍有回复的评论删除后只保留占位
+	res, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:    1,
+		VideoId:    0,
+		ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_DELETE,
+		Action:     &comment.ActionCommentRequest_CommentId{CommentId: root.Comment.Id},
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+
+	list, err := Client.ListComment(context.Background(), &comment.ListCommentRequest{
+		ActorId: 1,
+		VideoId: 0,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), list.StatusCode)
+	for _, c := range list.CommentList {
+		if c.Id == root.Comment.Id {
+			assert.True(t, c.Deleted)
+			assert.Equal(t, uint32(1), c.ReplyCount)
+		}
+	}
+}
+
+func TestLikeComment(t *testing.T) {
+	added, err := Client.ActionComment(context.Background(), &comment.ActionCommentRequest{
+		ActorId:    1,
+		VideoId:    0,
+		ActionType: comment.ActionCommentType_ACTION_COMMENT_TYPE_ADD,
+		Action:     &comment.ActionCommentRequest_CommentText{CommentText: "点赞这条评论"},
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), added.StatusCode)
+
+	res, err := Client.LikeComment(context.Background(), &comment.LikeCommentRequest{
+		ActorId:    2,
+		CommentId:  added.Comment.Id,
+		ActionType: 1,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+	assert.Equal(t, uint32(1), res.LikeCount)
+
+	res, err = Client.LikeComment(context.Background(), &comment.LikeCommentRequest{
+		ActorId:    2,
+		CommentId:  added.Comment.Id,
+		ActionType: 1,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(strings.CommentLikeDuplicateCode), res.StatusCode)
+
+	list, err := Client.ListComment(context.Background(), &comment.ListCommentRequest{
+		ActorId: 2,
+		VideoId: 0,
+		Sort:    comment.CommentSort_COMMENT_SORT_HOT,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), list.StatusCode)
+	for _, c := range list.CommentList {
+		if c.Id == added.Comment.Id {
+			assert.True(t, c.IsLiked)
+			assert.Equal(t, uint32(1), c.LikeCount)
+		}
+	}
+
+	res, err = Client.LikeComment(context.Background(), &comment.LikeCommentRequest{
+		ActorId:    2,
+		CommentId:  added.Comment.Id,
+		ActionType: 2,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+	assert.Equal(t, uint32(0), res.LikeCount)
+}
+
 func TestCountComment(t *testing.T) {
 	res, err := Client.CountComment(context.Background(), &comment.CountCommentRequest{
 		ActorId: 1,
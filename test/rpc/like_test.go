@@ -2,9 +2,12 @@ package rpc
 
 import (
 	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
 	"GuGoTik/src/rpc/favorite"
 	"context"
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -84,3 +87,117 @@ func TestCountUserTotalFavorited(t *testing.T) {
 	assert.Equal(t, int32(0), res.StatusCode)
 	assert.Equal(t, uint32(0), res.Count)
 }
+
+func TestFavoriteActionConcurrent(t *testing.T) {
+	setups1()
+	ctx := context.Background()
+	const workers = 32
+	pair := favorite.FavoriteRequest{ActorId: 3, VideoId: 20}
+
+	// 先取消点赞，保证从未点赞的状态开始
+	_, err := likeClient.FavoriteAction(ctx, &favorite.FavoriteRequest{ActorId: pair.ActorId, VideoId: pair.VideoId, ActionType: 2})
+	assert.Empty(t, err)
+	before, err := likeClient.CountFavorite(ctx, &favorite.CountFavoriteRequest{VideoId: pair.VideoId})
+	assert.Empty(t, err)
+
+	hammer := func(actionType uint32) (applied int32) {
+		wg := sync.WaitGroup{}
+		wg.Add(workers)
+		for i := 0; i < workers; i++ {
+			go func() {
+				defer wg.Done()
+				res, err := likeClient.FavoriteAction(ctx, &favorite.FavoriteRequest{ActorId: pair.ActorId, VideoId: pair.VideoId, ActionType: actionType})
+				if err == nil && res.StatusCode == strings.ServiceOKCode {
+					atomic.AddInt32(&applied, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		return
+	}
+
+	// 同一个用户并发点赞同一个视频，只能生效一次
+	assert.Equal(t, int32(1), hammer(1))
+	after, err := likeClient.CountFavorite(ctx, &favorite.CountFavoriteRequest{VideoId: pair.VideoId})
+	assert.Empty(t, err)
+	assert.Equal(t, before.Count+1, after.Count)
+	liked, err := likeClient.IsFavorite(ctx, &favorite.IsFavoriteRequest{ActorId: pair.ActorId, VideoId: pair.VideoId})
+	assert.Empty(t, err)
+	assert.True(t, liked.Result)
+
+	// 并发取消点赞同样只能生效一次，计数回到原值
+	assert.Equal(t, int32(1), hammer(2))
+	after, err = likeClient.CountFavorite(ctx, &favorite.CountFavoriteRequest{VideoId: pair.VideoId})
+	assert.Empty(t, err)
+	assert.Equal(t, before.Count, after.Count)
+	liked, err = likeClient.IsFavorite(ctx, &favorite.IsFavoriteRequest{ActorId: pair.ActorId, VideoId: pair.VideoId})
+	assert.Empty(t, err)
+	assert.False(t, liked.Result)
+}
+
+func TestReact(t *testing.T) {
+	setups1()
+	ctx := context.Background()
+	// 清理之前的测试留下的回应
+	_, _ = likeClient.React(ctx, &favorite.ReactRequest{ActorId: 4, VideoId: 20})
+
+	res, err := likeClient.React(ctx, &favorite.ReactRequest{ActorId: 4, VideoId: 20, Reaction: favorite.ReactionType_LOVE})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+
+	res, err = likeClient.React(ctx, &favorite.ReactRequest{ActorId: 4, VideoId: 20, Reaction: favorite.ReactionType_LOVE})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(strings.FavoriteServiceDuplicateCode), res.StatusCode)
+
+	res, err = likeClient.React(ctx, &favorite.ReactRequest{ActorId: 4, VideoId: 20, Reaction: favorite.ReactionType_HAHA})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+
+	reaction, err := likeClient.GetReaction(ctx, &favorite.GetReactionRequest{ActorId: 4, VideoId: 20})
+	assert.Empty(t, err)
+	assert.Equal(t, favorite.ReactionType_HAHA, reaction.Reaction)
+
+	counts, err := likeClient.CountReactions(ctx, &favorite.CountReactionsRequest{ActorId: 4, VideoId: 20})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), counts.StatusCode)
+	assert.Equal(t, favorite.ReactionType_HAHA, counts.ActorReaction)
+
+	isFavorite, err := likeClient.IsFavorite(ctx, &favorite.IsFavoriteRequest{ActorId: 4, VideoId: 20})
+	assert.Empty(t, err)
+	assert.True(t, isFavorite.Result)
+
+	res, err = likeClient.React(ctx, &favorite.ReactRequest{ActorId: 4, VideoId: 20})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+
+	res, err = likeClient.React(ctx, &favorite.ReactRequest{ActorId: 4, VideoId: 20, Reaction: 42})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(strings.FavoriteReactionInvalidCode), res.StatusCode)
+}
+
+func TestBatchVideoStats(t *testing.T) {
+	setups1()
+	res, err := likeClient.BatchVideoStats(context.Background(), &favorite.BatchVideoStatsRequest{
+		ActorId:  1,
+		VideoIds: []uint32{1, 20, 1},
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+	assert.Len(t, res.Stats, 2)
+	assert.Equal(t, uint32(1), res.Stats[0].VideoId)
+	assert.True(t, res.Stats[0].IsFavorite)
+
+	count, err := likeClient.CountFavorite(context.Background(), &favorite.CountFavoriteRequest{VideoId: 20})
+	assert.Empty(t, err)
+	assert.Equal(t, count.Count, res.Stats[1].FavoriteCount)
+}
+
+func TestListVideoLikers(t *testing.T) {
+	setups1()
+	res, err := likeClient.ListVideoLikers(context.Background(), &favorite.ListVideoLikersRequest{
+		ActorId: 0,
+		VideoId: 1,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(strings.FavoriteLikersForbiddenCode), res.StatusCode)
+}
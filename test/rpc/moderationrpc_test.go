@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"GuGoTik/src/constant/config"
+	"GuGoTik/src/constant/strings"
+	"GuGoTik/src/rpc/moderation"
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"testing"
+)
+
+var moderationClient moderation.ModerationServiceClient
+
+func setupModeration() {
+	conn, _ := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.ModerationRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	moderationClient = moderation.NewModerationServiceClient(conn)
+}
+
+func TestModeration_Forbidden(t *testing.T) {
+	setupModeration()
+	ctx := context.Background()
+
+	// 普通用户不能调用审核接口
+	pending, err := moderationClient.ListPending(ctx, &moderation.ListPendingRequest{
+		ActorId: 1,
+		Type:    moderation.ItemType_ITEM_TYPE_COMMENT,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(strings.ModerationForbiddenCode), pending.StatusCode)
+
+	res, err := moderationClient.BanUser(ctx, &moderation.BanUserRequest{
+		ActorId:       1,
+		UserId:        2,
+		DurationHours: 1,
+		Reason:        "test",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(strings.ModerationForbiddenCode), res.StatusCode)
+
+	history, err := moderationClient.ListHistory(ctx, &moderation.ListHistoryRequest{ActorId: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(strings.ModerationForbiddenCode), history.StatusCode)
+}
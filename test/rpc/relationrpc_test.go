@@ -3,6 +3,7 @@ package rpc
 import (
 	"GuGoTik/src/constant/config"
 	"GuGoTik/src/rpc/relation"
+	"GuGoTik/src/rpc/user"
 	"context"
 	"fmt"
 	"github.com/stretchr/testify/assert"
@@ -158,3 +159,107 @@ func TestGetFriendList(t *testing.T) {
 	assert.Equal(t, int32(0), res.StatusCode)
 
 }
+
+func TestBlock(t *testing.T) {
+	var Client relation.RelationServiceClient
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.RelationRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	assert.Empty(t, err)
+	Client = relation.NewRelationServiceClient(conn)
+
+	res, err := Client.Block(context.Background(), &relation.BlockRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+
+	// 被拉黑的用户不能关注拉黑自己的用户
+	followRes, err := Client.Follow(context.Background(), &relation.RelationActionRequest{
+		ActorId: 4,
+		UserId:  3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10037), followRes.StatusCode)
+
+	blockedRes, err := Client.IsBlocked(context.Background(), &relation.IsBlockedRequest{
+		ActorId: 4,
+		UserId:  3,
+	})
+	assert.NoError(t, err)
+	assert.True(t, blockedRes.Result)
+
+	res, err = Client.Unblock(context.Background(), &relation.BlockRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+}
+
+func TestFollowRequest(t *testing.T) {
+	var Client relation.RelationServiceClient
+	var UserClient user.UserServiceClient
+
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.RelationRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	assert.Empty(t, err)
+	Client = relation.NewRelationServiceClient(conn)
+
+	userConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.UserRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	assert.Empty(t, err)
+	UserClient = user.NewUserServiceClient(userConn)
+
+	privacyRes, err := UserClient.SetPrivacy(context.Background(), &user.PrivacyRequest{
+		ActorId: 4,
+		Private: true,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), privacyRes.StatusCode)
+
+	// 关注私密账号只会发出关注请求，同意后才出现在粉丝列表中
+	followRes, err := Client.Follow(context.Background(), &relation.RelationActionRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), followRes.StatusCode)
+	assert.True(t, followRes.Pending)
+
+	listRes, err := Client.GetFollowerList(context.Background(), &relation.FollowerListRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(10040), listRes.StatusCode)
+
+	acceptRes, err := Client.AcceptFollowRequest(context.Background(), &relation.RelationActionRequest{
+		ActorId: 4,
+		UserId:  3,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), acceptRes.StatusCode)
+
+	listRes, err = Client.GetFollowerList(context.Background(), &relation.FollowerListRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), listRes.StatusCode)
+
+	_, err = Client.Unfollow(context.Background(), &relation.RelationActionRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	_, err = UserClient.SetPrivacy(context.Background(), &user.PrivacyRequest{
+		ActorId: 4,
+		Private: false,
+	})
+	assert.NoError(t, err)
+}
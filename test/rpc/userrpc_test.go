@@ -2,6 +2,7 @@ package rpc
 
 import (
 	"GuGoTik/src/constant/config"
+	"GuGoTik/src/rpc/relation"
 	"GuGoTik/src/rpc/user"
 	"context"
 	"fmt"
@@ -39,3 +40,62 @@ func TestGetUserExistedInfo(t *testing.T) {
 	assert.Empty(t, err)
 	assert.Equal(t, int32(0), res.StatusCode)
 }
+
+func TestGetUserInfos(t *testing.T) {
+	var Client user.UserServiceClient
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.UserRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	assert.Empty(t, err)
+	Client = user.NewUserServiceClient(conn)
+	res, err := Client.GetUserInfos(context.Background(), &user.UsersRequest{
+		UserIds: []uint32{1, 2, 1},
+		ActorId: 1,
+	})
+	assert.Empty(t, err)
+	assert.Equal(t, int32(0), res.StatusCode)
+	assert.Equal(t, 2, len(res.Users))
+}
+
+func TestGetUserInfosBlocked(t *testing.T) {
+	var Client user.UserServiceClient
+	var RelationClient relation.RelationServiceClient
+	conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.UserRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	assert.Empty(t, err)
+	Client = user.NewUserServiceClient(conn)
+
+	relationConn, err := grpc.Dial(fmt.Sprintf("127.0.0.1%s", config.RelationRpcServerPort),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultServiceConfig(`{"loadBalancingPolicy": "round_robin"}`))
+	assert.Empty(t, err)
+	RelationClient = relation.NewRelationServiceClient(relationConn)
+
+	blockRes, err := RelationClient.Block(context.Background(), &relation.BlockRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), blockRes.StatusCode)
+
+	// 拉黑的双方都不能通过批量查询获取对方的信息
+	for _, pair := range [][2]uint32{{3, 4}, {4, 3}} {
+		res, err := Client.GetUserInfos(context.Background(), &user.UsersRequest{
+			UserIds: []uint32{pair[0], pair[1]},
+			ActorId: pair[0],
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, int32(0), res.StatusCode)
+		if assert.Equal(t, 1, len(res.Users)) {
+			assert.Equal(t, pair[0], res.Users[0].Id)
+		}
+	}
+
+	blockRes, err = RelationClient.Unblock(context.Background(), &relation.BlockRequest{
+		ActorId: 3,
+		UserId:  4,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0), blockRes.StatusCode)
+}
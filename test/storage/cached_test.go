@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"GuGoTik/src/storage/cached"
+	"GuGoTik/src/utils/deps"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+type profile struct {
+	Name string
+}
+
+func TestMultiLevelCacheWithFakeDeps(t *testing.T) {
+	fake, err := deps.NewFake()
+	assert.Empty(t, err)
+	defer fake.Close()
+
+	var loads atomic.Int32
+	c := cached.New[uint32, profile]("TestProfile", cached.JSONCodec[profile]{},
+		func(ctx context.Context, key uint32) (profile, bool, error) {
+			loads.Add(1)
+			return profile{Name: "user"}, key != 0, nil
+		}).
+		WithBatchLoader(func(ctx context.Context, keys []uint32) (map[uint32]profile, error) {
+			loads.Add(1)
+			values := make(map[uint32]profile)
+			for _, key := range keys {
+				if key != 0 {
+					values[key] = profile{Name: "user"}
+				}
+			}
+			return values, nil
+		})
+
+	ctx := context.Background()
+	value, ok, err := c.Get(ctx, 1)
+	assert.Empty(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "user", value.Name)
+
+	// 第二次读取由 Redis 提供，不会再次调用 Loader
+	_, ok, err = c.Get(ctx, 1)
+	assert.Empty(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int32(1), loads.Load())
+
+	values, err := c.GetMany(ctx, []uint32{0, 1, 2, 2})
+	assert.Empty(t, err)
+	assert.Equal(t, 2, len(values))
+	assert.Equal(t, int32(2), loads.Load())
+	assert.True(t, fake.Miniredis.Exists("GUGUTIKTestProfile-2"))
+
+	assert.Empty(t, c.Delete(ctx, 2))
+	assert.False(t, fake.Miniredis.Exists("GUGUTIKTestProfile-2"))
+}
+
+func TestGetWithFuncMiss(t *testing.T) {
+	fake, err := deps.NewFake()
+	assert.Empty(t, err)
+	defer fake.Close()
+
+	var loads atomic.Int32
+	load := func(ctx context.Context, key string) (string, error) {
+		loads.Add(1)
+		if key == "TestCount-0" {
+			return "", cached.ErrNotFound
+		}
+		return "1", nil
+	}
+
+	ctx := context.Background()
+	value, err := cached.GetWithFunc(ctx, "TestCount-1", load)
+	assert.Empty(t, err)
+	assert.Equal(t, "1", value)
+
+	// 第二次读取命中缓存，不会再次调用 Func
+	value, err = cached.GetWithFunc(ctx, "TestCount-1", load)
+	assert.Empty(t, err)
+	assert.Equal(t, "1", value)
+	assert.Equal(t, int32(1), loads.Load())
+
+	_, err = cached.GetWithFunc(ctx, "TestCount-0", load)
+	assert.ErrorIs(t, err, cached.ErrNotFound)
+	_, err = cached.GetWithFunc(ctx, "TestCount-0", load)
+	assert.ErrorIs(t, err, cached.ErrNotFound)
+	assert.Equal(t, int32(2), loads.Load())
+}
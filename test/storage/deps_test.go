@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"GuGoTik/src/models"
+	"GuGoTik/src/utils/deps"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"testing"
+)
+
+func TestFakeDatabase(t *testing.T) {
+	fake, err := deps.NewFake()
+	assert.Empty(t, err)
+	defer fake.Close()
+
+	assert.Empty(t, fake.DB.AutoMigrate(&models.ContentObject{}))
+	assert.Empty(t, fake.DB.Create(&models.ContentObject{Checksum: "sum", FileName: "sum.mp4", RefCount: 1}).Error)
+	assert.Empty(t, fake.DB.Model(&models.ContentObject{}).
+		Where("checksum = ?", "sum").
+		Update("ref_count", gorm.Expr("ref_count + 1")).Error)
+
+	var object models.ContentObject
+	assert.Empty(t, fake.DB.Where("checksum = ?", "sum").Take(&object).Error)
+	assert.Equal(t, int64(2), object.RefCount)
+
+	// 每个 Fake 使用独立的数据库
+	other, err := deps.NewFake()
+	assert.Empty(t, err)
+	defer other.Close()
+	assert.False(t, other.DB.Migrator().HasTable(&models.ContentObject{}))
+}